@@ -17,6 +17,8 @@
 package account
 
 import (
+	"bytes"
+
 	"github.com/zipper-project/zipper/common/crypto"
 	"github.com/zipper-project/zipper/common/utils"
 )
@@ -41,9 +43,12 @@ type Account struct {
 	Address   Address
 }
 
-// Serialize returns the serialized res of an account var
+// Serialize returns the serialized res of an account var. Accounts aren't hashed,
+// so the keystore keeps the legacy encoding which handles the public key
 func (a *Account) Serialize() []byte {
-	return utils.Serialize(a)
+	buf := new(bytes.Buffer)
+	utils.VarEncode(buf, a)
+	return buf.Bytes()
 }
 
 // Deserialize restore an account var from an serialized bytes
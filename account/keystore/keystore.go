@@ -52,12 +52,12 @@ const (
 // KeyStore definition
 type KeyStore struct {
 	storage keyStore
-	db      *db.BlockchainDB
+	db      db.Database
 	ksDir   string
 }
 
 // NewKeyStore new a KeyStore instance
-func NewKeyStore(db *db.BlockchainDB, keydir string, scryptN, scryptP int) *KeyStore {
+func NewKeyStore(db db.Database, keydir string, scryptN, scryptP int) *KeyStore {
 	once.Do(func() {
 		keydir, err := filepath.Abs(keydir)
 		if err != nil {
@@ -71,7 +71,7 @@ func NewKeyStore(db *db.BlockchainDB, keydir string, scryptN, scryptP int) *KeyS
 }
 
 // NewPlaintextKeyStore new a PlaintextKeyStore instance
-func NewPlaintextKeyStore(db *db.BlockchainDB, keydir string) *KeyStore {
+func NewPlaintextKeyStore(db db.Database, keydir string) *KeyStore {
 	once.Do(func() {
 		keydir, err := filepath.Abs(keydir)
 		if err != nil {
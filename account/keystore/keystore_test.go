@@ -114,7 +114,7 @@ func tmpKeyStore(t *testing.T, encrypted bool) (string, *KeyStore) {
 		MaxLogFileSize:    10485760,
 		LogLevel:          "warn",
 	}
-	db := db.NewDB(testConfig)
+	db := db.NewMemDB(testConfig)
 	if encrypted {
 		return d, NewKeyStore(db, d, veryLightScryptN, veryLightScryptP)
 	}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/willf/bloom"
 	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/blockchain/event"
 	"github.com/zipper-project/zipper/blockchain/validator"
 	"github.com/zipper-project/zipper/common/crypto"
 	"github.com/zipper-project/zipper/common/db"
@@ -32,9 +33,11 @@ import (
 	"github.com/zipper-project/zipper/config"
 	"github.com/zipper-project/zipper/consensus"
 	"github.com/zipper-project/zipper/consensus/consenter"
+	"github.com/zipper-project/zipper/coordinate"
 	"github.com/zipper-project/zipper/ledger"
 	"github.com/zipper-project/zipper/ledger/balance"
 	"github.com/zipper-project/zipper/ledger/state"
+	"github.com/zipper-project/zipper/params"
 	"github.com/zipper-project/zipper/peer"
 	p2p "github.com/zipper-project/zipper/peer/proto"
 	"github.com/zipper-project/zipper/proto"
@@ -76,29 +79,28 @@ var (
 )
 
 // load loads local blockchain data
-func (bc *Blockchain) load() {
+func (bc *Blockchain) load() error {
 	t := time.Now()
-	bc.ledger.VerifyChain()
+	if err := bc.ledger.VerifyChain(); err != nil {
+		return fmt.Errorf("VerifyChain -- %s", err)
+	}
 	delay := time.Since(t)
 
 	height, err := bc.ledger.Height()
-
 	if err != nil {
-		log.Error("GetBlockHeight error", err)
-		return
+		return fmt.Errorf("GetBlockHeight error %v", err)
 	}
 	bc.currentBlockHeader, err = bc.ledger.GetBlockByNumber(height)
-
 	if bc.currentBlockHeader == nil || err != nil {
-		log.Errorf("GetBlockByNumber error %v ", err)
-		panic(err)
+		return fmt.Errorf("GetBlockByNumber error %v", err)
 	}
 
 	log.Debugf("Load blockchain data, bestblockhash: %s height: %d load delay : %v ", bc.currentBlockHeader.Hash(), height, delay)
+	return nil
 }
 
 // NewBlockchain returns a fully initialised blockchain service using input data
-func NewBlockchain(pm peer.IProtocolManager) *Blockchain {
+func NewBlockchain(pm peer.IProtocolManager) (*Blockchain, error) {
 	bc := &Blockchain{
 		mu:                 sync.Mutex{},
 		wg:                 sync.WaitGroup{},
@@ -113,17 +115,54 @@ func NewBlockchain(pm peer.IProtocolManager) *Blockchain {
 
 	log.Debugf("start: ledger.NewLedger...")
 	bc.ledger = ledger.NewLedger(chainDb)
-	bc.load()
+	if err := bc.load(); err != nil {
+		return nil, err
+	}
+
+	// blocks carry the chain coordinate of the genesis
+	if genesis, err := bc.ledger.Genesis(); err != nil {
+		log.Errorf("Genesis error %v", err)
+	} else if genesis.ChainID != "" {
+		params.ChainID = coordinate.HexToChainCoordinate(genesis.ChainID)
+	}
 
 	log.Debugf("start: consenter.NewConsenter...")
-	bc.consenter = consenter.NewConsenter(config.ConsenterOptions(), bc)
+	consenterOption, err := bc.consenterOptions()
+	if err != nil {
+		return nil, err
+	}
+	bc.consenter = consenter.NewConsenter(consenterOption, bc)
 
 	bc.validator = validator.NewVerification(config.ValidatorConfig(), bc.ledger, bc.consenter)
 
 	log.Debugf("start: peer.NewServer...")
-	bc.server = peer.NewServer(config.ServerOption(), pm)
+	genesis, err := bc.ledger.GetGenesisBlock()
+	if err != nil {
+		return nil, err
+	}
+	option := config.ServerOption()
+	option.GenesisHash = genesis.Hash().Bytes()
+	bc.server = peer.NewServer(option, pm)
 
-	return bc
+	return bc, nil
+}
+
+// consenterOptions returns the configured consenter options, the validators of the genesis are the scip replicas
+func (bc *Blockchain) consenterOptions() (*consenter.Options, error) {
+	option := config.ConsenterOptions()
+	validators, err := bc.ledger.GetValidators()
+	if err != nil || len(validators) == 0 {
+		return option, err
+	}
+	if option.Scip.Q > len(validators) {
+		return nil, fmt.Errorf("scip quorum %d exceeds the %d genesis validators", option.Scip.Q, len(validators))
+	}
+	option.Scip.N = len(validators)
+	option.Scip.Validators = make([]string, len(validators))
+	for i, nodeID := range validators {
+		option.Scip.Validators[i] = config.SCIPReplicaID(option.Scip.Chain, nodeID)
+	}
+	return option, nil
 }
 
 func (bc *Blockchain) Start() {
@@ -200,6 +239,11 @@ func (bc *Blockchain) GetLedger() *ledger.Ledger {
 	return bc.ledger
 }
 
+// Events returns the event bus of the blockchain
+func (bc *Blockchain) Events() *event.Bus {
+	return bc.ledger.Events()
+}
+
 // CurrentBlockHash returns current block hash of the current block
 func (bc *Blockchain) CurrentBlockHash() crypto.Hash {
 	return bc.currentBlockHeader.Hash()
@@ -237,6 +281,14 @@ func (bc *Blockchain) GetBalance(addr account.Address) *balance.Balance {
 	return bc.validator.GetBalance(addr)
 }
 
+// GetNonce returns the nonce the next transaction of addr has to carry
+func (bc *Blockchain) GetNonce(addr account.Address) (uint32, error) {
+	if bc.validator == nil {
+		return bc.ledger.GetNonce(addr)
+	}
+	return bc.validator.GetNonce(addr), nil
+}
+
 // GetTransaction returns transaction in ledger first then txBool
 func (bc *Blockchain) GetTransaction(txHash crypto.Hash) (*proto.Transaction, error) {
 	tx, err := bc.ledger.GetTxByTxHash(txHash.Bytes())
@@ -274,7 +326,7 @@ func (bc *Blockchain) StartConsensusService() {
 }
 
 func (bc *Blockchain) processConsensusOutput(output *consensus.OutputTxs) {
-	blk := bc.GenerateBlock(output.Txs, output.Time)
+	blk := bc.GenerateBlock(output)
 	if blk.Height() == output.Height {
 		bc.Relay(blk)
 	}
@@ -298,7 +350,7 @@ func (bc *Blockchain) ProcessTransaction(tx *proto.Transaction, needNotify bool)
 	err := bc.validator.ProcessTransaction(tx)
 	log.Debugf("[Blockchain] new tx, tx_hash: %v, tx_sender: %v, tx_nonce: %v, end", tx.Hash().String(), tx.Sender().String(), tx.Nonce())
 	if err != nil {
-		log.Errorf(fmt.Sprintf("process transaction %v failed, %v", tx.Hash(), err))
+		log.Errorf("process transaction %v failed, %v", tx.Hash(), err)
 		return false
 	}
 
@@ -311,7 +363,14 @@ func (bc *Blockchain) ProcessBlock(blk *proto.Block, flag bool) bool {
 	defer bc.mu.Unlock()
 	log.Debugf("block previoushash %s, currentblockhash %s,len %d", blk.PreviousHash(), bc.CurrentBlockHash(), len(blk.Transactions))
 	if blk.PreviousHash() == bc.CurrentBlockHash().String() {
-		bc.ledger.AppendBlock(blk, flag)
+		if err := blk.Header.Verify(bc.currentBlockHeader, params.ChainID, time.Now()); err != nil {
+			log.Errorf("Invalid block %s -- %s", blk.Hash(), err)
+			return false
+		}
+		if err := bc.ledger.AppendBlock(blk, flag); err != nil {
+			log.Errorf("AppendBlock %s error %v", blk.Hash(), err)
+			return false
+		}
 		log.Infof("New Block  %s, height: %d Transaction Number: %d", blk.Hash(), blk.Height(), len(blk.Transactions))
 		bc.currentBlockHeader = blk.Header
 		return true
@@ -331,19 +390,29 @@ func (bc *Blockchain) merkleRootHash(txs []*proto.Transaction) crypto.Hash {
 }
 
 // GenerateBlock gets transactions from consensus service and generates a new block
-func (bc *Blockchain) GenerateBlock(txs proto.Transactions, createTime uint32) *proto.Block {
+func (bc *Blockchain) GenerateBlock(output *consensus.OutputTxs) *proto.Block {
 	var (
 		// default value is empty hash
 		merkleRootHash crypto.Hash
 		stateRootHash  crypto.Hash
 	)
 
+	// block timestamps must increase, consensus may output transactions created in the parent block second
+	createTime := output.Time
+	if createTime <= bc.currentBlockHeader.TimeStamp {
+		createTime = bc.currentBlockHeader.TimeStamp + 1
+	}
+
 	blk := proto.NewBlock(bc.currentBlockHeader.Hash(), stateRootHash,
 		createTime, bc.currentBlockHeader.Height+1,
 		uint32(100),
 		merkleRootHash,
-		txs,
+		output.Txs,
 	)
+	blk.Header.Version = proto.BlockVersion
+	blk.Header.ChainID = params.ChainID
+	blk.Header.Proposer = output.Proposer
+	blk.Header.ConsensusMetaHash = output.MetaHash.String()
 	return blk
 }
 
@@ -0,0 +1,109 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package blockchain
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/config"
+	"github.com/zipper-project/zipper/ledger"
+	"github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+func TestNewBlockchainOnSnapshot(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	source := ledger.NewLedger(db.NewMemDB(db.DefaultConfig()))
+	for height := uint32(1); height <= 2; height++ {
+		if err := source.AppendBlock(&proto.Block{Header: &proto.BlockHeader{Version: proto.BlockVersion, Height: height}}, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf := new(bytes.Buffer)
+	if err := source.ExportSnapshot(buf, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "blockchain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.DataDir = dir
+	kvdb := db.NewDB(config.DBConfig())
+	header, err := ledger.ImportSnapshot(kvdb, buf, false)
+	kvdb.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bc, err := NewBlockchain(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.ledger.DBHandler().Close()
+	utils.AssertEquals(t, bc.CurrentHeight(), uint32(2))
+	utils.AssertEquals(t, bc.currentBlockHeader.Hash(), header.Hash())
+
+	genesis, err := bc.ledger.GetGenesisBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceGenesis, err := source.GetGenesisBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, genesis.Hash(), sourceGenesis.Hash())
+}
+
+func TestConsenterOptions(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	kvdb := db.NewMemDB(db.DefaultConfig())
+	genesis := ledger.DefaultGenesis()
+	genesis.Validators = []string{"node-0", "node-1", "node-2"}
+	if _, err := ledger.InitGenesis(kvdb, genesis); err != nil {
+		t.Fatal(err)
+	}
+
+	bc := &Blockchain{ledger: ledger.NewLedger(kvdb)}
+	option, err := bc.consenterOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, option.Scip.N, 3)
+	utils.AssertEquals(t, option.Scip.Validators, []string{
+		config.SCIPReplicaID(option.Scip.Chain, "node-0"),
+		config.SCIPReplicaID(option.Scip.Chain, "node-1"),
+		config.SCIPReplicaID(option.Scip.Chain, "node-2"),
+	})
+
+	genesis.Validators = genesis.Validators[:2]
+	kvdb = db.NewMemDB(db.DefaultConfig())
+	if _, err := ledger.InitGenesis(kvdb, genesis); err != nil {
+		t.Fatal(err)
+	}
+	bc = &Blockchain{ledger: ledger.NewLedger(kvdb)}
+	if _, err := bc.consenterOptions(); err == nil {
+		t.Error("quorum above the genesis validators accepted")
+	}
+}
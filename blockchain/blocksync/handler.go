@@ -18,6 +18,7 @@
 package blocksync
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 
@@ -26,6 +27,7 @@ import (
 	"github.com/zipper-project/zipper/common/log"
 	"github.com/zipper-project/zipper/common/mpool"
 	"github.com/zipper-project/zipper/ledger"
+	"github.com/zipper-project/zipper/params"
 	"github.com/zipper-project/zipper/peer"
 	msgProto "github.com/zipper-project/zipper/peer/proto"
 	"github.com/zipper-project/zipper/proto"
@@ -235,7 +237,22 @@ func (worker *SyncWorker) OnBlock(workerData *types.WorkerData) {
 		return
 	}
 
-	if worker.bc.CurrentHeight()+1 < blockMsg.Block.Header.Height {
+	header := blockMsg.Block.GetHeader()
+	if header == nil {
+		log.Errorf("Get OnBlockMsg without block header")
+		return
+	}
+	// synced blocks are never the genesis, so they have to be of the current version as in Verify
+	if header.Version != proto.BlockVersion {
+		log.Errorf("Get block %s of unsupported version %d", blockMsg.Block.Hash(), header.Version)
+		return
+	}
+	if !bytes.Equal(header.ChainID, params.ChainID) {
+		log.Errorf("Get block %s of chain %x, local chain %x", blockMsg.Block.Hash(), header.ChainID, params.ChainID.Bytes())
+		return
+	}
+
+	if worker.bc.CurrentHeight()+1 < header.Height {
 		getBlocksMsg := &proto.GetBlocksMsg{
 			LocatorHashes: []string{worker.bc.CurrentBlockHash().String()},
 			HashStop:      crypto.Hash{}.String(),
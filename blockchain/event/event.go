@@ -0,0 +1,182 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package event
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/zipper-project/zipper/ledger/state"
+	"github.com/zipper-project/zipper/proto"
+)
+
+// Type is the type of an event
+type Type int
+
+// event types
+const (
+	TypeTxAccepted Type = iota
+	TypeTxRejected
+	TypeBlockCommitted
+	TypeStateChanged
+	TypeConsensusViewChanged
+	TypeTxParked
+)
+
+// Event is published on the bus
+type Event interface {
+	Type() Type
+}
+
+// TxAccepted is published when a transaction enters the validator pool
+type TxAccepted struct {
+	Tx *proto.Transaction
+}
+
+// TxRejected is published when the validator rejects a transaction
+type TxRejected struct {
+	Tx     *proto.Transaction
+	Reason string
+}
+
+// TxParked is published when a transaction waits in the validator for the earlier nonces of its sender,
+// TxAccepted follows once it enters the pool
+type TxParked struct {
+	Tx *proto.Transaction
+}
+
+// BlockCommitted is published when a block and its receipts are written to the ledger
+type BlockCommitted struct {
+	Block    *proto.Block
+	Receipts []*state.Receipt
+}
+
+// StateChange is a state key written by a block, Value is nil if the key was deleted
+type StateChange struct {
+	CfName string
+	Key    []byte
+	Value  []byte
+}
+
+// StateChanged is published with the state keys a committed block wrote
+type StateChanged struct {
+	Height  uint32
+	Changes []*StateChange
+}
+
+// ConsensusViewChanged is published when consensus votes a new primary
+type ConsensusViewChanged struct {
+	PrimaryID string
+	SeqNo     uint32
+	Height    uint32
+}
+
+// Type implements Event
+func (e *TxAccepted) Type() Type { return TypeTxAccepted }
+
+// Type implements Event
+func (e *TxRejected) Type() Type { return TypeTxRejected }
+
+// Type implements Event
+func (e *TxParked) Type() Type { return TypeTxParked }
+
+// Type implements Event
+func (e *BlockCommitted) Type() Type { return TypeBlockCommitted }
+
+// Type implements Event
+func (e *StateChanged) Type() Type { return TypeStateChanged }
+
+// Type implements Event
+func (e *ConsensusViewChanged) Type() Type { return TypeConsensusViewChanged }
+
+// Bus publishes events to its subscriptions, a nil bus drops every event
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// Subscription receives the events of its types in a bounded buffer.
+// Events published while the buffer is full are dropped so a slow subscriber never blocks the publisher
+type Subscription struct {
+	bus     *Bus
+	ch      chan Event
+	types   map[Type]bool
+	dropped uint64
+}
+
+// NewBus returns an event bus without subscriptions
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe returns a subscription buffering up to size events of types, of every type if none is given
+func (bus *Bus) Subscribe(size int, types ...Type) *Subscription {
+	sub := &Subscription{
+		bus: bus,
+		ch:  make(chan Event, size),
+	}
+	if len(types) > 0 {
+		sub.types = make(map[Type]bool)
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	bus.mu.Lock()
+	bus.subs[sub] = struct{}{}
+	bus.mu.Unlock()
+	return sub
+}
+
+// Publish delivers ev to the subscriptions of its type without blocking
+func (bus *Bus) Publish(ev Event) {
+	if bus == nil {
+		return
+	}
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+	for sub := range bus.subs {
+		if sub.types != nil && !sub.types[ev.Type()] {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			atomic.AddUint64(&sub.dropped, 1)
+		}
+	}
+}
+
+// Chan returns the channel the events are delivered on, it is closed by Unsubscribe
+func (sub *Subscription) Chan() <-chan Event {
+	return sub.ch
+}
+
+// Dropped returns the number of events dropped because the buffer was full
+func (sub *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&sub.dropped)
+}
+
+// Unsubscribe stops the delivery of events and closes the channel
+func (sub *Subscription) Unsubscribe() {
+	sub.bus.mu.Lock()
+	defer sub.bus.mu.Unlock()
+	if _, ok := sub.bus.subs[sub]; ok {
+		delete(sub.bus.subs, sub)
+		close(sub.ch)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package event
+
+import (
+	"testing"
+
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/proto"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	all := bus.Subscribe(1)
+	blocks := bus.Subscribe(2, TypeBlockCommitted)
+
+	block := &proto.Block{Header: &proto.BlockHeader{Height: 1}}
+	bus.Publish(&TxRejected{Reason: "test"})
+	bus.Publish(&BlockCommitted{Block: block})
+
+	// the buffer of all holds one event, the second one is dropped
+	ev := <-all.Chan()
+	utils.AssertEquals(t, ev.Type(), TypeTxRejected)
+	utils.AssertEquals(t, ev.(*TxRejected).Reason, "test")
+	utils.AssertEquals(t, all.Dropped(), uint64(1))
+
+	ev = <-blocks.Chan()
+	utils.AssertEquals(t, ev.(*BlockCommitted).Block, block)
+	utils.AssertEquals(t, blocks.Dropped(), uint64(0))
+
+	blocks.Unsubscribe()
+	blocks.Unsubscribe()
+	bus.Publish(&BlockCommitted{Block: block})
+	if _, ok := <-blocks.Chan(); ok {
+		t.Error("event delivered after unsubscribe")
+	}
+	utils.AssertEquals(t, (<-all.Chan()).Type(), TypeBlockCommitted)
+
+	var nilBus *Bus
+	nilBus.Publish(&TxAccepted{})
+}
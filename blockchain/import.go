@@ -0,0 +1,83 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package blockchain
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/zipper-project/zipper/ledger"
+	"github.com/zipper-project/zipper/proto"
+)
+
+// ImportBlocks feeds the blocks of an archive read from r through ProcessBlock and returns the number of blocks imported.
+// Blocks the chain already has are skipped, a block whose re-execution doesn't reproduce its archived hash is rolled
+// back and stops the import. Blocks are verified as synced ones, so archives of chains built before proto.BlockVersion
+// are rejected. progress is called with the height of each imported block
+func (bc *Blockchain) ImportBlocks(r io.Reader, progress func(height uint32)) (uint32, error) {
+	var imported uint32
+	err := ledger.ReadBlocks(r, func(blk *proto.Block) error {
+		// ProcessBlock rewrites the header with the recomputed hashes
+		hash := blk.Hash()
+		height := bc.CurrentHeight()
+		if blk.Height() <= height {
+			local, err := bc.ledger.GetBlockHashByNumber(blk.Height())
+			if err != nil {
+				return err
+			}
+			if local != hash {
+				return fmt.Errorf("block %d %s conflicts with the local block %s", blk.Height(), hash, local)
+			}
+			return nil
+		}
+		if blk.Height() > height+1 {
+			return fmt.Errorf("block %d doesn't extend the chain at height %d", blk.Height(), height)
+		}
+
+		if !bc.ProcessBlock(blk, false) {
+			return fmt.Errorf("block %d %s is rejected", blk.Height(), hash)
+		}
+		if current := bc.CurrentBlockHash(); current != hash {
+			if err := bc.rollback(height); err != nil {
+				return fmt.Errorf("block %d re-executes to %s instead of %s, rolling it back failed -- %s", blk.Height(), current, hash, err)
+			}
+			return fmt.Errorf("block %d re-executes to %s instead of %s", blk.Height(), current, hash)
+		}
+
+		imported++
+		if progress != nil {
+			progress(blk.Height())
+		}
+		return nil
+	})
+	return imported, err
+}
+
+// rollback rolls the chain back to height
+func (bc *Blockchain) rollback(height uint32) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if err := bc.ledger.Rollback(height); err != nil {
+		return err
+	}
+	header, err := bc.ledger.GetBlockByNumber(height)
+	if err != nil {
+		return err
+	}
+	bc.currentBlockHeader = header
+	return nil
+}
@@ -18,6 +18,7 @@
 package blockchain
 
 import (
+	"github.com/zipper-project/zipper/blockchain/event"
 	"github.com/zipper-project/zipper/consensus"
 	"github.com/zipper-project/zipper/proto"
 )
@@ -33,3 +34,8 @@ func (bc *Blockchain) GetBlockchainInfo() *consensus.BlockchainInfo {
 		Height: height,
 	}
 }
+
+// ViewChanged publishes the consensus view change
+func (bc *Blockchain) ViewChanged(primaryID string, seqNo uint32, height uint32) {
+	bc.ledger.Events().Publish(&event.ConsensusViewChanged{PrimaryID: primaryID, SeqNo: seqNo, Height: height})
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	"strings"
+	"time"
 
 	"github.com/zipper-project/zipper/common/utils"
 	"github.com/zipper-project/zipper/coordinate"
@@ -51,8 +52,12 @@ func (v *Verification) checkTransactionLegal(tx *proto.Transaction) error {
 		return fmt.Errorf("[validator] illegal transaction %s : fromCahin %s or toChain %s == params.ChainID %s", tx.Hash(), tx.FromChain(), tx.ToChain(), params.ChainID.String())
 	}
 
-	if tx.Amount() < 0 || tx.Fee() < 0 {
-		return fmt.Errorf("[validator] illegal transaction %s : Amount must be >0 or Fee must bigger than 0", tx.Hash())
+	if err := tx.CheckAmounts(); err != nil {
+		return fmt.Errorf("[validator] illegal transaction %s : %v", tx.Hash(), err)
+	}
+
+	if err := v.checkExpiry(tx, time.Now(), v.nextHeight()); err != nil {
+		return err
 	}
 
 	switch tx.GetType() {
@@ -80,6 +85,25 @@ func (v *Verification) checkTransactionLegal(tx *proto.Transaction) error {
 		}
 	case proto.TransactionType_Merged:
 	// nothing to do
+	case proto.TransactionType_ContractQuery:
+		return fmt.Errorf("[validator] illegal transaction %s : contract queries are answered over RPC, they don't go through consensus", tx.Hash())
+	case proto.TransactionType_Mint, proto.TransactionType_Burn:
+		if strings.Compare(tx.FromChain(), tx.ToChain()) != 0 {
+			return fmt.Errorf("[validator] illegal transaction %s : fromchain %s == tochain %s", tx.Hash(), tx.FromChain(), tx.ToChain())
+		}
+		if tx.GetType() == proto.TransactionType_Burn && !bytes.Equal(tx.Sender().Bytes(), tx.Recipient().Bytes()) {
+			return fmt.Errorf("[validator] illegal transaction %s : burn recipient %s isn't the sender %s", tx.Hash(), tx.Recipient(), tx.Sender())
+		}
+	case proto.TransactionType_ContractUpgrade, proto.TransactionType_ContractDecommission:
+		if strings.Compare(tx.FromChain(), tx.ToChain()) != 0 {
+			return fmt.Errorf("[validator] illegal transaction %s : fromchain %s == tochain %s", tx.Hash(), tx.FromChain(), tx.ToChain())
+		}
+		if addr := tx.GetContractSpec().GetAddr(); len(addr) == 0 || !bytes.Equal(addr, tx.Recipient().Bytes()) {
+			return fmt.Errorf("[validator] illegal transaction %s : contract address %x isn't the recipient %s", tx.Hash(), addr, tx.Recipient())
+		}
+		if tx.GetType() == proto.TransactionType_ContractUpgrade && len(tx.GetContractSpec().GetCode()) == 0 {
+			return fmt.Errorf("[validator] illegal transaction %s : contract upgrade has no code", tx.Hash())
+		}
 	case proto.TransactionType_Issue, proto.TransactionType_IssueUpdate:
 		fromChain := coordinate.HexToChainCoordinate(tx.FromChain())
 		toChain := coordinate.HexToChainCoordinate(tx.FromChain())
@@ -147,5 +171,11 @@ func (v *Verification) checkTransaction(tx *proto.Transaction) error {
 		return fmt.Errorf("[validator] illegal transaction %s: invalid signature", tx.Hash())
 	}
 
+	if policy := v.feePolicy(); policy != nil {
+		if err := policy.CheckFee(tx); err != nil {
+			return fmt.Errorf("[validator] illegal transaction %s: %s", tx.Hash(), err)
+		}
+	}
+
 	return nil
 }
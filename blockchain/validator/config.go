@@ -25,21 +25,27 @@ type Config struct {
 	TxPoolDelay       int
 	MaxWorker         int
 	MaxQueue          int
-	TxPoolTimeOut     time.Duration
+	TxPoolTimeOut     time.Duration // how long a transaction may wait in the pool after its create time
+	MaxClockSkew      time.Duration // how far ahead of the local clock the create time of a transaction may be
+	MaxNonceGap       int           // how far ahead of the next nonce of its sender a transaction may wait in the pool
+	MaxParkedTxs      int           // how many transactions of all senders may wait in the pool for their nonce
 	BlacklistDur      time.Duration
 	SecurityPluginDir string
-	PublicAddresses     []string
+	PublicAddresses   []string
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		IsValid:        true,
-		TxPoolCapacity: 200000,
-		TxPoolDelay:    5000,
-		MaxWorker:      10,
-		MaxQueue:       2000,
-		TxPoolTimeOut:  30 * time.Minute,
-		BlacklistDur:   1 * time.Minute,
+		IsValid:         true,
+		TxPoolCapacity:  200000,
+		TxPoolDelay:     5000,
+		MaxWorker:       10,
+		MaxQueue:        2000,
+		TxPoolTimeOut:   30 * time.Minute,
+		MaxClockSkew:    5 * time.Minute,
+		MaxNonceGap:     64,
+		MaxParkedTxs:    4096,
+		BlacklistDur:    1 * time.Minute,
 		PublicAddresses: []string{},
 	}
 }
@@ -0,0 +1,95 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package validator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zipper-project/zipper/common/log"
+	"github.com/zipper-project/zipper/common/utils/sortedlinkedlist"
+	"github.com/zipper-project/zipper/proto"
+)
+
+// nextHeight returns the height of the next block
+func (v *Verification) nextHeight() uint32 {
+	height, err := v.ledger.Height()
+	if err != nil {
+		log.Errorf("[validator] height --- %s", err)
+	}
+	return height + 1
+}
+
+// checkExpiry returns an error if the create time of tx is further ahead of now than the allowed clock skew,
+// tx waited longer than the pool timeout or it is valid until a height below the next block height
+func (v *Verification) checkExpiry(tx *proto.Transaction, now time.Time, next uint32) error {
+	created := time.Unix(int64(tx.CreateTime()), 0)
+	if created.After(now.Add(v.config.MaxClockSkew)) {
+		return fmt.Errorf("[validator] illegal transaction %s : create time %s is ahead of the local time %s", tx.Hash(), created.Format(time.RFC3339), now.Format(time.RFC3339))
+	}
+	if v.config.TxPoolTimeOut > 0 && now.Sub(created) > v.config.TxPoolTimeOut {
+		return fmt.Errorf("[validator] transaction %s expired : created at %s, timeout %s", tx.Hash(), created.Format(time.RFC3339), v.config.TxPoolTimeOut)
+	}
+	if until := tx.ValidUntil(); until != 0 && next > until {
+		return fmt.Errorf("[validator] transaction %s expired : valid until block %d, next block %d", tx.Hash(), until, next)
+	}
+	return nil
+}
+
+// evictExpired removes the expired transactions from the pool and the parked ones
+func (v *Verification) evictExpired() {
+	now, next := time.Now(), v.nextHeight()
+	v.rwInTxs.Lock()
+	var elems []sortedlinkedlist.IElement
+	var expired []error
+	v.txpool.IterElement(func(element sortedlinkedlist.IElement) bool {
+		tx := element.(*proto.Transaction)
+		if err := v.checkExpiry(tx, now, next); err != nil {
+			elems = append(elems, tx)
+			expired = append(expired, err)
+		}
+		return false
+	})
+	v.txpool.Removes(elems)
+	for _, elem := range elems {
+		tx := elem.(*proto.Transaction)
+		delete(v.inTxs, tx.Hash())
+		v.forgetNonce(tx)
+	}
+	for sender, parked := range v.futureTxs {
+		for nonce, tx := range parked {
+			if err := v.checkExpiry(tx, now, next); err != nil {
+				delete(parked, nonce)
+				elems = append(elems, tx)
+				expired = append(expired, err)
+			}
+		}
+		if len(parked) == 0 {
+			delete(v.futureTxs, sender)
+		}
+	}
+	v.rwInTxs.Unlock()
+
+	for i, elem := range elems {
+		log.Debugf("[txPool] evict %s", expired[i])
+		v.reject(elem.(*proto.Transaction), expired[i].Error())
+	}
+	if len(elems) > 0 {
+		log.Infof("[txPool] evicted %d expired transactions, txpool_len: %d", len(elems), v.txpool.Len())
+	}
+}
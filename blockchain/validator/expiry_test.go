@@ -0,0 +1,81 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package validator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/common/utils/sortedlinkedlist"
+	"github.com/zipper-project/zipper/ledger"
+	"github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+func TestExpiry(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	v := &Verification{
+		config:    DefaultConfig(),
+		ledger:    ledger.NewLedger(db.NewMemDB(db.DefaultConfig())),
+		txpool:    sortedlinkedlist.NewSortedLinkedList(),
+		inTxs:     make(map[crypto.Hash]*proto.Transaction),
+		nonces:    make(map[string]uint32),
+		futureTxs: make(map[string]map[uint32]*proto.Transaction),
+	}
+	keypair, _ := crypto.GenerateKey()
+	now := time.Now()
+
+	fresh := newNonceTx(keypair, 0)
+	utils.AssertEquals(t, v.checkExpiry(fresh, now, 1), nil)
+
+	ahead := newNonceTx(keypair, 1)
+	ahead.GetHeader().CreateTime = uint32(now.Add(v.config.MaxClockSkew + time.Minute).Unix())
+	if err := v.checkExpiry(ahead, now, 1); err == nil {
+		t.Error("create time beyond the clock skew accepted")
+	}
+
+	old := newNonceTx(keypair, 2)
+	old.GetHeader().CreateTime = uint32(now.Add(-v.config.TxPoolTimeOut - time.Minute).Unix())
+	if err := v.checkExpiry(old, now, 1); err == nil {
+		t.Error("transaction older than the pool timeout accepted")
+	}
+
+	until := newNonceTx(keypair, 3)
+	until.GetHeader().ValidUntil = 1
+	utils.AssertEquals(t, v.checkExpiry(until, now, 1), nil)
+	if err := v.checkExpiry(until, now, 2); err == nil {
+		t.Error("transaction valid until a passed height accepted")
+	}
+
+	// the pool and the parked transactions lose the expired ones only
+	for _, tx := range []*proto.Transaction{fresh, old} {
+		v.txpool.Add(tx)
+		v.inTxs[tx.Hash()] = tx
+	}
+	parked := newNonceTx(keypair, 4)
+	parked.GetHeader().CreateTime = old.CreateTime()
+	v.futureTxs[parked.Sender().String()] = map[uint32]*proto.Transaction{4: parked}
+	v.evictExpired()
+	utils.AssertEquals(t, v.txpool.Len(), 1)
+	utils.AssertEquals(t, len(v.inTxs), 1)
+	utils.AssertEquals(t, v.inTxs[fresh.Hash()], fresh)
+	utils.AssertEquals(t, len(v.futureTxs), 0)
+}
@@ -0,0 +1,215 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package validator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/blockchain/event"
+	"github.com/zipper-project/zipper/common/log"
+	"github.com/zipper-project/zipper/ledger/state"
+	"github.com/zipper-project/zipper/proto"
+)
+
+// nextNonce returns the nonce the next transaction of addr has to carry, counting the transactions in the pool
+func (v *Verification) nextNonce(addr account.Address) uint32 {
+	committed, err := v.ledger.GetNonce(addr)
+	if err != nil {
+		log.Errorf("[validator] nonce of %s --- %s", addr, err)
+	}
+	if pending, ok := v.nonces[addr.String()]; ok && pending > committed {
+		return pending
+	}
+	delete(v.nonces, addr.String())
+	return committed
+}
+
+// checkNonce returns whether tx carries the next nonce of its sender, a future nonce is parked until the gap fills
+func (v *Verification) checkNonce(tx *proto.Transaction) (bool, error) {
+	if !state.NonceEnforced(tx) {
+		return true, nil
+	}
+	next := v.nextNonce(tx.Sender())
+	switch {
+	case tx.Nonce() < next:
+		return false, fmt.Errorf("transaction %s nonce %d is stale, the next nonce of %s is %d", tx.Hash(), tx.Nonce(), tx.Sender(), next)
+	case tx.Nonce() == next:
+		return true, nil
+	case tx.Nonce()-next > uint32(v.config.MaxNonceGap):
+		return false, fmt.Errorf("transaction %s nonce %d is too far ahead of the next nonce %d of %s", tx.Hash(), tx.Nonce(), next, tx.Sender())
+	}
+
+	sender := tx.Sender().String()
+	if _, ok := v.futureTxs[sender][tx.Nonce()]; ok {
+		return false, fmt.Errorf("transaction %s nonce %d of %s is already waiting", tx.Hash(), tx.Nonce(), tx.Sender())
+	}
+	if v.parkedCount() >= v.config.MaxParkedTxs && !v.evictParked(tx) {
+		return false, fmt.Errorf("transaction %s nonce %d of %s can't wait, %d transactions are waiting", tx.Hash(), tx.Nonce(), tx.Sender(), v.parkedCount())
+	}
+	if v.futureTxs[sender] == nil {
+		v.futureTxs[sender] = make(map[uint32]*proto.Transaction)
+	}
+	v.futureTxs[sender][tx.Nonce()] = tx
+	log.Debugf("[validator] park transaction %s, nonce %d of %s waits for %d", tx.Hash(), tx.Nonce(), tx.Sender(), next)
+	return false, nil
+}
+
+// parkedCount returns the number of transactions of all senders waiting for their nonce
+func (v *Verification) parkedCount() int {
+	count := 0
+	for _, parked := range v.futureTxs {
+		count += len(parked)
+	}
+	return count
+}
+
+// evictParked makes room for parking tx by dropping the highest nonce of the sender with the most parked transactions,
+// it returns false if tx itself would be that transaction
+func (v *Verification) evictParked(tx *proto.Transaction) bool {
+	sender := tx.Sender().String()
+	victim, most := sender, len(v.futureTxs[sender])+1
+	for s, parked := range v.futureTxs {
+		if len(parked) > most {
+			victim, most = s, len(parked)
+		}
+	}
+
+	var highest *proto.Transaction
+	for _, ptx := range v.futureTxs[victim] {
+		if highest == nil || ptx.Nonce() > highest.Nonce() {
+			highest = ptx
+		}
+	}
+	if highest == nil || (victim == sender && tx.Nonce() > highest.Nonce()) {
+		return false
+	}
+	delete(v.futureTxs[victim], highest.Nonce())
+	if len(v.futureTxs[victim]) == 0 {
+		delete(v.futureTxs, victim)
+	}
+	log.Warnf("[validator] too many waiting transactions, drop transaction %s nonce %d of %s", highest.Hash(), highest.Nonce(), highest.Sender())
+	v.ledger.Events().Publish(&event.TxRejected{Tx: highest, Reason: "too many transactions are waiting for their nonce"})
+	return true
+}
+
+// useNonce advances the next nonce of the sender of tx added to the pool and
+// returns the parked transactions of the sender whose turn has come
+func (v *Verification) useNonce(tx *proto.Transaction) proto.Transactions {
+	if !state.NonceEnforced(tx) {
+		return nil
+	}
+	v.nonces[tx.Sender().String()] = tx.Nonce() + 1
+	return v.promote(tx.Sender())
+}
+
+// promote returns the parked transactions of addr continuing its next nonce and drops the stale ones
+func (v *Verification) promote(addr account.Address) proto.Transactions {
+	sender := addr.String()
+	parked := v.futureTxs[sender]
+	if len(parked) == 0 {
+		return nil
+	}
+
+	var txs proto.Transactions
+	next := v.nextNonce(addr)
+	for nonce := range parked {
+		if nonce < next {
+			delete(parked, nonce)
+		}
+	}
+	for tx, ok := parked[next]; ok; tx, ok = parked[next] {
+		delete(parked, next)
+		txs = append(txs, tx)
+		next++
+	}
+	if len(txs) > 0 {
+		v.nonces[sender] = next
+	}
+	if len(parked) == 0 {
+		delete(v.futureTxs, sender)
+	}
+	return txs
+}
+
+// forgetNonce resyncs the next nonce of the sender of tx with the ledger once tx left the pool without being committed
+func (v *Verification) forgetNonce(tx *proto.Transaction) {
+	if state.NonceEnforced(tx) {
+		delete(v.nonces, tx.Sender().String())
+	}
+}
+
+// orderByNonce reorders the transactions of each sender by nonce, keeping the positions the sender takes in txs
+func orderByNonce(txs proto.Transactions) {
+	positions := make(map[string][]int)
+	for i, tx := range txs {
+		if state.NonceEnforced(tx) {
+			sender := tx.Sender().String()
+			positions[sender] = append(positions[sender], i)
+		}
+	}
+	for _, idx := range positions {
+		if len(idx) < 2 {
+			continue
+		}
+		sorted := make(proto.Transactions, len(idx))
+		for i, j := range idx {
+			sorted[i] = txs[j]
+		}
+		sort.Stable(sorted)
+		for i, j := range idx {
+			txs[j] = sorted[i]
+		}
+	}
+}
+
+// verifyNonce checks the nonce of tx of a consensus batch against the committed nonce of its sender and
+// the nonces of the transactions of the sender accepted earlier in the batch
+func (v *Verification) verifyNonce(tx *proto.Transaction, batch map[string]uint32) error {
+	if !state.NonceEnforced(tx) {
+		return nil
+	}
+	if last, ok := batch[tx.Sender().String()]; ok {
+		if tx.Nonce() != last+1 {
+			return fmt.Errorf("nonce %d doesn't follow nonce %d of %s in the batch", tx.Nonce(), last, tx.Sender())
+		}
+		return nil
+	}
+	committed, err := v.ledger.GetNonce(tx.Sender())
+	if err != nil {
+		return err
+	}
+	if tx.Nonce() < committed {
+		return fmt.Errorf("nonce %d is stale, the next nonce of %s is %d", tx.Nonce(), tx.Sender(), committed)
+	}
+	return nil
+}
+
+// promoteParked moves the parked transactions whose gap was filled by committed transactions to the pool
+func (v *Verification) promoteParked() {
+	v.rwInTxs.Lock()
+	defer v.rwInTxs.Unlock()
+	for sender := range v.futureTxs {
+		for _, tx := range v.promote(account.HexToAddress(sender)) {
+			v.txpool.Add(tx)
+			v.inTxs[tx.Hash()] = tx
+			v.ledger.Events().Publish(&event.TxAccepted{Tx: tx})
+		}
+	}
+}
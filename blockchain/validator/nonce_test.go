@@ -0,0 +1,139 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package validator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/blockchain/event"
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger"
+	"github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+func newNonceTx(keypair *crypto.PrivateKey, nonce uint32) *proto.Transaction {
+	tx := proto.NewTransaction(account.NewChainCoordinate([]byte{byte(0)}),
+		account.NewChainCoordinate([]byte{byte(0)}),
+		proto.TransactionType_Atomic,
+		nonce,
+		account.PublicKeyToAddress(*keypair.Public()),
+		account.HexToAddress("0xa032277be213f56221b6140998c03d860a60e1f8"),
+		uint32(0),
+		big.NewInt(1),
+		big.NewInt(0),
+		utils.CurrentTimestamp())
+	signature, _ := keypair.Sign(tx.Hash().Bytes())
+	tx.GetHeader().Signature = signature.Bytes()
+	return tx
+}
+
+func TestNonce(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	v := &Verification{
+		config:    DefaultConfig(),
+		ledger:    ledger.NewLedger(db.NewMemDB(db.DefaultConfig())),
+		nonces:    make(map[string]uint32),
+		futureTxs: make(map[string]map[uint32]*proto.Transaction),
+	}
+	keypair, _ := crypto.GenerateKey()
+	sender := account.PublicKeyToAddress(*keypair.Public())
+
+	// future nonces wait for the gap to fill
+	for _, nonce := range []uint32{2, 1} {
+		ready, err := v.checkNonce(newNonceTx(keypair, nonce))
+		utils.AssertEquals(t, ready, false)
+		utils.AssertEquals(t, err, nil)
+	}
+	if _, err := v.checkNonce(newNonceTx(keypair, 2)); err == nil {
+		t.Error("nonce parked twice")
+	}
+	if _, err := v.checkNonce(newNonceTx(keypair, uint32(v.config.MaxNonceGap)+1)); err == nil {
+		t.Error("nonce beyond the max gap parked")
+	}
+
+	first := newNonceTx(keypair, 0)
+	ready, err := v.checkNonce(first)
+	utils.AssertEquals(t, ready, true)
+	utils.AssertEquals(t, err, nil)
+	promoted := v.useNonce(first)
+	utils.AssertEquals(t, len(promoted), 2)
+	utils.AssertEquals(t, promoted[0].Nonce(), uint32(1))
+	utils.AssertEquals(t, promoted[1].Nonce(), uint32(2))
+	utils.AssertEquals(t, v.GetNonce(sender), uint32(3))
+
+	if _, err := v.checkNonce(newNonceTx(keypair, 1)); err == nil {
+		t.Error("stale nonce accepted")
+	}
+
+	// the pool lost its transactions, the next nonce is the committed one again
+	v.forgetNonce(first)
+	utils.AssertEquals(t, v.GetNonce(sender), uint32(0))
+
+	batch := proto.Transactions{newNonceTx(keypair, 1), promoted[1], first}
+	orderByNonce(batch)
+	for i, tx := range batch {
+		utils.AssertEquals(t, tx.Nonce(), uint32(i))
+	}
+	nonces := make(map[string]uint32)
+	utils.AssertEquals(t, v.verifyNonce(batch[0], nonces), nil)
+	nonces[sender.String()] = 0
+	if err := v.verifyNonce(batch[2], nonces); err == nil {
+		t.Error("nonce gap in a batch accepted")
+	}
+}
+
+func TestParkedLimit(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	v := &Verification{
+		config:    DefaultConfig(),
+		ledger:    ledger.NewLedger(db.NewMemDB(db.DefaultConfig())),
+		nonces:    make(map[string]uint32),
+		futureTxs: make(map[string]map[uint32]*proto.Transaction),
+	}
+	v.config.MaxParkedTxs = 3
+	a, _ := crypto.GenerateKey()
+	b, _ := crypto.GenerateKey()
+	c, _ := crypto.GenerateKey()
+	for _, tx := range []*proto.Transaction{newNonceTx(a, 1), newNonceTx(a, 2), newNonceTx(b, 1)} {
+		_, err := v.checkNonce(tx)
+		utils.AssertEquals(t, err, nil)
+	}
+
+	// the pool is full, a sender parking as many as the others can't push them out
+	if _, err := v.checkNonce(newNonceTx(b, 2)); err == nil {
+		t.Error("transaction parked beyond the limit")
+	}
+	utils.AssertEquals(t, v.parkedCount(), 3)
+
+	// the highest nonce of the sender parking the most makes room
+	rejected := v.ledger.Events().Subscribe(1, event.TypeTxRejected)
+	_, err := v.checkNonce(newNonceTx(c, 1))
+	utils.AssertEquals(t, err, nil)
+	ev := <-rejected.Chan()
+	utils.AssertEquals(t, ev.(*event.TxRejected).Tx.Sender(), account.PublicKeyToAddress(*a.Public()))
+	utils.AssertEquals(t, ev.(*event.TxRejected).Tx.Nonce(), uint32(2))
+	utils.AssertEquals(t, v.parkedCount(), 3)
+	utils.AssertEquals(t, len(v.futureTxs[account.PublicKeyToAddress(*a.Public()).String()]), 1)
+	_, ok := v.futureTxs[account.PublicKeyToAddress(*a.Public()).String()][1]
+	utils.AssertEquals(t, ok, true)
+}
@@ -20,10 +20,12 @@ package validator
 import (
 	"bytes"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
 	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/blockchain/event"
 	"github.com/zipper-project/zipper/common/crypto"
 	"github.com/zipper-project/zipper/common/log"
 	"github.com/zipper-project/zipper/common/utils/sortedlinkedlist"
@@ -46,6 +48,7 @@ type Validator interface {
 	GetTransactionByHash(txHash crypto.Hash) (*proto.Transaction, bool)
 	GetAsset(id uint32) *state.Asset
 	GetBalance(addr account.Address) *balance.Balance
+	GetNonce(addr account.Address) uint32
 }
 
 type Verification struct {
@@ -61,7 +64,13 @@ type Verification struct {
 	rwAccount          sync.RWMutex
 	assets             map[uint32]*state.Asset
 	inTxs              map[crypto.Hash]*proto.Transaction
+	nonces             map[string]uint32
+	futureTxs          map[string]map[uint32]*proto.Transaction
 	rwInTxs            sync.RWMutex
+	policy             *state.FeePolicy
+	policyHeight       uint32
+	policyLoaded       bool
+	rwPolicy           sync.Mutex
 	sync.RWMutex
 	//static map[crypto.Hash]time.Duration
 }
@@ -78,6 +87,8 @@ func NewVerification(config *Config, ledger *ledger.Ledger, consenter consensus.
 		accounts:           make(map[string]*balance.Balance),
 		assets:             make(map[uint32]*state.Asset),
 		inTxs:              make(map[crypto.Hash]*proto.Transaction),
+		nonces:             make(map[string]uint32),
+		futureTxs:          make(map[string]map[uint32]*proto.Transaction),
 	}
 }
 
@@ -103,6 +114,7 @@ func (v *Verification) makeRequestBatch() proto.Transactions {
 		return false
 	})
 
+	orderByNonce(requestBatch)
 	return requestBatch
 }
 
@@ -118,6 +130,8 @@ func (v *Verification) processLoop() {
 				}
 			}
 			v.rwBlacklist.Unlock()
+			v.promoteParked()
+			v.evictExpired()
 		case cnt := <-v.requestBatchSignal:
 			if cnt >= (v.config.TxPoolDelay + v.consenter.BatchSize()) {
 				requestBatch := v.makeRequestBatch()
@@ -133,26 +147,52 @@ func (v *Verification) processLoop() {
 	}
 }
 
+// ProcessTransaction adds tx to the transaction pool and publishes whether it was accepted, rejected or parked
 func (v *Verification) ProcessTransaction(tx *proto.Transaction) error {
+	parked, err := v.addTransaction(tx)
+	switch {
+	case err != nil:
+		v.ledger.Events().Publish(&event.TxRejected{Tx: tx, Reason: err.Error()})
+		return err
+	case parked:
+		v.ledger.Events().Publish(&event.TxParked{Tx: tx})
+	default:
+		v.ledger.Events().Publish(&event.TxAccepted{Tx: tx})
+	}
+	return nil
+}
+
+// addTransaction adds tx to the transaction pool, parked is true if tx waits for the earlier nonces of its sender instead
+func (v *Verification) addTransaction(tx *proto.Transaction) (parked bool, err error) {
 	startTime := time.Now()
 	if err := v.checkTransaction(tx); err != nil {
-		return err
+		return false, err
 	}
 
 	v.rwInTxs.Lock()
 	if v.isExist(tx) {
 		v.rwInTxs.Unlock()
-		return fmt.Errorf("transaction %s already existed", tx.Hash())
+		return false, fmt.Errorf("transaction %s already existed", tx.Hash())
+	}
+	if ready, err := v.checkNonce(tx); !ready {
+		v.rwInTxs.Unlock()
+		return err == nil, err
 	}
 
 	if v.isOverCapacity() {
 		elem := v.txpool.RemoveFront()
 		delete(v.inTxs, elem.(*proto.Transaction).Hash())
+		v.forgetNonce(elem.(*proto.Transaction))
 		log.Warnf("[validator]  excess capacity, remove front transaction")
 	}
 
 	v.txpool.Add(tx)
 	v.inTxs[tx.Hash()] = tx
+	for _, ptx := range v.useNonce(tx) {
+		v.txpool.Add(ptx)
+		v.inTxs[ptx.Hash()] = ptx
+		v.ledger.Events().Publish(&event.TxAccepted{Tx: ptx})
+	}
 	cnt := v.txpool.Len()
 	v.rwInTxs.Unlock()
 	if cnt == 1 {
@@ -162,7 +202,7 @@ func (v *Verification) ProcessTransaction(tx *proto.Transaction) error {
 	log.Debugf("ProcessTransaction, tx_hash: %+v time: %s", tx.Hash(), time.Now().Sub(startTime))
 	log.Debugf("[txPool] add transaction success, tx_hash: %s, sender: %s, receiver: %s, assetID %d, amount: %s,txpool_len: %d",
 		tx.Hash().String(), tx.Sender(), tx.Recipient(), tx.AssetID(), tx.Amount(), cnt)
-	return nil
+	return false, nil
 }
 
 func (v *Verification) consensusFailed(flag int, txs proto.Transactions) {
@@ -214,6 +254,7 @@ func (v *Verification) consensusFailed(flag int, txs proto.Transactions) {
 		var elems []sortedlinkedlist.IElement
 		for _, tx := range txs {
 			delete(v.inTxs, tx.Hash())
+			v.forgetNonce(tx)
 			elems = append(elems, tx)
 		}
 		v.txpool.Removes(elems)
@@ -231,13 +272,27 @@ func (v *Verification) VerifyTxs(txs proto.Transactions) (ttxs proto.Transaction
 	v.rwAccount.Lock()
 	defer v.rwInTxs.Unlock()
 	defer v.rwAccount.Unlock()
+	nonces := make(map[string]uint32)
+	now, next := time.Now(), v.nextHeight()
 	for _, tx := range txs {
+		var err error
 		if !v.isExist(tx) {
-			if err := v.checkTransaction(tx); err != nil {
-				etxs = append(etxs, tx)
-				log.Errorf("[validator] tx_hash: %s illegal, err %s", tx.Hash().String(), err)
-				continue
-			}
+			err = v.checkTransaction(tx)
+		} else {
+			// pool transactions were checked when they arrived, they may have expired since
+			err = v.checkExpiry(tx, now, next)
+		}
+		if err != nil {
+			v.reject(tx, err.Error())
+			etxs = append(etxs, tx)
+			log.Errorf("[validator] tx_hash: %s illegal, err %s", tx.Hash().String(), err)
+			continue
+		}
+		if err := v.verifyNonce(tx, nonces); err != nil {
+			v.reject(tx, err.Error())
+			etxs = append(etxs, tx)
+			log.Errorf("[validator] tx_hash: %s, %s", tx.Hash().String(), err)
+			continue
 		}
 
 		assetID := tx.AssetID()
@@ -247,6 +302,7 @@ func (v *Verification) VerifyTxs(txs proto.Transactions) (ttxs proto.Transaction
 		}
 		if tx.GetType() != proto.TransactionType_Issue {
 			if asset == nil {
+				v.reject(tx, fmt.Sprintf("asset %d not exist", tx.AssetID()))
 				etxs = append(etxs, tx)
 				log.Errorf("[validator] tx_hash: %s, asset %d not exist", tx.Hash().String(), tx.AssetID())
 				continue
@@ -254,12 +310,34 @@ func (v *Verification) VerifyTxs(txs proto.Transactions) (ttxs proto.Transaction
 			if tx.GetType() == proto.TransactionType_IssueUpdate && len(tx.Payload) > 0 {
 				newAsset, err := asset.Update(string(tx.Payload))
 				if err != nil {
+					v.reject(tx, fmt.Sprintf("update asset %d --- %s", assetID, err))
 					etxs = append(etxs, tx)
 					log.Errorf("[validator] tx_hash: %s, update asset %d(%s) --- %s", tx.Hash().String(), assetID, string(tx.Payload), err)
 					continue
 				}
 				v.assets[assetID] = newAsset
 			}
+			if tp := tx.GetType(); tp == proto.TransactionType_Mint || tp == proto.TransactionType_Burn {
+				newAsset, err := asset.ChangeSupply(tx)
+				if err != nil {
+					v.reject(tx, fmt.Sprintf("%s asset %d --- %s", tp, assetID, err))
+					etxs = append(etxs, tx)
+					log.Errorf("[validator] tx_hash: %s, %s asset %d --- %s", tx.Hash().String(), tp, assetID, err)
+					continue
+				}
+				if !v.updateAccount(tx) {
+					v.reject(tx, fmt.Sprintf("asset %d balance is not enough", tx.AssetID()))
+					etxs = append(etxs, tx)
+					log.Errorf("[validator] tx_hash: %s, asset %d balance is not enough", tx.Hash().String(), tx.AssetID())
+					continue
+				}
+				v.assets[assetID] = newAsset
+				if state.NonceEnforced(tx) {
+					nonces[tx.Sender().String()] = tx.Nonce()
+				}
+				ttxs = append(ttxs, tx)
+				continue
+			}
 		} else {
 			if asset == nil {
 				asset := &state.Asset{
@@ -268,13 +346,19 @@ func (v *Verification) VerifyTxs(txs proto.Transactions) (ttxs proto.Transaction
 					Owner:  tx.Recipient(),
 				}
 				newAsset, err := asset.Update(string(tx.Payload))
+				if err == nil {
+					newAsset.TotalSupply = new(big.Int)
+					newAsset, err = newAsset.Mint(tx.Amount())
+				}
 				if err != nil {
+					v.reject(tx, fmt.Sprintf("new issue asset %d --- %s", assetID, err))
 					etxs = append(etxs, tx)
 					log.Errorf("[validator] tx_hash: %s, new issue asset %d(%s) --- %s", tx.Hash().String(), assetID, string(tx.Payload), err)
 					continue
 				}
 				v.assets[assetID] = newAsset
 			} else {
+				v.reject(tx, fmt.Sprintf("new issue asset %d --- already exist", assetID))
 				etxs = append(etxs, tx)
 				log.Errorf("[validator] tx_hash: %s, new issue asset %d(%s) --- already exist", tx.Hash().String(), assetID, string(tx.Payload))
 				continue
@@ -283,16 +367,25 @@ func (v *Verification) VerifyTxs(txs proto.Transactions) (ttxs proto.Transaction
 
 		// remove balance is negative tx
 		if !v.updateAccount(tx) {
+			v.reject(tx, fmt.Sprintf("asset %d balance is not enough", tx.AssetID()))
 			etxs = append(etxs, tx)
 			log.Errorf("[validator] tx_hash: %s, asset %d balance is not enough", tx.Hash().String(), tx.AssetID())
 			continue
 		}
+		if state.NonceEnforced(tx) {
+			nonces[tx.Sender().String()] = tx.Nonce()
+		}
 		ttxs = append(ttxs, tx)
 	}
 
 	return ttxs, etxs
 }
 
+// reject publishes the rejection of tx by consensus verification
+func (v *Verification) reject(tx *proto.Transaction, reason string) {
+	v.ledger.Events().Publish(&event.TxRejected{Tx: tx, Reason: reason})
+}
+
 func (v *Verification) RemoveTxsInVerification(txs proto.Transactions) {
 	v.rwInTxs.Lock()
 	defer v.rwInTxs.Unlock()
@@ -312,36 +405,63 @@ func (v *Verification) fetchAccount(address account.Address) *balance.Balance {
 	return account
 }
 
+// feePolicy returns the committed fee policy, nil if there is none. The policy only changes with a
+// committed block, it is decoded once per block height
+func (v *Verification) feePolicy() *state.FeePolicy {
+	height, err := v.ledger.Height()
+	if err != nil {
+		log.Errorf("[validator] height --- %s", err)
+		return nil
+	}
+
+	v.rwPolicy.Lock()
+	defer v.rwPolicy.Unlock()
+	if v.policyLoaded && v.policyHeight == height {
+		return v.policy
+	}
+	policy, err := v.ledger.GetFeePolicy()
+	if err != nil {
+		log.Errorf("[validator] fee policy --- %s", err)
+		return nil
+	}
+	v.policy, v.policyHeight, v.policyLoaded = policy, height, true
+	return policy
+}
+
+// updateAccount applies tx to the balances of its accounts, the fees credited to the fee collector
+// aren't tracked as the proposer of the block isn't known yet
 func (v *Verification) updateAccount(tx *proto.Transaction) bool {
 	assetID := tx.AssetID()
-	plusAmount := tx.Amount()
-	plusFee := tx.Fee()
-	subAmount := -plusAmount
-	subFee := -plusFee
+	policy := v.feePolicy()
+	debit, credit := state.TransferAmounts(tx, policy)
 
 	if fromChain := coordinate.HexToChainCoordinate(tx.FromChain()).Bytes(); bytes.Equal(fromChain, params.ChainID) {
 		senderAccont := v.fetchAccount(tx.Sender())
 		if senderAccont != nil {
-			senderAccont.Add(assetID, subAmount)
-			senderAccont.Add(assetID, subFee)
+			senderAccont.Add(assetID, new(big.Int).Neg(debit))
 			//	log.Debugln("[validator] updateAccount sender: ", tx.Sender(), "amount: ", senderAccont.amount)
-			if (tx.GetType() != proto.TransactionType_Issue && tx.GetType() != proto.TransactionType_IssueUpdate) && senderAccont.Get(assetID) < 0 {
-				senderAccont.Add(assetID, plusAmount)
-				senderAccont.Add(assetID, plusFee)
+			if senderAccont.Get(assetID).Sign() < 0 {
+				senderAccont.Add(assetID, debit)
 				return false
 			}
+			if policy != nil {
+				senderAccont.Add(policy.AssetID, new(big.Int).Neg(tx.Fee()))
+				if senderAccont.Get(policy.AssetID).Sign() < 0 {
+					senderAccont.Add(policy.AssetID, tx.Fee())
+					senderAccont.Add(assetID, debit)
+					return false
+				}
+			}
 		}
 	}
 
 	if toChain := coordinate.HexToChainCoordinate(tx.ToChain()).Bytes(); bytes.Equal(toChain, params.ChainID) {
 		receiverAccount := v.fetchAccount(tx.Recipient())
 		if receiverAccount != nil {
-			receiverAccount.Add(assetID, plusAmount)
-			receiverAccount.Add(assetID, plusFee)
+			receiverAccount.Add(assetID, credit)
 			//	log.Debugln("[validator] updateAccount Recipient: ", tx.Recipient(), "amount: ", receiverAccount.amount)
-			if receiverAccount.Get(assetID) < 0 {
-				receiverAccount.Add(assetID, subAmount)
-				receiverAccount.Add(assetID, subFee)
+			if receiverAccount.Get(assetID).Sign() < 0 {
+				receiverAccount.Add(assetID, new(big.Int).Neg(credit))
 				return false
 			}
 		}
@@ -351,24 +471,23 @@ func (v *Verification) updateAccount(tx *proto.Transaction) bool {
 
 func (v *Verification) rollBackAccount(tx *proto.Transaction) {
 	assetID := tx.AssetID()
-	plusAmount := tx.Amount()
-	plusFee := tx.Fee()
-	subAmount := -plusAmount
-	subFee := -plusFee
+	policy := v.feePolicy()
+	debit, credit := state.TransferAmounts(tx, policy)
 
 	if fromChain := coordinate.HexToChainCoordinate(tx.FromChain()).Bytes(); bytes.Equal(fromChain, params.ChainID) {
 		senderAccont := v.fetchAccount(tx.Sender())
 		if senderAccont != nil {
-			senderAccont.Add(assetID, plusAmount)
-			senderAccont.Add(assetID, plusFee)
+			senderAccont.Add(assetID, debit)
+			if policy != nil {
+				senderAccont.Add(policy.AssetID, tx.Fee())
+			}
 		}
 	}
 
 	if toChain := coordinate.HexToChainCoordinate(tx.ToChain()).Bytes(); bytes.Equal(toChain, params.ChainID) {
 		receiverAccount := v.fetchAccount(tx.Recipient())
 		if receiverAccount != nil {
-			receiverAccount.Add(assetID, subAmount)
-			receiverAccount.Add(assetID, subFee)
+			receiverAccount.Add(assetID, new(big.Int).Neg(credit))
 		}
 	}
 }
@@ -400,6 +519,13 @@ func (v *Verification) GetBalance(addr account.Address) *balance.Balance {
 	return acconut
 }
 
+// GetNonce returns the nonce the next transaction of addr has to carry, counting the transactions in the pool
+func (v *Verification) GetNonce(addr account.Address) uint32 {
+	v.rwInTxs.Lock()
+	defer v.rwInTxs.Unlock()
+	return v.nextNonce(addr)
+}
+
 func (v *Verification) GetAsset(id uint32) *state.Asset {
 	v.rwAccount.Lock()
 	defer v.rwAccount.Unlock()
@@ -0,0 +1,61 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package validator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger"
+	"github.com/zipper-project/zipper/ledger/state"
+	"github.com/zipper-project/zipper/params"
+	"github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+func TestFeePolicyCache(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	policy := &state.FeePolicy{
+		AssetID: 1,
+		Pool:    account.HexToAddress("0xb032277be213f56221b6140998c03d860a60e1f8"),
+		MinFees: map[string]*big.Int{"Atomic": big.NewInt(2)},
+	}
+	genesis := ledger.DefaultGenesis()
+	genesis.Assets = []*state.Asset{{ID: 1, Name: "fee"}}
+	genesis.FeePolicy = policy
+	testDb := db.NewMemDB(db.DefaultConfig())
+	if _, err := ledger.InitGenesis(testDb, genesis); err != nil {
+		t.Fatal(err)
+	}
+	v := &Verification{config: DefaultConfig(), ledger: ledger.NewLedger(testDb)}
+	utils.AssertEquals(t, v.feePolicy().MinFee(proto.TransactionType_Atomic).Int64(), int64(2))
+
+	// the policy is decoded once per height, a change only shows with the next block
+	policy.MinFees["Atomic"] = big.NewInt(5)
+	value, _ := state.ConcrateStateJson(policy)
+	testDb.Put("scontract", []byte(state.ConstructCompositeKey(params.GlobalStateKey, params.FeePolicyKey)), value.Bytes())
+	utils.AssertEquals(t, v.feePolicy().MinFee(proto.TransactionType_Atomic).Int64(), int64(2))
+
+	if err := v.ledger.AppendBlock(&proto.Block{Header: &proto.BlockHeader{Version: proto.BlockVersion, Height: 1}}, true); err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, v.feePolicy().MinFee(proto.TransactionType_Atomic).Int64(), int64(5))
+}
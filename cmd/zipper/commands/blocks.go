@@ -0,0 +1,145 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zipper-project/zipper/ledger"
+)
+
+// blocksProgressInterval is the number of imported blocks between progress reports
+const blocksProgressInterval = 1000
+
+var (
+	blocksFrom uint32
+	blocksTo   uint32
+	blocksOut  string
+	blocksIn   string
+)
+
+// blocksCmd represents the blocks command
+var blocksCmd = &cobra.Command{
+	Use:   "blocks",
+	Short: "Export or import a block archive",
+	Long:  `Export a range of blocks with their transactions to an archive file, or replay an archive into the chain, the node must be stopped`,
+}
+
+var blocksExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a range of blocks",
+	Run: func(cmd *cobra.Command, args []string) {
+		if blocksOut == "" {
+			fmt.Println("blocks export requires --out")
+			os.Exit(-1)
+		}
+
+		l, err := openLedger()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		if !cmd.Flags().Changed("to") {
+			if blocksTo, err = l.Height(); err != nil {
+				fmt.Println(err)
+				os.Exit(-1)
+			}
+		}
+
+		f, err := os.Create(blocksOut)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		w := bufio.NewWriter(f)
+		err = l.ExportBlocks(w, blocksFrom, blocksTo)
+		if err == nil {
+			err = w.Flush()
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(blocksOut)
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		fmt.Printf("exported blocks %d to %d to %s\n", blocksFrom, blocksTo, blocksOut)
+	},
+}
+
+var blocksImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import the blocks of an archive",
+	Long: `Replay the blocks of an archive through the block validation of the node. Blocks the chain
+already has are skipped, the import stops at the first block that is rejected or doesn't re-execute to its archived hash`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if blocksIn == "" {
+			fmt.Println("blocks import requires --in")
+			os.Exit(-1)
+		}
+
+		f, err := os.Open(blocksIn)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		defer f.Close()
+
+		// the checksum is at the end of the archive, check it before any block is applied
+		from, to, err := ledger.CheckBlockArchive(bufio.NewReader(f))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		if _, err := f.Seek(0, os.SEEK_SET); err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+
+		bc, err := openBlockchain()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		start := time.Now()
+		imported, err := bc.ImportBlocks(bufio.NewReader(f), func(height uint32) {
+			if height%blocksProgressInterval == 0 || height == to {
+				fmt.Printf("imported block %d of %d-%d, %s elapsed\n", height, from, to, time.Since(start))
+			}
+		})
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		fmt.Printf("imported %d blocks, height %d\n", imported, bc.CurrentHeight())
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(blocksCmd)
+	blocksCmd.AddCommand(blocksExportCmd, blocksImportCmd)
+	blocksExportCmd.Flags().Uint32Var(&blocksFrom, "from", 0, "height of the first block to export")
+	blocksExportCmd.Flags().Uint32Var(&blocksTo, "to", 0, "height of the last block to export, the current height by default")
+	blocksExportCmd.Flags().StringVar(&blocksOut, "out", "", "archive file to write")
+	blocksImportCmd.Flags().StringVar(&blocksIn, "in", "", "archive file to read")
+}
@@ -0,0 +1,69 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zipper-project/zipper/ledger"
+)
+
+var genesisFile string
+
+// initCmd represents the init command
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Initialise a data dir with a genesis",
+	Long:  `Write the genesis block and initial state of a genesis file into an empty data dir, the default genesis is used without --genesis`,
+	Run: func(cmd *cobra.Command, args []string) {
+		genesis := ledger.DefaultGenesis()
+		if genesisFile != "" {
+			f, err := os.Open(genesisFile)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(-1)
+			}
+			genesis, err = ledger.ReadGenesis(f)
+			f.Close()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(-1)
+			}
+		}
+
+		kvdb, err := openDB()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		header, err := ledger.InitGenesis(kvdb, genesis)
+		kvdb.Close()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		fmt.Printf("initialised genesis block %s, state %s\n", header.Hash(), header.StateHash)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(initCmd)
+	initCmd.Flags().StringVar(&genesisFile, "genesis", "", "genesis file")
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package commands
+
+import (
+	"github.com/zipper-project/zipper/blockchain"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/log"
+	"github.com/zipper-project/zipper/config"
+	"github.com/zipper-project/zipper/ledger"
+)
+
+// readConfig reads the config file and sets up the log and the vm
+func readConfig() error {
+	if err := config.ReadInConfig(cfgFile); err != nil {
+		return err
+	}
+
+	cfg := config.NodeOption()
+	log.New(cfg.LogFile)
+	log.SetLevel(cfg.LogLevel)
+	config.VMConfig(cfg.LogFile, cfg.LogLevel)
+	return nil
+}
+
+// openDB opens the database of the configured data dir, the node must not be running
+func openDB() (db.Database, error) {
+	if err := readConfig(); err != nil {
+		return nil, err
+	}
+	return db.NewDB(config.DBConfig()), nil
+}
+
+// openLedger opens the ledger of the configured data dir, the node must not be running
+func openLedger() (*ledger.Ledger, error) {
+	kvdb, err := openDB()
+	if err != nil {
+		return nil, err
+	}
+	return ledger.NewLedger(kvdb), nil
+}
+
+// openBlockchain opens the blockchain of the configured data dir without starting its services, the node must not be running
+func openBlockchain() (*blockchain.Blockchain, error) {
+	if err := readConfig(); err != nil {
+		return nil, err
+	}
+	return blockchain.NewBlockchain(nil)
+}
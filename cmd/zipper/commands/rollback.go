@@ -0,0 +1,56 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var rollbackHeight uint32
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Roll the chain back to a height",
+	Long:  `Roll the chain back to a height by reverting the undo journals of the blocks above it, the node must be stopped`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !cmd.Flags().Changed("height") {
+			fmt.Println("rollback requires --height")
+			os.Exit(-1)
+		}
+
+		l, err := openLedger()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		if err := l.Rollback(rollbackHeight); err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		fmt.Printf("rolled back to height %d\n", rollbackHeight)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(rollbackCmd)
+	rollbackCmd.Flags().Uint32Var(&rollbackHeight, "height", 0, "height of the block to roll back to")
+}
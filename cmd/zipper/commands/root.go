@@ -37,7 +37,11 @@ var RootCmd = &cobra.Command{
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	Run: func(cmd *cobra.Command, args []string) {
-		zip := node.NewNode(cfgFile)
+		zip, err := node.NewNode(cfgFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
 		zip.Start()
 	},
 }
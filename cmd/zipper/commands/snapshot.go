@@ -0,0 +1,121 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zipper-project/zipper/ledger"
+)
+
+var (
+	snapshotHeight          uint32
+	snapshotFile            string
+	snapshotAllowUnverified bool
+)
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Export or import a state snapshot",
+	Long:  `Export the state at a height to a file, or bootstrap an empty data dir from it, the node must be stopped`,
+}
+
+var snapshotExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the state at a height",
+	Run: func(cmd *cobra.Command, args []string) {
+		if snapshotFile == "" {
+			fmt.Println("snapshot export requires --file")
+			os.Exit(-1)
+		}
+
+		l, err := openLedger()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		if !cmd.Flags().Changed("height") {
+			if snapshotHeight, err = l.Height(); err != nil {
+				fmt.Println(err)
+				os.Exit(-1)
+			}
+		}
+
+		f, err := os.Create(snapshotFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		w := bufio.NewWriter(f)
+		err = l.ExportSnapshot(w, snapshotHeight)
+		if err == nil {
+			err = w.Flush()
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(snapshotFile)
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		fmt.Printf("exported the state at height %d to %s\n", snapshotHeight, snapshotFile)
+	},
+}
+
+var snapshotImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Bootstrap an empty data dir from a snapshot",
+	Run: func(cmd *cobra.Command, args []string) {
+		if snapshotFile == "" {
+			fmt.Println("snapshot import requires --file")
+			os.Exit(-1)
+		}
+
+		f, err := os.Open(snapshotFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		defer f.Close()
+
+		kvdb, err := openDB()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		header, err := ledger.ImportSnapshot(kvdb, bufio.NewReader(f), snapshotAllowUnverified)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		fmt.Printf("imported the state at height %d, block hash %s\n", header.Height, header.Hash())
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.AddCommand(snapshotExportCmd, snapshotImportCmd)
+	snapshotCmd.PersistentFlags().StringVar(&snapshotFile, "file", "", "snapshot file")
+	snapshotExportCmd.Flags().Uint32Var(&snapshotHeight, "height", 0, "height of the block to export the state at, the current height by default")
+	snapshotImportCmd.Flags().BoolVar(&snapshotAllowUnverified, "allow-unverified", false, "import a state that doesn't match the state hash of its block, for blocks without a state trie root")
+}
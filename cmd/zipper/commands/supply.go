@@ -0,0 +1,57 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// supplyCmd represents the supply command
+var supplyCmd = &cobra.Command{
+	Use:   "supply",
+	Short: "Check the asset supplies",
+	Long: `Check that the balances of every asset sum to its recorded supply, the node must be stopped.
+Assets issued before supply tracking have no recorded supply and are skipped`,
+	Run: func(cmd *cobra.Command, args []string) {
+		l, err := openLedger()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		mismatches, err := l.CheckSupply()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		if len(mismatches) == 0 {
+			fmt.Println("balances match the supply of every asset")
+			return
+		}
+		for _, m := range mismatches {
+			fmt.Printf("asset %d balances sum to %s, its supply is %s\n", m.AssetID, m.Balances, m.Recorded)
+		}
+		os.Exit(-1)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(supplyCmd)
+}
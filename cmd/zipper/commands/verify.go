@@ -0,0 +1,80 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var reexecute bool
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the chain data",
+	Long: `Verify that every block header links to the header below it, the node must be stopped.
+With --reexecute every block is replayed from genesis into a scratch ledger and the recomputed
+transactions merkle hash and state hash are compared with the stored headers`,
+	Run: func(cmd *cobra.Command, args []string) {
+		l, err := openLedger()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		if err := l.VerifyChain(); err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		height, err := l.Height()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		fmt.Printf("block headers link up to height %d\n", height)
+		if !reexecute {
+			return
+		}
+
+		d, err := l.Reexecute()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		if d == nil {
+			fmt.Printf("re-executed %d blocks, no divergence\n", height)
+			return
+		}
+		if d.Field == "state" {
+			fmt.Printf("stored state diverges from the state re-executed up to block %d\n", d.Height)
+		} else {
+			fmt.Printf("block %d diverges, %s stored %s recomputed %s\n", d.Height, d.Field, d.Stored, d.Recomputed)
+		}
+		for _, k := range d.Keys {
+			fmt.Printf("  %s %q stored %x recomputed %x\n", k.CfName, k.Key, k.Stored, k.Recomputed)
+		}
+		os.Exit(-1)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().BoolVar(&reexecute, "reexecute", false, "replay every block from genesis and compare the recomputed hashes")
+}
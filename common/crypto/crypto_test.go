@@ -77,6 +77,49 @@ func TestHash(t *testing.T) {
 	}
 }
 
+func TestMerkleBranch(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		var hashs []Hash
+		for i := 0; i < n; i++ {
+			hashs = append(hashs, Sha256([]byte{byte(i)}))
+		}
+		root := GetMerkleHash(hashs)
+
+		for i := 0; i < n; i++ {
+			branch := ComputeMerkleBranch(hashs, i)
+			if !VerifyMerkleBranch(hashs[i], i, n, branch, root) {
+				t.Errorf("merkle branch of %d/%d doesn't verify", i, n)
+			}
+			if VerifyMerkleBranch(hashs[i], i+1<<uint(len(branch)), n, branch, root) {
+				t.Errorf("merkle branch of %d/%d verifies at an out of range index", i, n)
+			}
+			if n > 1 && VerifyMerkleBranch(hashs[(i+1)%n], i, n, branch, root) {
+				t.Errorf("merkle branch of %d/%d verifies another hash", i, n)
+			}
+		}
+	}
+}
+
+func TestMerkleBranchOutOfRange(t *testing.T) {
+	var hashs []Hash
+	for i := 0; i < 5; i++ {
+		hashs = append(hashs, Sha256([]byte{byte(i)}))
+	}
+	root := GetMerkleHash(hashs)
+
+	// the last hash of the odd level is its own sibling, the branch would also lead to the root from index 5
+	branch := ComputeMerkleBranch(hashs, 4)
+	if !VerifyMerkleBranch(hashs[4], 4, 5, branch, root) {
+		t.Fatal("merkle branch of the last hash doesn't verify")
+	}
+	if VerifyMerkleBranch(hashs[4], 5, 5, branch, root) || VerifyMerkleBranch(hashs[4], 5, 6, branch, root) {
+		t.Error("merkle branch verifies past the last hash")
+	}
+	if VerifyMerkleBranch(hashs[4], 4, 6, branch, root) {
+		t.Error("merkle branch verifies with a wrong hash count")
+	}
+}
+
 func TestLoadAndSaveECDSA(t *testing.T) {
 	priv, _ := HexToECDSA(testPrivateKey)
 	priv.SaveECDSA("nodekey")
@@ -126,11 +126,15 @@ func CalcHash(a, b Hash) Hash {
 
 // ComputeMerkleHash returns the merkle root hash of the hash lists
 func ComputeMerkleHash(data []Hash) []Hash {
-	length := len(data)
-	if length <= 1 {
+	if len(data) <= 1 {
 		return data
 	}
+	return ComputeMerkleHash(merkleParents(data))
+}
 
+// merkleParents returns the next level of the merkle tree, the last hash is paired with itself if odd
+func merkleParents(data []Hash) []Hash {
+	length := len(data)
 	digests := make([]Hash, 0)
 	for i := 0; i < length/2*2; i += 2 {
 		h := CalcHash(data[i], data[i+1])
@@ -143,9 +147,53 @@ func ComputeMerkleHash(data []Hash) []Hash {
 		h.Reverse()
 		digests = append(digests, h)
 	}
-	data = digests
+	return digests
+}
+
+// ComputeMerkleBranch returns the sibling hashes on the path from data[index] to the merkle root
+func ComputeMerkleBranch(data []Hash, index int) []Hash {
+	if index < 0 || index >= len(data) {
+		return nil
+	}
+
+	branch := make([]Hash, 0)
+	for len(data) > 1 {
+		sibling := index ^ 1
+		if sibling >= len(data) {
+			sibling = index
+		}
+		branch = append(branch, data[sibling])
+		data = merkleParents(data)
+		index /= 2
+	}
+	return branch
+}
 
-	return ComputeMerkleHash(data)
+// VerifyMerkleBranch reports whether the branch leads from hash h at index of leafCount hashes to the merkle root.
+// The last hash of an odd level is paired with itself, so its sibling has to be the hash itself and any other
+// sibling has to differ from it, otherwise the branch would also verify at a position past the last hash
+func VerifyMerkleBranch(h Hash, index, leafCount int, branch []Hash, root Hash) bool {
+	if index < 0 || index >= leafCount {
+		return false
+	}
+	n := leafCount
+	for _, sibling := range branch {
+		if n <= 1 {
+			return false
+		}
+		if (index^1 >= n) != sibling.Equal(h) {
+			return false
+		}
+		if index%2 == 0 {
+			h = CalcHash(h, sibling)
+		} else {
+			h = CalcHash(sibling, h)
+		}
+		h.Reverse()
+		index /= 2
+		n = (n + 1) / 2
+	}
+	return n == 1 && h.Equal(root)
 }
 
 // GetMerkleHash returns the final hash
@@ -0,0 +1,185 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package db
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/zipper-project/zipper/common/log"
+)
+
+const (
+	// OperationPut represents put operation
+	OperationPut uint = iota
+	// OperationDelete represents delete operation
+	OperationDelete
+)
+
+// storage engines
+const (
+	EngineRocksDB = "rocksdb"
+	EngineLevelDB = "leveldb"
+	EngineMemDB   = "memdb"
+)
+
+var (
+	deafultColumnfamilies = []string{"asset", "balance", "ledger", "peer", "index", "state", "block", "transaction", "storage", "scontract", "persistCacheTxs", "statetrie", "receipt", "undo", "history", "event"}
+
+	// defaultEngine is rocksdb when built with the rocksdb tag, leveldb otherwise
+	defaultEngine = EngineLevelDB
+
+	engines = map[string]func(c *Config) (Database, error){
+		EngineLevelDB: func(c *Config) (Database, error) { return NewLevelDB(c) },
+		EngineMemDB:   func(c *Config) (Database, error) { return NewMemDB(c), nil },
+	}
+)
+
+// Database is a key value store with column families
+type Database interface {
+	// Get returns the value of key, or nil if key doesn't exist
+	Get(cfName string, key []byte) ([]byte, error)
+	// GetByPrefix returns the key values with prefix in ascending key order
+	GetByPrefix(cfName string, prefix []byte) []*KeyValue
+	// GetByRange returns the key values from startKey up to and including limitKey in ascending key order
+	GetByRange(cfName string, startKey []byte, limitKey []byte) []*KeyValue
+	// NewIterator returns an iterator over the keys with prefix, starting at the first key not less than start.
+	// The iterator must be released after use
+	NewIterator(cfName string, prefix []byte, start []byte) Iterator
+	Put(cfName string, key []byte, value []byte) error
+	Delete(cfName string, key []byte) error
+	// AtomicWrite applies all the write batches or none of them
+	AtomicWrite(writeBatchs []*WriteBatch) error
+	// ColumnFamilies returns the names of the column families
+	ColumnFamilies() []string
+	Close()
+}
+
+// Iterator iterates over the keys of a column family in ascending order
+type Iterator interface {
+	// Next moves the iterator to the next key and reports whether there is one
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Release()
+}
+
+//KeyValue key value
+type KeyValue struct {
+	Key   []byte `enc:"1"`
+	Value []byte `enc:"2"`
+}
+
+// Config is the configuration of the database
+type Config struct {
+	Engine            string
+	DbPath            string
+	Columnfamilies    []string
+	KeepLogFileNumber int
+	MaxLogFileSize    int
+	LogLevel          string
+}
+
+// WriteBatch wrappers batch operation
+type WriteBatch struct {
+	CfName    string
+	Operation uint
+	Key       []byte
+	Value     []byte
+	Typ       string
+}
+
+// DefaultConfig defines the default configuration of the database
+func DefaultConfig() *Config {
+	return &Config{
+		Engine:            defaultEngine,
+		DbPath:            "/tmp/rocksdb-test/",
+		Columnfamilies:    deafultColumnfamilies,
+		KeepLogFileNumber: 10,
+		MaxLogFileSize:    10485760,
+		LogLevel:          "warn",
+	}
+}
+
+// Open opens the database of the configured engine
+func Open(c *Config) (Database, error) {
+	engine := c.Engine
+	if engine == "" {
+		engine = defaultEngine
+	}
+	open, ok := engines[engine]
+	if !ok && engine == EngineRocksDB {
+		return nil, fmt.Errorf("db engine %s requires building with the rocksdb tag", engine)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unsupported db engine %s", engine)
+	}
+	return open(c)
+}
+
+// NewDB returns a database instance, it panics if the database can't be opened
+func NewDB(c *Config) Database {
+	db, err := Open(c)
+	if err != nil {
+		panic(fmt.Sprintf("failed to open db, error: [%s]", err))
+	}
+	return db
+}
+
+// NewWriteBatch returns a writebatch instance
+func NewWriteBatch(cfName string, operation uint, key, value []byte, typ string) *WriteBatch {
+	return &WriteBatch{
+		CfName:    cfName,
+		Operation: operation,
+		Key:       key,
+		Value:     value,
+		Typ:       typ,
+	}
+}
+
+// columnFamilies returns the configured column families with the default one
+func columnFamilies(c *Config) []string {
+	return append(append([]string{}, c.Columnfamilies...), "default")
+}
+
+func columnNotExists(cfName string) {
+	log.Errorf("column family does not exist %s", cfName)
+	panic("column family does not exist")
+}
+
+func getByPrefix(db Database, cfName string, prefix []byte) []*KeyValue {
+	var keyValues []*KeyValue
+	it := db.NewIterator(cfName, prefix, nil)
+	defer it.Release()
+	for it.Next() {
+		keyValues = append(keyValues, &KeyValue{Key: it.Key(), Value: it.Value()})
+	}
+	return keyValues
+}
+
+func getByRange(db Database, cfName string, startKey []byte, limitKey []byte) []*KeyValue {
+	var keyValues []*KeyValue
+	it := db.NewIterator(cfName, nil, startKey)
+	defer it.Release()
+	for it.Next() {
+		keyValues = append(keyValues, &KeyValue{Key: it.Key(), Value: it.Value()})
+		if bytes.Equal(it.Key(), limitKey) {
+			break
+		}
+	}
+	return keyValues
+}
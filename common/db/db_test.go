@@ -0,0 +1,177 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/zipper-project/zipper/common/utils"
+)
+
+// forEachEngine runs f on an empty database of every engine
+func forEachEngine(t *testing.T, f func(t *testing.T, db Database)) {
+	names := make([]string, 0, len(engines))
+	for engine := range engines {
+		names = append(names, engine)
+	}
+	sort.Strings(names)
+
+	for _, engine := range names {
+		dir, err := ioutil.TempDir("", "zipper-db-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := DefaultConfig()
+		c.Engine = engine
+		c.DbPath = dir
+		db := NewDB(c)
+		t.Run(engine, func(t *testing.T) { f(t, db) })
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestReadAndWrite(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db Database) {
+		// Put
+		err := db.Put("default", []byte("foo"), []byte("bar"))
+		if err != nil {
+			t.Fatalf("faild to put, err: [%s]", err)
+		}
+		// Get
+		value, err1 := db.Get("default", []byte("foo"))
+		if err1 != nil {
+			t.Fatalf("faild to get, err: [%s]", err1)
+		}
+
+		utils.AssertEquals(t, value, []byte("bar"))
+	})
+}
+
+func TestDelete(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db Database) {
+		err := db.Put("default", []byte("foo"), []byte("bar"))
+		if err != nil {
+			t.Fatalf("faild to put, err: [%s]", err)
+		}
+		db.Delete("default", []byte("foo"))
+		value, err1 := db.Get("default", []byte("foo"))
+		if err1 != nil {
+			t.Fatalf("faild to delete, err: [%s]", err)
+		}
+		if value != nil {
+			t.Fatalf("faild to put")
+		}
+	})
+}
+
+func TestGetByRangeOrPrefix(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db Database) {
+		for i := 0; i < 10; i++ {
+			key := []byte("key_" + strconv.Itoa(i))
+			value := []byte("value_" + strconv.Itoa(i))
+
+			err := db.Put("balance", key, value)
+			if err != nil {
+				t.Fatalf("faild to put, err: [%s]", err)
+			}
+			key1 := []byte("key_1" + strconv.Itoa(i))
+			value1 := []byte("value_1" + strconv.Itoa(i))
+
+			err = db.Put("balance", key1, value1)
+			if err != nil {
+				t.Fatalf("faild to put, err: [%s]", err)
+			}
+		}
+
+		values := db.GetByRange("balance", []byte("key_1"), []byte("key_3"))
+		for _, v := range values {
+			t.Log("key: ", string(v.Key), "value: ", string(v.Value))
+		}
+		utils.AssertEquals(t, len(values), 13)
+
+		t.Log("-------------------------------")
+
+		values1 := db.GetByPrefix("balance", []byte("key_1"))
+		for _, v := range values1 {
+			t.Log("key: ", string(v.Key), "value: ", string(v.Value))
+		}
+		utils.AssertEquals(t, len(values1), 11)
+	})
+}
+
+func TestIterator(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db Database) {
+		for i := 0; i < 5; i++ {
+			db.Put("index", []byte("iter_"+strconv.Itoa(i)), []byte(strconv.Itoa(i)))
+		}
+		db.Put("index", []byte("iter"), []byte("-"))
+		db.Put("index", []byte("itex_0"), []byte("-"))
+
+		var keys []string
+		iter := db.NewIterator("index", []byte("iter_"), []byte("iter_2"))
+		for iter.Next() {
+			keys = append(keys, string(iter.Key()))
+		}
+		iter.Release()
+		utils.AssertEquals(t, keys, []string{"iter_2", "iter_3", "iter_4"})
+
+		keys = nil
+		iter = db.NewIterator("index", []byte("iter_"), nil)
+		for iter.Next() {
+			keys = append(keys, string(iter.Key()))
+		}
+		iter.Release()
+		utils.AssertEquals(t, len(keys), 5)
+	})
+}
+
+func TestWriteBatch(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db Database) {
+		var writeBatchs []*WriteBatch
+		for i := 0; i < 100; i++ {
+			writeBatchs = append(writeBatchs, NewWriteBatch("balance", OperationPut, []byte("key"+strconv.Itoa(i)), []byte("value"+strconv.Itoa(i)), "balance"))
+		}
+		t.Log("start writeBatch...")
+		var cnt int
+		for i := 0; i < 100; i++ {
+			t.Log("times: ", cnt)
+			db.AtomicWrite(writeBatchs)
+			cnt++
+		}
+	})
+}
+
+func TestColumnFamilies(t *testing.T) {
+	forEachEngine(t, func(t *testing.T, db Database) {
+		db.AtomicWrite([]*WriteBatch{
+			NewWriteBatch("asset", OperationPut, []byte("key"), []byte("asset"), "asset"),
+			NewWriteBatch("balance", OperationPut, []byte("key"), []byte("balance"), "balance"),
+		})
+		value, err := db.Get("asset", []byte("key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.AssertEquals(t, value, []byte("asset"))
+		utils.AssertEquals(t, len(db.GetByPrefix("balance", nil)), 1)
+		utils.AssertEquals(t, len(db.GetByPrefix("index", nil)), 0)
+	})
+}
@@ -0,0 +1,152 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package db
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// LevelDB is a pure Go database, column families are kept apart by prefixing their keys with the column family name
+type LevelDB struct {
+	db  *leveldb.DB
+	cfs map[string][]byte
+}
+
+// NewLevelDB opens the leveldb at the configured path
+func NewLevelDB(config *Config) (*LevelDB, error) {
+	db, err := leveldb.OpenFile(config.DbPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ldb := &LevelDB{db: db, cfs: make(map[string][]byte)}
+	for _, cfName := range columnFamilies(config) {
+		ldb.cfs[cfName] = append([]byte(cfName), 0)
+	}
+	return ldb, nil
+}
+
+func (ldb *LevelDB) key(cfName string, key []byte) []byte {
+	prefix, ok := ldb.cfs[cfName]
+	if !ok {
+		columnNotExists(cfName)
+	}
+	return append(append([]byte{}, prefix...), key...)
+}
+
+// Get returns the value for the given column family and key
+func (ldb *LevelDB) Get(cfName string, key []byte) ([]byte, error) {
+	value, err := ldb.db.Get(ldb.key(cfName, key), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	return value, err
+}
+
+// GetByPrefix for bulk reads
+func (ldb *LevelDB) GetByPrefix(cfName string, prefix []byte) []*KeyValue {
+	return getByPrefix(ldb, cfName, prefix)
+}
+
+//GetByRange Range query
+func (ldb *LevelDB) GetByRange(cfName string, startKey []byte, limitKey []byte) []*KeyValue {
+	return getByRange(ldb, cfName, startKey, limitKey)
+}
+
+// NewIterator returns an iterator over the keys with prefix, starting at the first key not less than start.
+// The iterator must be released after use
+func (ldb *LevelDB) NewIterator(cfName string, prefix []byte, start []byte) Iterator {
+	if bytes.Compare(start, prefix) < 0 {
+		start = prefix
+	}
+	r := util.BytesPrefix(ldb.key(cfName, prefix))
+	r.Start = ldb.key(cfName, start)
+	return &leveldbIterator{it: ldb.db.NewIterator(r, nil), skip: len(ldb.cfs[cfName])}
+}
+
+// Put saves the key/value in the given column family
+func (ldb *LevelDB) Put(cfName string, key []byte, value []byte) error {
+	return ldb.db.Put(ldb.key(cfName, key), value, nil)
+}
+
+// Delete deletes the given key in the specified column family
+func (ldb *LevelDB) Delete(cfName string, key []byte) error {
+	return ldb.db.Delete(ldb.key(cfName, key), nil)
+}
+
+// AtomicWrite writes batch
+func (ldb *LevelDB) AtomicWrite(writeBatchs []*WriteBatch) error {
+	batch := new(leveldb.Batch)
+	for _, writeBatch := range writeBatchs {
+		switch writeBatch.Operation {
+		case OperationPut:
+			batch.Put(ldb.key(writeBatch.CfName, writeBatch.Key), writeBatch.Value)
+		case OperationDelete:
+			batch.Delete(ldb.key(writeBatch.CfName, writeBatch.Key))
+		}
+	}
+	return ldb.db.Write(batch, nil)
+}
+
+// ColumnFamilies returns the names of the column families
+func (ldb *LevelDB) ColumnFamilies() []string {
+	cfs := make([]string, 0, len(ldb.cfs))
+	for cfName := range ldb.cfs {
+		cfs = append(cfs, cfName)
+	}
+	sort.Strings(cfs)
+	return cfs
+}
+
+// Close closes leveldb
+func (ldb *LevelDB) Close() {
+	ldb.db.Close()
+}
+
+type leveldbIterator struct {
+	it   iterator.Iterator
+	skip int
+}
+
+func (iter *leveldbIterator) Next() bool {
+	return iter.it.Next()
+}
+
+func (iter *leveldbIterator) Key() []byte {
+	key := iter.it.Key()
+	if key == nil {
+		return nil
+	}
+	return append([]byte{}, key[iter.skip:]...)
+}
+
+func (iter *leveldbIterator) Value() []byte {
+	value := iter.it.Value()
+	if value == nil {
+		return nil
+	}
+	return append([]byte{}, value...)
+}
+
+func (iter *leveldbIterator) Release() {
+	iter.it.Release()
+}
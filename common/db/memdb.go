@@ -0,0 +1,170 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package db
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemDB is an in-memory database, its content is lost on close
+type MemDB struct {
+	sync.RWMutex
+	cfs map[string]map[string][]byte
+}
+
+// NewMemDB returns an empty in-memory database with the configured column families
+func NewMemDB(config *Config) *MemDB {
+	db := &MemDB{cfs: make(map[string]map[string][]byte)}
+	for _, cfName := range columnFamilies(config) {
+		db.cfs[cfName] = make(map[string][]byte)
+	}
+	return db
+}
+
+func (db *MemDB) cf(cfName string) map[string][]byte {
+	cf, ok := db.cfs[cfName]
+	if !ok {
+		columnNotExists(cfName)
+	}
+	return cf
+}
+
+// Get returns the value for the given column family and key
+func (db *MemDB) Get(cfName string, key []byte) ([]byte, error) {
+	db.RLock()
+	defer db.RUnlock()
+	value, ok := db.cf(cfName)[string(key)]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte{}, value...), nil
+}
+
+// GetByPrefix for bulk reads
+func (db *MemDB) GetByPrefix(cfName string, prefix []byte) []*KeyValue {
+	return getByPrefix(db, cfName, prefix)
+}
+
+//GetByRange Range query
+func (db *MemDB) GetByRange(cfName string, startKey []byte, limitKey []byte) []*KeyValue {
+	return getByRange(db, cfName, startKey, limitKey)
+}
+
+// NewIterator returns an iterator over the keys with prefix, starting at the first key not less than start.
+// The iterator sees the content at the time it is created
+func (db *MemDB) NewIterator(cfName string, prefix []byte, start []byte) Iterator {
+	db.RLock()
+	defer db.RUnlock()
+	if bytes.Compare(start, prefix) < 0 {
+		start = prefix
+	}
+
+	it := &memIterator{pos: -1}
+	for key, value := range db.cf(cfName) {
+		if strings.HasPrefix(key, string(prefix)) && key >= string(start) {
+			it.kvs = append(it.kvs, &KeyValue{Key: []byte(key), Value: append([]byte{}, value...)})
+		}
+	}
+	sort.Slice(it.kvs, func(i, j int) bool { return bytes.Compare(it.kvs[i].Key, it.kvs[j].Key) < 0 })
+	return it
+}
+
+// Put saves the key/value in the given column family
+func (db *MemDB) Put(cfName string, key []byte, value []byte) error {
+	db.Lock()
+	defer db.Unlock()
+	db.cf(cfName)[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+// Delete deletes the given key in the specified column family
+func (db *MemDB) Delete(cfName string, key []byte) error {
+	db.Lock()
+	defer db.Unlock()
+	delete(db.cf(cfName), string(key))
+	return nil
+}
+
+// AtomicWrite writes batch
+func (db *MemDB) AtomicWrite(writeBatchs []*WriteBatch) error {
+	db.Lock()
+	defer db.Unlock()
+	for _, writeBatch := range writeBatchs {
+		db.cf(writeBatch.CfName)
+	}
+	for _, writeBatch := range writeBatchs {
+		switch writeBatch.Operation {
+		case OperationPut:
+			db.cfs[writeBatch.CfName][string(writeBatch.Key)] = append([]byte{}, writeBatch.Value...)
+		case OperationDelete:
+			delete(db.cfs[writeBatch.CfName], string(writeBatch.Key))
+		}
+	}
+	return nil
+}
+
+// ColumnFamilies returns the names of the column families
+func (db *MemDB) ColumnFamilies() []string {
+	cfs := make([]string, 0, len(db.cfs))
+	for cfName := range db.cfs {
+		cfs = append(cfs, cfName)
+	}
+	sort.Strings(cfs)
+	return cfs
+}
+
+// Close drops the content of the database
+func (db *MemDB) Close() {
+	db.Lock()
+	defer db.Unlock()
+	for cfName := range db.cfs {
+		db.cfs[cfName] = make(map[string][]byte)
+	}
+}
+
+type memIterator struct {
+	kvs []*KeyValue
+	pos int
+}
+
+func (it *memIterator) Next() bool {
+	if it.pos < len(it.kvs) {
+		it.pos++
+	}
+	return it.pos < len(it.kvs)
+}
+
+func (it *memIterator) Key() []byte {
+	if it.pos < 0 || it.pos >= len(it.kvs) {
+		return nil
+	}
+	return it.kvs[it.pos].Key
+}
+
+func (it *memIterator) Value() []byte {
+	if it.pos < 0 || it.pos >= len(it.kvs) {
+		return nil
+	}
+	return it.kvs[it.pos].Value
+}
+
+func (it *memIterator) Release() {
+	it.kvs = nil
+}
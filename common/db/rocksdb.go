@@ -14,104 +14,45 @@
 //
 // You should have received a copy of the ISC License
 // along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+//go:build rocksdb
+// +build rocksdb
+
 package db
 
 import (
 	"bytes"
-	"fmt"
-	"sync"
+	"sort"
 
 	"github.com/tecbot/gorocksdb"
 	"github.com/zipper-project/zipper/common/log"
 	"github.com/zipper-project/zipper/common/utils"
 )
 
-const (
-	// OperationPut represents put operation
-	OperationPut uint = iota
-	// OperationDelete represents delete operation
-	OperationDelete
-)
-
-var (
-	deafultColumnfamilies = []string{"asset", "balance", "ledger", "peer", "index", "state", "block", "transaction", "storage", "scontract", "persistCacheTxs"}
-	config                *Config
-	dbInstance            *BlockchainDB
-	once                  sync.Once
-
-	rocksDBLogLevelMap = map[string]gorocksdb.InfoLogLevel{
-		"debug": gorocksdb.DebugInfoLogLevel,
-		"info":  gorocksdb.InfoInfoLogLevel,
-		"warn":  gorocksdb.WarnInfoLogLevel,
-		"error": gorocksdb.ErrorInfoLogLevel,
-		"fatal": gorocksdb.FatalInfoLogLevel,
-	}
-)
+var rocksDBLogLevelMap = map[string]gorocksdb.InfoLogLevel{
+	"debug": gorocksdb.DebugInfoLogLevel,
+	"info":  gorocksdb.InfoInfoLogLevel,
+	"warn":  gorocksdb.WarnInfoLogLevel,
+	"error": gorocksdb.ErrorInfoLogLevel,
+	"fatal": gorocksdb.FatalInfoLogLevel,
+}
 
-//KeyValue key value
-type KeyValue struct {
-	Key   []byte
-	Value []byte
+func init() {
+	defaultEngine = EngineRocksDB
+	engines[EngineRocksDB] = func(c *Config) (Database, error) { return NewRocksDB(c) }
 }
 
 // CfHandlerMap is columnfamilies handler set
 type CfHandlerMap map[string]*gorocksdb.ColumnFamilyHandle
 
-// BlockchainDB encapsulates rocksdb's structures
-type BlockchainDB struct {
+// RocksDB encapsulates rocksdb's structures
+type RocksDB struct {
 	DB         *gorocksdb.DB
 	cfHandlers CfHandlerMap
 }
 
-// Config is the configuration of the gorocksdb
-type Config struct {
-	DbPath            string
-	Columnfamilies    []string
-	KeepLogFileNumber int
-	MaxLogFileSize    int
-	LogLevel          string
-}
-
-// WriteBatch wrappers batch operation
-type WriteBatch struct {
-	CfName    string
-	Operation uint
-	Key       []byte
-	Value     []byte
-	Typ       string
-}
-
-// DefaultConfig defines the default configuration of the rocksdb
-func DefaultConfig() *Config {
-	return &Config{
-		DbPath:            "/tmp/rocksdb-test/",
-		Columnfamilies:    deafultColumnfamilies,
-		KeepLogFileNumber: 10,
-		MaxLogFileSize:    10485760,
-		LogLevel:          "warn",
-	}
-}
-
-//GetDBInstance returns db instance
-func GetDBInstance() *BlockchainDB {
-	if dbInstance == nil {
-		NewDB(DefaultConfig())
-	}
-	return dbInstance
-}
-
-// NewDB returns a basic db instance
-func NewDB(c *Config) *BlockchainDB {
-	once.Do(func() {
-		config = c
-
-		dbInstance = &BlockchainDB{}
-		dbInstance.open()
-	})
-	return dbInstance
-}
-
-func (blockchainDB *BlockchainDB) open() {
+// NewRocksDB opens the rocksdb at the configured path
+func NewRocksDB(config *Config) (*RocksDB, error) {
 	opts := gorocksdb.NewDefaultOptions()
 	defer opts.Destroy()
 
@@ -135,25 +76,26 @@ func (blockchainDB *BlockchainDB) open() {
 
 	var cfOpts []*gorocksdb.Options
 
-	Columnfamilies := append(config.Columnfamilies, "default")
+	Columnfamilies := columnFamilies(config)
 	for range Columnfamilies {
 		cfOpts = append(cfOpts, opts)
 	}
 
 	db, cfHandlers, err := gorocksdb.OpenDbColumnFamilies(opts, config.DbPath, Columnfamilies, cfOpts)
 	if err != nil {
-		panic(fmt.Sprintf("failed to open db, error: [%s]", err))
+		return nil, err
 	}
 
-	blockchainDB.DB = db
+	blockchainDB := &RocksDB{DB: db}
 	blockchainDB.cfHandlers = make(map[string]*gorocksdb.ColumnFamilyHandle)
 	for index, cfName := range Columnfamilies {
 		blockchainDB.cfHandlers[cfName] = cfHandlers[index]
 	}
+	return blockchainDB, nil
 }
 
 // Close releases all column family handles and closes rocksdb
-func (blockchainDB *BlockchainDB) Close() {
+func (blockchainDB *RocksDB) Close() {
 	for cfName := range blockchainDB.cfHandlers {
 		blockchainDB.cfHandlers[cfName].Destroy()
 	}
@@ -161,7 +103,7 @@ func (blockchainDB *BlockchainDB) Close() {
 }
 
 // Get returns the value for the given column family and key
-func (blockchainDB *BlockchainDB) Get(cfName string, key []byte) ([]byte, error) {
+func (blockchainDB *RocksDB) Get(cfName string, key []byte) ([]byte, error) {
 	blockchainDB.checkIfColumnExists(cfName)
 
 	opt := gorocksdb.NewDefaultReadOptions()
@@ -180,7 +122,7 @@ func (blockchainDB *BlockchainDB) Get(cfName string, key []byte) ([]byte, error)
 }
 
 // GetByPrefix for bulk reads
-func (blockchainDB *BlockchainDB) GetByPrefix(cfName string, prefix []byte) []*KeyValue {
+func (blockchainDB *RocksDB) GetByPrefix(cfName string, prefix []byte) []*KeyValue {
 	blockchainDB.checkIfColumnExists(cfName)
 
 	var keyValues []*KeyValue
@@ -200,7 +142,7 @@ func (blockchainDB *BlockchainDB) GetByPrefix(cfName string, prefix []byte) []*K
 }
 
 //GetByRange Range query
-func (blockchainDB *BlockchainDB) GetByRange(cfName string, startkey []byte, limitKey []byte) []*KeyValue {
+func (blockchainDB *RocksDB) GetByRange(cfName string, startkey []byte, limitKey []byte) []*KeyValue {
 	blockchainDB.checkIfColumnExists(cfName)
 
 	var keyValues []*KeyValue
@@ -223,8 +165,65 @@ func (blockchainDB *BlockchainDB) GetByRange(cfName string, startkey []byte, lim
 	return keyValues
 }
 
+type rocksdbIterator struct {
+	ro      *gorocksdb.ReadOptions
+	it      *gorocksdb.Iterator
+	prefix  []byte
+	started bool
+	key     []byte
+	value   []byte
+}
+
+// NewIterator returns an iterator over the keys with prefix, starting at the first key not less than start.
+// The iterator must be released after use
+func (blockchainDB *RocksDB) NewIterator(cfName string, prefix []byte, start []byte) Iterator {
+	blockchainDB.checkIfColumnExists(cfName)
+
+	ro := gorocksdb.NewDefaultReadOptions()
+	ro.SetFillCache(true)
+	it := blockchainDB.DB.NewIteratorCF(ro, blockchainDB.cfHandlers[cfName])
+	if bytes.Compare(start, prefix) < 0 {
+		start = prefix
+	}
+	it.Seek(start)
+	return &rocksdbIterator{ro: ro, it: it, prefix: prefix}
+}
+
+// Next moves the iterator to the next key and reports whether there is one
+func (iter *rocksdbIterator) Next() bool {
+	if iter.started {
+		iter.it.Next()
+	}
+	iter.started = true
+	if !iter.it.ValidForPrefix(iter.prefix) {
+		iter.key, iter.value = nil, nil
+		return false
+	}
+	k, v := iter.it.Key(), iter.it.Value()
+	iter.key, iter.value = utils.MinimizeSilce(k.Data()), utils.MinimizeSilce(v.Data())
+	k.Free()
+	v.Free()
+	return true
+}
+
+// Key returns the key at the current position
+func (iter *rocksdbIterator) Key() []byte {
+	return iter.key
+}
+
+// Value returns the value at the current position
+func (iter *rocksdbIterator) Value() []byte {
+	return iter.value
+}
+
+// Release releases the iterator
+func (iter *rocksdbIterator) Release() {
+	iter.it.Close()
+	iter.ro.Destroy()
+}
+
 // Put saves the key/value in the given column family
-func (blockchainDB *BlockchainDB) Put(cfName string, key []byte, value []byte) error {
+func (blockchainDB *RocksDB) Put(cfName string, key []byte, value []byte) error {
 	blockchainDB.checkIfColumnExists(cfName)
 
 	opt := gorocksdb.NewDefaultWriteOptions()
@@ -237,7 +236,7 @@ func (blockchainDB *BlockchainDB) Put(cfName string, key []byte, value []byte) e
 }
 
 // Delete deletes the given key in the specified column family
-func (blockchainDB *BlockchainDB) Delete(cfName string, key []byte) error {
+func (blockchainDB *RocksDB) Delete(cfName string, key []byte) error {
 	blockchainDB.checkIfColumnExists(cfName)
 
 	opt := gorocksdb.NewDefaultWriteOptions()
@@ -250,7 +249,7 @@ func (blockchainDB *BlockchainDB) Delete(cfName string, key []byte) error {
 }
 
 // AtomicWrite writes batch
-func (blockchainDB *BlockchainDB) AtomicWrite(writeBatchs []*WriteBatch) error {
+func (blockchainDB *RocksDB) AtomicWrite(writeBatchs []*WriteBatch) error {
 	wb := gorocksdb.NewWriteBatch()
 	defer wb.Destroy()
 	for _, writeBatch := range writeBatchs {
@@ -268,20 +267,19 @@ func (blockchainDB *BlockchainDB) AtomicWrite(writeBatchs []*WriteBatch) error {
 	return blockchainDB.DB.Write(wo, wb)
 }
 
-func (blockchainDB *BlockchainDB) checkIfColumnExists(cfName string) {
-	if _, ok := blockchainDB.cfHandlers[cfName]; !ok {
-		log.Errorf("column family does not exist %s", cfName)
-		panic("column family does not exist")
+// ColumnFamilies returns the names of the column families
+func (blockchainDB *RocksDB) ColumnFamilies() []string {
+	cfs := make([]string, 0, len(blockchainDB.cfHandlers))
+	for cfName := range blockchainDB.cfHandlers {
+		cfs = append(cfs, cfName)
 	}
+	sort.Strings(cfs)
+	return cfs
 }
 
-// NewWriteBatch returns a writebatch instance
-func NewWriteBatch(cfName string, operation uint, key, value []byte, typ string) *WriteBatch {
-	return &WriteBatch{
-		CfName:    cfName,
-		Operation: operation,
-		Key:       key,
-		Value:     value,
-		Typ:       typ,
+func (blockchainDB *RocksDB) checkIfColumnExists(cfName string) {
+	if _, ok := blockchainDB.cfHandlers[cfName]; !ok {
+		log.Errorf("column family does not exist %s", cfName)
+		panic("column family does not exist")
 	}
 }
@@ -14,9 +14,14 @@
 //
 // You should have received a copy of the ISC License
 // along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+//go:build rocksdb
+// +build rocksdb
+
 package db
 
 import (
+	"io/ioutil"
 	"os"
 	"strconv"
 	"testing"
@@ -24,9 +29,32 @@ import (
 	"github.com/zipper-project/zipper/common/utils"
 )
 
-func TestReadAndWrite(t *testing.T) {
-	db := NewDB(DefaultConfig())
-	defer os.RemoveAll(config.DbPath)
+// newTestRocksDB opens a rocksdb in a temporary directory, the returned func closes and removes it
+func newTestRocksDB(t *testing.T) (*RocksDB, func()) {
+	dir, err := ioutil.TempDir("", "zipper-rocksdb-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := DefaultConfig()
+	c.DbPath = dir
+	db, err := NewRocksDB(c)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRocksDBDefaultEngine(t *testing.T) {
+	utils.AssertEquals(t, DefaultConfig().Engine, EngineRocksDB)
+}
+
+func TestRocksDBReadAndWrite(t *testing.T) {
+	db, closeDB := newTestRocksDB(t)
+	defer closeDB()
 	// Put
 	err := db.Put("default", []byte("foo"), []byte("bar"))
 	if err != nil {
@@ -41,9 +69,9 @@ func TestReadAndWrite(t *testing.T) {
 	utils.AssertEquals(t, value, []byte("bar"))
 }
 
-func TestDelete(t *testing.T) {
-	db := NewDB(DefaultConfig())
-	defer os.RemoveAll(config.DbPath)
+func TestRocksDBDelete(t *testing.T) {
+	db, closeDB := newTestRocksDB(t)
+	defer closeDB()
 
 	err := db.Put("default", []byte("foo"), []byte("bar"))
 	if err != nil {
@@ -59,9 +87,9 @@ func TestDelete(t *testing.T) {
 	}
 }
 
-func TestGetByRangeOrPrefix(t *testing.T) {
-	db := NewDB(DefaultConfig())
-	defer os.RemoveAll(config.DbPath)
+func TestRocksDBGetByRangeOrPrefix(t *testing.T) {
+	db, closeDB := newTestRocksDB(t)
+	defer closeDB()
 	for i := 0; i < 10; i++ {
 		key := []byte("key_" + strconv.Itoa(i))
 		value := []byte("value_" + strconv.Itoa(i))
@@ -83,6 +111,7 @@ func TestGetByRangeOrPrefix(t *testing.T) {
 	for _, v := range values {
 		t.Log("key: ", string(v.Key), "value: ", string(v.Value))
 	}
+	utils.AssertEquals(t, len(values), 13)
 
 	t.Log("-------------------------------")
 
@@ -90,11 +119,38 @@ func TestGetByRangeOrPrefix(t *testing.T) {
 	for _, v := range values1 {
 		t.Log("key: ", string(v.Key), "value: ", string(v.Value))
 	}
+	utils.AssertEquals(t, len(values1), 11)
 }
 
-func TestWriteBatch(t *testing.T) {
-	db := NewDB(DefaultConfig())
-	defer os.RemoveAll(config.DbPath)
+func TestRocksDBIterator(t *testing.T) {
+	db, closeDB := newTestRocksDB(t)
+	defer closeDB()
+	for i := 0; i < 5; i++ {
+		db.Put("index", []byte("iter_"+strconv.Itoa(i)), []byte(strconv.Itoa(i)))
+	}
+	db.Put("index", []byte("iter"), []byte("-"))
+	db.Put("index", []byte("itex_0"), []byte("-"))
+
+	var keys []string
+	iter := db.NewIterator("index", []byte("iter_"), []byte("iter_2"))
+	for iter.Next() {
+		keys = append(keys, string(iter.Key()))
+	}
+	iter.Release()
+	utils.AssertEquals(t, keys, []string{"iter_2", "iter_3", "iter_4"})
+
+	keys = nil
+	iter = db.NewIterator("index", []byte("iter_"), nil)
+	for iter.Next() {
+		keys = append(keys, string(iter.Key()))
+	}
+	iter.Release()
+	utils.AssertEquals(t, len(keys), 5)
+}
+
+func TestRocksDBWriteBatch(t *testing.T) {
+	db, closeDB := newTestRocksDB(t)
+	defer closeDB()
 	var writeBatchs []*WriteBatch
 	for i := 0; i < 100; i++ {
 		writeBatchs = append(writeBatchs, NewWriteBatch("balance", OperationPut, []byte("key"+strconv.Itoa(i)), []byte("value"+strconv.Itoa(i)), "balance"))
@@ -103,7 +159,10 @@ func TestWriteBatch(t *testing.T) {
 	var cnt int
 	for i := 0; i < 100; i++ {
 		t.Log("times: ", cnt)
-		db.AtomicWrite(writeBatchs)
+		if err := db.AtomicWrite(writeBatchs); err != nil {
+			t.Fatal(err)
+		}
 		cnt++
 	}
+	utils.AssertEquals(t, len(db.GetByPrefix("balance", []byte("key"))), 100)
 }
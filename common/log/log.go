@@ -114,6 +114,11 @@ func GetLevel() logrus.Level {
 	return logrus.GetLevel()
 }
 
+// IsDebug reports whether the standard logger logs at level Debug
+func IsDebug() bool {
+	return logrus.GetLevel() >= logrus.DebugLevel
+}
+
 // AddHook adds a hook to the standard logger hooks.
 func AddHook(hook logrus.Hook) {
 	logrus.AddHook(hook)
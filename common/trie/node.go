@@ -0,0 +1,162 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package trie
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+const (
+	// HashSize represents the hash length
+	HashSize = 32
+	// KeyBits represents the depth of the trie
+	KeyBits = HashSize * 8
+
+	leafPrefix   byte = 0x00
+	branchPrefix byte = 0x01
+)
+
+var (
+	// ErrInvalidNode is returned when a stored node can't be decoded
+	ErrInvalidNode = errors.New("invalid trie node")
+	// ErrMissingNode is returned when a node referenced by the trie isn't in the store
+	ErrMissingNode = errors.New("missing trie node")
+)
+
+// Hash represents the 32 byte hash of trie keys, values and nodes.
+// The zero hash is the root of an empty trie.
+type Hash [HashSize]byte
+
+// String returns the hex representation of the hash
+func (h Hash) String() string { return hex.EncodeToString(h[:]) }
+
+// Bytes returns the bytes representation of the hash
+func (h Hash) Bytes() []byte { return h[:] }
+
+// IsEmpty reports whether h is the zero hash
+func (h Hash) IsEmpty() bool { return h == Hash{} }
+
+// MarshalText returns the hex representation of h.
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// UnmarshalText parses a hash in hex syntax.
+func (h *Hash) UnmarshalText(input []byte) error {
+	buf, err := hex.DecodeString(string(input))
+	if err != nil {
+		return err
+	}
+	if len(buf) != HashSize {
+		return errors.New("invalid hash length")
+	}
+	copy(h[:], buf)
+	return nil
+}
+
+// BytesToHash converts b to a hash, b must be HashSize long
+func BytesToHash(b []byte) Hash {
+	var h Hash
+	copy(h[:], b)
+	return h
+}
+
+// KeyHash returns the trie key of the given key in namespace
+func KeyHash(namespace string, key []byte) Hash {
+	buf := make([]byte, 0, len(namespace)+1+len(key))
+	buf = append(buf, namespace...)
+	buf = append(buf, 0x00)
+	buf = append(buf, key...)
+	return sha256.Sum256(buf)
+}
+
+// ValueHash returns the hash committed by a leaf holding value
+func ValueHash(value []byte) Hash {
+	return sha256.Sum256(value)
+}
+
+// LeafHash returns the hash of a leaf node
+func LeafHash(key, valueHash Hash) Hash {
+	return sha256.Sum256(encodeLeaf(key, valueHash))
+}
+
+// BranchHash returns the hash of a branch node
+func BranchHash(left, right Hash) Hash {
+	return sha256.Sum256(encodeBranch(left, right))
+}
+
+// bit returns the bit of key at depth, 0 is the most significant bit
+func bit(key Hash, depth int) byte {
+	return (key[depth/8] >> uint(7-depth%8)) & 0x1
+}
+
+type node struct {
+	leaf      bool
+	key       Hash
+	valueHash Hash
+	left      Hash
+	right     Hash
+}
+
+func encodeLeaf(key, valueHash Hash) []byte {
+	buf := make([]byte, 0, 1+2*HashSize)
+	buf = append(buf, leafPrefix)
+	buf = append(buf, key[:]...)
+	buf = append(buf, valueHash[:]...)
+	return buf
+}
+
+func encodeBranch(left, right Hash) []byte {
+	buf := make([]byte, 0, 1+2*HashSize)
+	buf = append(buf, branchPrefix)
+	buf = append(buf, left[:]...)
+	buf = append(buf, right[:]...)
+	return buf
+}
+
+func (n *node) encode() []byte {
+	if n.leaf {
+		return encodeLeaf(n.key, n.valueHash)
+	}
+	return encodeBranch(n.left, n.right)
+}
+
+func (n *node) hash() Hash {
+	return sha256.Sum256(n.encode())
+}
+
+func decodeNode(data []byte) (*node, error) {
+	if len(data) != 1+2*HashSize {
+		return nil, ErrInvalidNode
+	}
+	switch data[0] {
+	case leafPrefix:
+		return &node{
+			leaf:      true,
+			key:       BytesToHash(data[1 : 1+HashSize]),
+			valueHash: BytesToHash(data[1+HashSize:]),
+		}, nil
+	case branchPrefix:
+		return &node{
+			left:  BytesToHash(data[1 : 1+HashSize]),
+			right: BytesToHash(data[1+HashSize:]),
+		}, nil
+	}
+	return nil, ErrInvalidNode
+}
@@ -0,0 +1,128 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package trie
+
+import (
+	"errors"
+
+	"github.com/zipper-project/zipper/common/utils"
+)
+
+var (
+	// ErrInvalidProof is returned when a proof is malformed
+	ErrInvalidProof = errors.New("invalid trie proof")
+	// ErrProofMismatch is returned when a proof doesn't lead to the expected root
+	ErrProofMismatch = errors.New("trie proof doesn't match root")
+	// ErrValueMismatch is returned when a proof doesn't commit to the claimed value
+	ErrValueMismatch = errors.New("trie proof doesn't match value")
+)
+
+// Proof is the path from the root to the node a key resolves to.
+// Siblings are ordered from the root downwards. The path ends either at a leaf,
+// which holds the key itself or proves its absence, or at an empty subtree.
+type Proof struct {
+	Siblings      []Hash `json:"siblings"`
+	HasLeaf       bool   `json:"hasLeaf"`
+	LeafKey       Hash   `json:"leafKey"`
+	LeafValueHash Hash   `json:"leafValueHash"`
+}
+
+// Prove returns the proof of key against the current root
+func (t *Trie) Prove(key Hash) (*Proof, error) {
+	proof := &Proof{}
+	h := t.root
+	for depth := 0; !h.IsEmpty(); depth++ {
+		n, err := t.load(h)
+		if err != nil {
+			return nil, err
+		}
+		if n.leaf {
+			proof.HasLeaf = true
+			proof.LeafKey = n.key
+			proof.LeafValueHash = n.valueHash
+			break
+		}
+		if bit(key, depth) == 0 {
+			proof.Siblings = append(proof.Siblings, n.right)
+			h = n.left
+		} else {
+			proof.Siblings = append(proof.Siblings, n.left)
+			h = n.right
+		}
+	}
+	return proof, nil
+}
+
+// Verify checks the proof of key against root. It returns the value hash of key
+// and true if the proof shows key exists, or false if it shows key is absent.
+func (p *Proof) Verify(root, key Hash) (Hash, bool, error) {
+	if len(p.Siblings) >= KeyBits {
+		return Hash{}, false, ErrInvalidProof
+	}
+
+	var h Hash
+	if p.HasLeaf {
+		// a leaf can only sit in the subtree its key leads to
+		for depth := range p.Siblings {
+			if bit(p.LeafKey, depth) != bit(key, depth) {
+				return Hash{}, false, ErrInvalidProof
+			}
+		}
+		h = LeafHash(p.LeafKey, p.LeafValueHash)
+	}
+
+	for depth := len(p.Siblings) - 1; depth >= 0; depth-- {
+		if bit(key, depth) == 0 {
+			h = BranchHash(h, p.Siblings[depth])
+		} else {
+			h = BranchHash(p.Siblings[depth], h)
+		}
+	}
+
+	if h != root {
+		return Hash{}, false, ErrProofMismatch
+	}
+	if p.HasLeaf && p.LeafKey == key {
+		return p.LeafValueHash, true, nil
+	}
+	return Hash{}, false, nil
+}
+
+// KeyProof proves the value of a key in namespace against a trie root,
+// or that the key is absent when Exists is false.
+type KeyProof struct {
+	Namespace string      `json:"namespace"`
+	Key       utils.Bytes `json:"key"`
+	Exists    bool        `json:"exists"`
+	Value     utils.Bytes `json:"value"`
+	Proof     *Proof      `json:"proof"`
+}
+
+// Verify checks the claimed value of the key against root
+func (kp *KeyProof) Verify(root Hash) error {
+	if kp.Proof == nil {
+		return ErrInvalidProof
+	}
+	valueHash, ok, err := kp.Proof.Verify(root, KeyHash(kp.Namespace, kp.Key))
+	if err != nil {
+		return err
+	}
+	if ok != kp.Exists || (ok && valueHash != ValueHash(kp.Value)) {
+		return ErrValueMismatch
+	}
+	return nil
+}
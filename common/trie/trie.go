@@ -0,0 +1,233 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+// Package trie implements a compact sparse merkle trie over 256 bit keys.
+//
+// A leaf commits to a key and the hash of its value, a branch commits to its
+// two children and an empty subtree is the zero hash. A subtree holding a
+// single leaf is represented by the leaf itself, so the shape of the trie and
+// therefore its root only depend on the set of key/value pairs it holds.
+// Nodes are content addressed and never modified, every root ever committed
+// stays readable as long as its nodes are kept in the store.
+package trie
+
+// NodeStore is the backend committed nodes are loaded from
+type NodeStore interface {
+	GetNode(hash Hash) ([]byte, error)
+}
+
+// Trie is a sparse merkle trie rooted at a given hash
+type Trie struct {
+	root  Hash
+	store NodeStore
+	dirty map[Hash][]byte
+}
+
+// New returns the trie rooted at root, the zero hash is the empty trie
+func New(root Hash, store NodeStore) *Trie {
+	return &Trie{
+		root:  root,
+		store: store,
+		dirty: make(map[Hash][]byte),
+	}
+}
+
+// Root returns the current root hash
+func (t *Trie) Root() Hash {
+	return t.root
+}
+
+// Get returns the value hash stored for key
+func (t *Trie) Get(key Hash) (Hash, bool, error) {
+	h := t.root
+	for depth := 0; !h.IsEmpty(); depth++ {
+		n, err := t.load(h)
+		if err != nil {
+			return Hash{}, false, err
+		}
+		if n.leaf {
+			if n.key == key {
+				return n.valueHash, true, nil
+			}
+			break
+		}
+		if bit(key, depth) == 0 {
+			h = n.left
+		} else {
+			h = n.right
+		}
+	}
+	return Hash{}, false, nil
+}
+
+// Update sets the value hash of key
+func (t *Trie) Update(key, valueHash Hash) error {
+	root, err := t.insert(t.root, 0, key, valueHash)
+	if err != nil {
+		return err
+	}
+	t.root = root
+	return nil
+}
+
+// Delete removes key from the trie
+func (t *Trie) Delete(key Hash) error {
+	root, err := t.delete(t.root, 0, key)
+	if err != nil {
+		return err
+	}
+	t.root = root
+	return nil
+}
+
+// Commit returns the nodes created since the last commit that are reachable from the current root.
+// The caller is responsible for writing them to the store.
+func (t *Trie) Commit() map[Hash][]byte {
+	nodes := make(map[Hash][]byte)
+	t.collect(t.root, nodes)
+	t.dirty = make(map[Hash][]byte)
+	return nodes
+}
+
+func (t *Trie) collect(h Hash, nodes map[Hash][]byte) {
+	data, ok := t.dirty[h]
+	if !ok {
+		return
+	}
+	nodes[h] = data
+	if n, err := decodeNode(data); err == nil && !n.leaf {
+		t.collect(n.left, nodes)
+		t.collect(n.right, nodes)
+	}
+}
+
+func (t *Trie) load(h Hash) (*node, error) {
+	data, ok := t.dirty[h]
+	if !ok {
+		var err error
+		if data, err = t.store.GetNode(h); err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			return nil, ErrMissingNode
+		}
+	}
+	return decodeNode(data)
+}
+
+func (t *Trie) put(n *node) Hash {
+	data := n.encode()
+	h := n.hash()
+	t.dirty[h] = data
+	return h
+}
+
+func (t *Trie) insert(h Hash, depth int, key, valueHash Hash) (Hash, error) {
+	leaf := &node{leaf: true, key: key, valueHash: valueHash}
+	if h.IsEmpty() {
+		return t.put(leaf), nil
+	}
+	n, err := t.load(h)
+	if err != nil {
+		return Hash{}, err
+	}
+	if n.leaf {
+		if n.key == key {
+			return t.put(leaf), nil
+		}
+		return t.join(depth, n, leaf), nil
+	}
+
+	left, right := n.left, n.right
+	if bit(key, depth) == 0 {
+		left, err = t.insert(left, depth+1, key, valueHash)
+	} else {
+		right, err = t.insert(right, depth+1, key, valueHash)
+	}
+	if err != nil {
+		return Hash{}, err
+	}
+	return t.put(&node{left: left, right: right}), nil
+}
+
+// join builds the subtree at depth holding the two leaves a and b
+func (t *Trie) join(depth int, a, b *node) Hash {
+	ba, bb := bit(a.key, depth), bit(b.key, depth)
+	if ba == bb {
+		child := t.join(depth+1, a, b)
+		if ba == 0 {
+			return t.put(&node{left: child})
+		}
+		return t.put(&node{right: child})
+	}
+	ha, hb := t.put(a), t.put(b)
+	if ba == 0 {
+		return t.put(&node{left: ha, right: hb})
+	}
+	return t.put(&node{left: hb, right: ha})
+}
+
+func (t *Trie) delete(h Hash, depth int, key Hash) (Hash, error) {
+	if h.IsEmpty() {
+		return h, nil
+	}
+	n, err := t.load(h)
+	if err != nil {
+		return Hash{}, err
+	}
+	if n.leaf {
+		if n.key == key {
+			return Hash{}, nil
+		}
+		return h, nil
+	}
+
+	left, right := n.left, n.right
+	if bit(key, depth) == 0 {
+		left, err = t.delete(left, depth+1, key)
+	} else {
+		right, err = t.delete(right, depth+1, key)
+	}
+	if err != nil {
+		return Hash{}, err
+	}
+	if left == n.left && right == n.right {
+		return h, nil
+	}
+	return t.collapse(left, right)
+}
+
+// collapse returns the branch of left and right, a branch left with a single leaf is replaced by the leaf
+func (t *Trie) collapse(left, right Hash) (Hash, error) {
+	if left.IsEmpty() && right.IsEmpty() {
+		return Hash{}, nil
+	}
+	if left.IsEmpty() || right.IsEmpty() {
+		child := left
+		if child.IsEmpty() {
+			child = right
+		}
+		n, err := t.load(child)
+		if err != nil {
+			return Hash{}, err
+		}
+		if n.leaf {
+			return child, nil
+		}
+	}
+	return t.put(&node{left: left, right: right}), nil
+}
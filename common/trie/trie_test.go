@@ -0,0 +1,141 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package trie
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zipper-project/zipper/common/utils"
+)
+
+type memStore map[Hash][]byte
+
+func (s memStore) GetNode(h Hash) ([]byte, error) {
+	return s[h], nil
+}
+
+func testKeys(n int) []Hash {
+	keys := make([]Hash, n)
+	for i := range keys {
+		keys[i] = KeyHash("balance", []byte(fmt.Sprintf("key%d", i)))
+	}
+	return keys
+}
+
+func TestRootIndependentOfOrder(t *testing.T) {
+	keys := testKeys(50)
+
+	t1 := New(Hash{}, memStore{})
+	for i, k := range keys {
+		if err := t1.Update(k, ValueHash([]byte{byte(i)})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t2 := New(Hash{}, memStore{})
+	for i := len(keys) - 1; i >= 0; i-- {
+		if err := t2.Update(keys[i], ValueHash([]byte{byte(i)})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	utils.AssertEquals(t, t1.Root(), t2.Root())
+}
+
+func TestDelete(t *testing.T) {
+	keys := testKeys(20)
+	tr := New(Hash{}, memStore{})
+	for _, k := range keys[:10] {
+		tr.Update(k, ValueHash(k[:]))
+	}
+	root := tr.Root()
+
+	for _, k := range keys[10:] {
+		tr.Update(k, ValueHash(k[:]))
+	}
+	for _, k := range keys[10:] {
+		if err := tr.Delete(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	utils.AssertEquals(t, tr.Root(), root)
+
+	for _, k := range keys[:10] {
+		tr.Delete(k)
+	}
+	utils.AssertEquals(t, tr.Root(), Hash{})
+}
+
+func TestCommitAndReload(t *testing.T) {
+	keys := testKeys(30)
+	store := memStore{}
+	tr := New(Hash{}, store)
+	for _, k := range keys {
+		tr.Update(k, ValueHash(k[:]))
+	}
+	for h, data := range tr.Commit() {
+		store[h] = data
+	}
+
+	reloaded := New(tr.Root(), store)
+	for _, k := range keys {
+		vh, ok, err := reloaded.Get(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.AssertEquals(t, ok, true)
+		utils.AssertEquals(t, vh, ValueHash(k[:]))
+	}
+	_, ok, _ := reloaded.Get(KeyHash("balance", []byte("missing")))
+	utils.AssertEquals(t, ok, false)
+}
+
+func TestProof(t *testing.T) {
+	keys := testKeys(30)
+	tr := New(Hash{}, memStore{})
+	for _, k := range keys {
+		tr.Update(k, ValueHash(k[:]))
+	}
+	root := tr.Root()
+
+	for _, k := range keys {
+		proof, err := tr.Prove(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vh, ok, err := proof.Verify(root, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.AssertEquals(t, ok, true)
+		utils.AssertEquals(t, vh, ValueHash(k[:]))
+	}
+
+	missing := KeyHash("balance", []byte("missing"))
+	proof, _ := tr.Prove(missing)
+	_, ok, err := proof.Verify(root, missing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, ok, false)
+
+	proof, _ = tr.Prove(keys[0])
+	proof.LeafValueHash = ValueHash([]byte("forged"))
+	if _, _, err := proof.Verify(root, keys[0]); err != ErrProofMismatch {
+		t.Errorf("forged proof verified, err %v", err)
+	}
+}
@@ -0,0 +1,522 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The canonical encoding gives every value exactly one byte representation,
+// so it is safe to hash and sign. It is written by Serialize behind a header
+// of canonicalMagic and the encoding version:
+//
+//	unsigned integers  varint
+//	signed integers    zigzag varint
+//	floats             IEEE 754 bits, big endian
+//	bool               one byte, 0 or 1
+//	string, []byte     varint length, bytes
+//	slice, array       varint count, elements
+//	map                varint count, key/value pairs sorted by encoded key
+//	pointer            one byte presence flag, element
+//	*big.Int           sign byte, varint length, big endian magnitude
+//	struct             varint count, fields as (tag, varint length, value) in tag order
+//
+// Struct fields are identified by the tag in `enc:"N"`, or the field number of
+// protobuf messages, and skipped with `enc:"-"`. Structs with an exported field
+// without tag are rejected, so reordering fields can't change the encoding.
+// A field tagged `enc:"N,omitempty"` isn't written when it holds its zero value,
+// so a field added that way leaves the encoding of the existing values unchanged.
+// Unexported fields, interfaces, channels and functions aren't encoded.
+// Decoding ignores unknown tags.
+
+// EncodingVersion is the version of the canonical encoding written by Serialize
+const EncodingVersion byte = 1
+
+// canonicalMagic prefixes canonical data. The legacy encoding always writes
+// minimal varints, so it never starts with a 0xFD varint of value 0.
+var canonicalMagic = []byte{0xFD, 0x00, 0x00}
+
+// IsCanonical reports whether data was written with the canonical encoding
+func IsCanonical(data []byte) bool {
+	return len(data) > len(canonicalMagic) && bytes.HasPrefix(data, canonicalMagic)
+}
+
+// CanonicalEncode writes the canonical encoding of val without header
+func CanonicalEncode(w io.Writer, val interface{}) {
+	s := reflect.ValueOf(val)
+	if s.Kind() == reflect.Ptr {
+		s = s.Elem()
+	}
+	if s.IsValid() {
+		canonicalEncode(w, s)
+	}
+}
+
+// CanonicalDecode decodes the canonical encoding without header to val, val must be pointer
+func CanonicalDecode(r io.Reader, val interface{}) error {
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("canonical decode error, val must be pointer")
+	}
+	return canonicalDecode(&byteReader{r: r}, rv.Elem())
+}
+
+var bigIntType = reflect.TypeOf(big.Int{})
+
+func canonicalEncode(w io.Writer, s reflect.Value) {
+	switch s.Kind() {
+	case reflect.Struct:
+		if s.Type() == bigIntType {
+			if !s.CanAddr() {
+				cpy := reflect.New(bigIntType).Elem()
+				cpy.Set(s)
+				s = cpy
+			}
+			encodeBigInt(w, s.Addr().Interface().(*big.Int))
+			return
+		}
+		structEncode(w, s)
+	case reflect.Ptr:
+		if s.IsNil() {
+			w.Write([]byte{0})
+			return
+		}
+		w.Write([]byte{1})
+		if bigVal, ok := s.Interface().(*big.Int); ok {
+			encodeBigInt(w, bigVal)
+			return
+		}
+		canonicalEncode(w, s.Elem())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		WriteVarInt(w, s.Uint())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		WriteVarInt(w, zigzag(s.Int()))
+	case reflect.Float32, reflect.Float64:
+		buf := make([]byte, 8)
+		binary.BigEndian.PutUint64(buf, math.Float64bits(s.Float()))
+		w.Write(buf)
+	case reflect.Bool:
+		if s.Bool() {
+			w.Write([]byte{1})
+		} else {
+			w.Write([]byte{0})
+		}
+	case reflect.String:
+		WriteVarInt(w, uint64(s.Len()))
+		w.Write([]byte(s.String()))
+	case reflect.Slice, reflect.Array:
+		if s.Type().Elem().Kind() == reflect.Uint8 {
+			buf := make([]byte, s.Len())
+			reflect.Copy(reflect.ValueOf(buf), s)
+			WriteVarInt(w, uint64(len(buf)))
+			w.Write(buf)
+			return
+		}
+		WriteVarInt(w, uint64(s.Len()))
+		for i := 0; i < s.Len(); i++ {
+			canonicalEncode(w, s.Index(i))
+		}
+	case reflect.Map:
+		canonicalMapEncode(w, s)
+	}
+}
+
+func encodeBigInt(w io.Writer, v *big.Int) {
+	if v.Sign() < 0 {
+		w.Write([]byte{1})
+	} else {
+		w.Write([]byte{0})
+	}
+	buf := v.Bytes()
+	WriteVarInt(w, uint64(len(buf)))
+	w.Write(buf)
+}
+
+func canonicalMapEncode(w io.Writer, s reflect.Value) {
+	type entry struct {
+		key   []byte
+		value []byte
+	}
+	entries := make([]entry, 0, s.Len())
+	for _, key := range s.MapKeys() {
+		kw, vw := new(bytes.Buffer), new(bytes.Buffer)
+		canonicalEncode(kw, key)
+		canonicalEncode(vw, s.MapIndex(key))
+		entries = append(entries, entry{kw.Bytes(), vw.Bytes()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+
+	WriteVarInt(w, uint64(len(entries)))
+	for _, e := range entries {
+		w.Write(e.key)
+		w.Write(e.value)
+	}
+}
+
+type structField struct {
+	tag       uint64
+	index     int
+	omitEmpty bool
+}
+
+// protobufNumber returns the field number of a protobuf tag "wiretype,number,..."
+func protobufNumber(tag reflect.StructTag) (string, bool) {
+	parts := strings.Split(tag.Get("protobuf"), ",")
+	if len(parts) < 2 {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// structFields returns the encoded fields of t ordered by tag
+func structFields(t reflect.Type) ([]structField, error) {
+	fields := make([]structField, 0, t.NumField())
+	seen := make(map[uint64]bool)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		switch f.Type.Kind() {
+		case reflect.Interface, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+			continue
+		}
+
+		s, ok := f.Tag.Lookup("enc")
+		if !ok {
+			s, ok = protobufNumber(f.Tag)
+		}
+		if !ok {
+			return nil, fmt.Errorf("missing enc tag of %s.%s", t.Name(), f.Name)
+		}
+		if s == "-" {
+			continue
+		}
+		omitEmpty := false
+		if strings.HasSuffix(s, ",omitempty") {
+			s = strings.TrimSuffix(s, ",omitempty")
+			omitEmpty = true
+		}
+		tag, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || tag == 0 {
+			return nil, fmt.Errorf("invalid enc tag %q of %s.%s", s, t.Name(), f.Name)
+		}
+		if seen[tag] {
+			return nil, fmt.Errorf("duplicate enc tag %d of %s.%s", tag, t.Name(), f.Name)
+		}
+		seen[tag] = true
+		fields = append(fields, structField{tag: tag, index: i, omitEmpty: omitEmpty})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].tag < fields[j].tag })
+	return fields, nil
+}
+
+func structEncode(w io.Writer, s reflect.Value) {
+	fields, err := structFields(s.Type())
+	if err != nil {
+		panic(err)
+	}
+	written := fields[:0:0]
+	for _, f := range fields {
+		if !f.omitEmpty || !s.Field(f.index).IsZero() {
+			written = append(written, f)
+		}
+	}
+	WriteVarInt(w, uint64(len(written)))
+	for _, f := range written {
+		fw := new(bytes.Buffer)
+		canonicalEncode(fw, s.Field(f.index))
+		WriteVarInt(w, f.tag)
+		WriteVarInt(w, uint64(fw.Len()))
+		w.Write(fw.Bytes())
+	}
+}
+
+// byteReader checks lengths against the bytes left so corrupt data can't force large allocations
+type byteReader struct {
+	r io.Reader
+}
+
+func (br *byteReader) readN(n uint64) ([]byte, error) {
+	if n > math.MaxInt32 {
+		return nil, fmt.Errorf("canonical decode error, length %d too large", n)
+	}
+	if r, ok := br.r.(interface{ Len() int }); ok && uint64(r.Len()) < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	buf := make([]byte, n)
+	_, err := io.ReadFull(br.r, buf)
+	return buf, err
+}
+
+func (br *byteReader) readVarInt() (uint64, error) {
+	return ReadVarInt(br.r)
+}
+
+func canonicalDecode(r *byteReader, s reflect.Value) error {
+	switch s.Kind() {
+	case reflect.Struct:
+		if s.Type() == bigIntType {
+			bigVal, err := decodeBigInt(r)
+			if err != nil {
+				return err
+			}
+			s.Set(reflect.ValueOf(*bigVal))
+			return nil
+		}
+		return structDecode(r, s)
+	case reflect.Ptr:
+		flag, err := r.readN(1)
+		if err != nil {
+			return err
+		}
+		switch flag[0] {
+		case 0:
+			s.Set(reflect.Zero(s.Type()))
+			return nil
+		case 1:
+		default:
+			return fmt.Errorf("canonical decode error, invalid pointer flag %d", flag[0])
+		}
+		if s.Type() == reflect.PtrTo(bigIntType) {
+			bigVal, err := decodeBigInt(r)
+			if err != nil {
+				return err
+			}
+			s.Set(reflect.ValueOf(bigVal))
+			return nil
+		}
+		val := reflect.New(s.Type().Elem())
+		if err := canonicalDecode(r, val.Elem()); err != nil {
+			return err
+		}
+		s.Set(val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		l, err := r.readVarInt()
+		if err != nil {
+			return err
+		}
+		if s.OverflowUint(l) {
+			return fmt.Errorf("canonical decode error, %d overflows %s", l, s.Type())
+		}
+		s.SetUint(l)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		l, err := r.readVarInt()
+		if err != nil {
+			return err
+		}
+		v := unzigzag(l)
+		if s.OverflowInt(v) {
+			return fmt.Errorf("canonical decode error, %d overflows %s", v, s.Type())
+		}
+		s.SetInt(v)
+	case reflect.Float32, reflect.Float64:
+		buf, err := r.readN(8)
+		if err != nil {
+			return err
+		}
+		s.SetFloat(math.Float64frombits(binary.BigEndian.Uint64(buf)))
+	case reflect.Bool:
+		buf, err := r.readN(1)
+		if err != nil {
+			return err
+		}
+		if buf[0] > 1 {
+			return fmt.Errorf("canonical decode error, invalid bool %d", buf[0])
+		}
+		s.SetBool(buf[0] == 1)
+	case reflect.String:
+		l, err := r.readVarInt()
+		if err != nil {
+			return err
+		}
+		buf, err := r.readN(l)
+		if err != nil {
+			return err
+		}
+		s.SetString(string(buf))
+	case reflect.Slice:
+		l, err := r.readVarInt()
+		if err != nil {
+			return err
+		}
+		if s.Type().Elem().Kind() == reflect.Uint8 {
+			buf, err := r.readN(l)
+			if err != nil {
+				return err
+			}
+			if l == 0 {
+				buf = nil
+			}
+			s.SetBytes(buf)
+			return nil
+		}
+		if l == 0 {
+			s.Set(reflect.Zero(s.Type()))
+			return nil
+		}
+		if l > math.MaxInt32 {
+			return fmt.Errorf("canonical decode error, length %d too large", l)
+		}
+		newVal := reflect.MakeSlice(s.Type(), 0, 0)
+		for i := uint64(0); i < l; i++ {
+			elem := reflect.New(s.Type().Elem()).Elem()
+			if err := canonicalDecode(r, elem); err != nil {
+				return err
+			}
+			newVal = reflect.Append(newVal, elem)
+		}
+		s.Set(newVal)
+	case reflect.Array:
+		l, err := r.readVarInt()
+		if err != nil {
+			return err
+		}
+		if l != uint64(s.Len()) {
+			return fmt.Errorf("canonical decode error, array length %d, expected %d", l, s.Len())
+		}
+		if s.Type().Elem().Kind() == reflect.Uint8 {
+			buf, err := r.readN(l)
+			if err != nil {
+				return err
+			}
+			reflect.Copy(s, reflect.ValueOf(buf))
+			return nil
+		}
+		for i := 0; i < s.Len(); i++ {
+			if err := canonicalDecode(r, s.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		return canonicalMapDecode(r, s)
+	}
+	return nil
+}
+
+func decodeBigInt(r *byteReader) (*big.Int, error) {
+	sign, err := r.readN(1)
+	if err != nil {
+		return nil, err
+	}
+	l, err := r.readVarInt()
+	if err != nil {
+		return nil, err
+	}
+	buf, err := r.readN(l)
+	if err != nil {
+		return nil, err
+	}
+	v := new(big.Int).SetBytes(buf)
+	if sign[0] == 1 {
+		v.Neg(v)
+	}
+	return v, nil
+}
+
+func canonicalMapDecode(r *byteReader, s reflect.Value) error {
+	l, err := r.readVarInt()
+	if err != nil {
+		return err
+	}
+	t := s.Type()
+	newVal := reflect.MakeMap(t)
+	for i := uint64(0); i < l; i++ {
+		key := reflect.New(t.Key()).Elem()
+		value := reflect.New(t.Elem()).Elem()
+		if err := canonicalDecode(r, key); err != nil {
+			return err
+		}
+		if err := canonicalDecode(r, value); err != nil {
+			return err
+		}
+		newVal.SetMapIndex(key, value)
+	}
+	s.Set(newVal)
+	return nil
+}
+
+func structDecode(r *byteReader, s reflect.Value) error {
+	fields, err := structFields(s.Type())
+	if err != nil {
+		return err
+	}
+	byTag := make(map[uint64]structField, len(fields))
+	for _, f := range fields {
+		byTag[f.tag] = f
+	}
+
+	n, err := r.readVarInt()
+	if err != nil {
+		return err
+	}
+	var lastTag uint64
+	for i := uint64(0); i < n; i++ {
+		tag, err := r.readVarInt()
+		if err != nil {
+			return err
+		}
+		if tag <= lastTag {
+			return fmt.Errorf("canonical decode error, field tag %d out of order in %s", tag, s.Type())
+		}
+		lastTag = tag
+
+		l, err := r.readVarInt()
+		if err != nil {
+			return err
+		}
+		buf, err := r.readN(l)
+		if err != nil {
+			return err
+		}
+		f, ok := byTag[tag]
+		if !ok {
+			continue
+		}
+		fieldReader := bytes.NewReader(buf)
+		if err := canonicalDecode(&byteReader{r: fieldReader}, s.Field(f.index)); err != nil {
+			return err
+		}
+		if fieldReader.Len() != 0 {
+			return fmt.Errorf("canonical decode error, trailing bytes in field %d of %s", tag, s.Type())
+		}
+		if f.omitEmpty && s.Field(f.index).IsZero() {
+			return fmt.Errorf("canonical decode error, empty field %d of %s isn't omitted", tag, s.Type())
+		}
+	}
+	return nil
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
@@ -0,0 +1,206 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+
+package utils
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+type canonicalInner struct {
+	Name  string `enc:"1"`
+	Value []byte `enc:"2"`
+}
+
+type canonicalTest struct {
+	ID       int64             `enc:"1"`
+	Height   uint32            `enc:"2"`
+	Ok       bool              `enc:"3"`
+	Amount   *big.Int          `enc:"4"`
+	Amounts  map[uint32]int64  `enc:"5"`
+	Names    map[string]string `enc:"6"`
+	Inner    *canonicalInner   `enc:"7"`
+	Inners   []canonicalInner  `enc:"8"`
+	Hash     [4]byte           `enc:"9"`
+	Nums     [2]uint16         `enc:"10"`
+	internal int
+}
+
+func newCanonicalTest() *canonicalTest {
+	return &canonicalTest{
+		ID:      -42,
+		Height:  100,
+		Ok:      true,
+		Amount:  big.NewInt(-123456789),
+		Amounts: map[uint32]int64{0: 1, 1: -1, 2: 1 << 40, 300: 3},
+		Names:   map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"},
+		Inner:   &canonicalInner{Name: "inner", Value: []byte{1, 2, 3}},
+		Inners:  []canonicalInner{{Name: "x"}, {Name: "y", Value: []byte{4}}},
+		Hash:    [4]byte{1, 2, 3, 4},
+		Nums:    [2]uint16{7, 8},
+	}
+}
+
+func TestCanonicalRoundTrip(t *testing.T) {
+	v := newCanonicalTest()
+	data := Serialize(v)
+	if !IsCanonical(data) {
+		t.Fatal("serialized data isn't canonical")
+	}
+
+	d := &canonicalTest{}
+	if err := Deserialize(data, d); err != nil {
+		t.Fatal(err)
+	}
+	AssertEquals(t, d, v)
+}
+
+func TestCanonicalMapOrder(t *testing.T) {
+	data := Serialize(newCanonicalTest())
+	for i := 0; i < 20; i++ {
+		if !bytes.Equal(Serialize(newCanonicalTest()), data) {
+			t.Fatal("encoding of the same value differs")
+		}
+	}
+}
+
+func TestCanonicalFieldTags(t *testing.T) {
+	type v1 struct {
+		A uint32 `enc:"1"`
+		B string `enc:"2"`
+	}
+	type v2 struct {
+		C []byte `enc:"3"`
+		B string `enc:"2"`
+		A uint32 `enc:"1"`
+	}
+
+	d := &v2{}
+	if err := Deserialize(Serialize(&v1{A: 1, B: "b"}), d); err != nil {
+		t.Fatal(err)
+	}
+	AssertEquals(t, d, &v2{A: 1, B: "b"})
+
+	// unknown fields are skipped
+	old := &v1{}
+	if err := Deserialize(Serialize(&v2{A: 2, B: "c", C: []byte{1}}), old); err != nil {
+		t.Fatal(err)
+	}
+	AssertEquals(t, old, &v1{A: 2, B: "c"})
+}
+
+func TestCanonicalUntagged(t *testing.T) {
+	type untagged struct {
+		A uint32 `enc:"1"`
+		B string
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("struct with an untagged field encoded")
+		}
+	}()
+	Serialize(&untagged{A: 1})
+}
+
+func TestCanonicalProtobufTags(t *testing.T) {
+	type message struct {
+		A uint32 `protobuf:"varint,2,opt,name=a"`
+		B string `protobuf:"bytes,1,opt,name=b"`
+	}
+	type tagged struct {
+		B string `enc:"1"`
+		A uint32 `enc:"2"`
+	}
+	AssertEquals(t, Serialize(&message{A: 1, B: "b"}), Serialize(&tagged{A: 1, B: "b"}))
+}
+
+func TestCanonicalOmitEmpty(t *testing.T) {
+	type v1 struct {
+		A uint32 `enc:"1"`
+	}
+	type v2 struct {
+		A uint32   `enc:"1"`
+		B *big.Int `enc:"2,omitempty"`
+	}
+
+	// an empty field added with omitempty leaves the encoding unchanged
+	AssertEquals(t, Serialize(&v2{A: 1}), Serialize(&v1{A: 1}))
+	d := &v2{}
+	if err := Deserialize(Serialize(&v2{A: 1, B: big.NewInt(-7)}), d); err != nil {
+		t.Fatal(err)
+	}
+	AssertEquals(t, d.B.String(), "-7")
+
+	// an empty value written explicitly is rejected, so the encoding stays unique
+	type explicit struct {
+		A uint32   `enc:"1"`
+		B *big.Int `enc:"2"`
+	}
+	if err := Deserialize(Serialize(&explicit{A: 1}), d); err == nil {
+		t.Error("explicit empty field decoded")
+	}
+}
+
+func TestDeserializeLegacy(t *testing.T) {
+	type legacy struct {
+		ID     uint32
+		Name   string
+		Amount int64
+		Data   []byte
+	}
+	v := &legacy{ID: 1, Name: "legacy", Amount: 10000, Data: []byte{1, 2}}
+	buf := new(bytes.Buffer)
+	VarEncode(buf, v)
+	if IsCanonical(buf.Bytes()) {
+		t.Fatal("legacy data detected as canonical")
+	}
+
+	d := &legacy{}
+	if err := Deserialize(buf.Bytes(), d); err != nil {
+		t.Fatal(err)
+	}
+	AssertEquals(t, d, v)
+
+	// 0xFD is a legacy varint prefix too
+	var amount int64
+	buf.Reset()
+	VarEncode(buf, int64(0xFFFF))
+	if err := Deserialize(buf.Bytes(), &amount); err != nil {
+		t.Fatal(err)
+	}
+	AssertEquals(t, amount, int64(0xFFFF))
+}
+
+func TestDeserializeUnknownVersion(t *testing.T) {
+	data := Serialize(uint32(1))
+	data[len(canonicalMagic)] = EncodingVersion + 1
+	var v uint32
+	if err := Deserialize(data, &v); err == nil {
+		t.Error("unknown encoding version accepted")
+	}
+}
+
+func TestDeserializeCorrupt(t *testing.T) {
+	data := Serialize(newCanonicalTest())
+	for i := len(canonicalMagic) + 1; i < len(data); i++ {
+		d := &canonicalTest{}
+		// must fail or succeed without panicking
+		Deserialize(data[:i], d)
+	}
+}
@@ -295,15 +295,33 @@ func mapDecode(r io.Reader, s reflect.Value) error {
 	return err
 }
 
-// Serialize serializes an object to bytes
+// Serialize serializes an object to bytes with the canonical encoding
 func Serialize(obj interface{}) []byte {
 	buf := new(bytes.Buffer)
-	VarEncode(buf, obj)
+	buf.Write(canonicalMagic)
+	buf.WriteByte(EncodingVersion)
+	CanonicalEncode(buf, obj)
 	return buf.Bytes()
 }
 
-// Deserialize deserializes bytes to object
+// Deserialize deserializes bytes to object. Data written before the canonical
+// encoding was introduced is decoded with the legacy decoder.
 func Deserialize(data []byte, obj interface{}) error {
-	buf := bytes.NewBuffer(data)
-	return VarDecode(buf, obj)
+	if !IsCanonical(data) {
+		buf := bytes.NewBuffer(data)
+		return VarDecode(buf, obj)
+	}
+
+	version := data[len(canonicalMagic)]
+	if version != EncodingVersion {
+		return fmt.Errorf("unsupported encoding version %d", version)
+	}
+	r := bytes.NewReader(data[len(canonicalMagic)+1:])
+	if err := CanonicalDecode(r, obj); err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("canonical decode error, %d trailing bytes", r.Len())
+	}
+	return nil
 }
@@ -35,6 +35,7 @@ func ConsenterOptions() *consenter.Options {
 
 func NoopsOptions() *noops.Options {
 	option := noops.NewDefaultOptions()
+	option.ID = getString("blockchain.nodeId", option.ID)
 	option.BatchSize = getInt("consensus.noops.batchSize", option.BatchSize)
 	option.BatchTimeout = getDuration("consensus.noops.batchTimeout", option.BatchTimeout)
 	option.BlockSize = getInt("consensus.noops.blockSize", option.BlockSize)
@@ -42,10 +43,15 @@ func NoopsOptions() *noops.Options {
 	return option
 }
 
+// SCIPReplicaID returns the scip replica id of the node nodeID on chain
+func SCIPReplicaID(chain, nodeID string) string {
+	return chain + ":" + utils.BytesToHex(crypto.Ripemd160([]byte(nodeID+chain)))
+}
+
 func SCIPOptions() *scip.Options {
 	option := scip.NewDefaultOptions()
 	option.Chain = getString("blockchain.chainId", option.Chain)
-	option.ID = option.Chain + ":" + utils.BytesToHex(crypto.Ripemd160([]byte(getString("blockchain.nodeId", option.ID)+option.Chain)))
+	option.ID = SCIPReplicaID(option.Chain, getString("blockchain.nodeId", option.ID))
 	option.N = getInt("consensus.scip.N", option.N)
 	option.Q = getInt("consensus.scip.Q", option.Q)
 	option.K = getInt("consensus.scip.K", option.K)
@@ -26,6 +26,7 @@ import (
 // DBConfig returns configurations for database
 func DBConfig() *db.Config {
 	dbConfig := db.DefaultConfig()
+	dbConfig.Engine = getString("db.engine", dbConfig.Engine)
 	dbConfig.DbPath = filepath.Join(DataDir, ChainDataDirName)
 	dbConfig.Columnfamilies = getStringSlice("db.columnfamilies", dbConfig.Columnfamilies)
 	dbConfig.KeepLogFileNumber = getInt("db.keepLogFileNumber", dbConfig.KeepLogFileNumber)
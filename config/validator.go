@@ -28,6 +28,9 @@ func ValidatorConfig() *validator.Config {
 	config.BlacklistDur = getDuration("validator.blacklisttimeout", config.BlacklistDur)
 	config.TxPoolCapacity = getInt("validator.txpool.capacity", config.TxPoolCapacity)
 	config.TxPoolTimeOut = getDuration("validator.txpool.timeout", config.TxPoolTimeOut)
+	config.MaxClockSkew = getDuration("validator.txpool.maxclockskew", config.MaxClockSkew)
+	config.MaxNonceGap = getInt("validator.txpool.maxnoncegap", config.MaxNonceGap)
+	config.MaxParkedTxs = getInt("validator.txpool.maxparked", config.MaxParkedTxs)
 	config.TxPoolDelay = getInt("validator.txpool.txdelay", config.TxPoolDelay)
 	config.TxPoolDelay = getInt("validator.txpool.txdelay", config.TxPoolDelay)
 	config.PublicAddresses = getStringSlice("validator.issueaddr", []string{})
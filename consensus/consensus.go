@@ -20,6 +20,7 @@ package consensus
 import (
 	"time"
 
+	"github.com/zipper-project/zipper/common/crypto"
 	"github.com/zipper-project/zipper/proto"
 )
 
@@ -39,6 +40,10 @@ type OutputTxs struct {
 	SeqNos []uint32
 	Time   uint32
 	Height uint32
+	// Proposer is the replica ID of the node that proposed the transactions
+	Proposer string
+	// MetaHash is the hash of the consensus decision the block is built from
+	MetaHash crypto.Hash
 }
 
 // Consenter Interface for plugin consenser
@@ -70,4 +75,6 @@ type BlockchainInfo struct {
 type IStack interface {
 	VerifyTxs(request proto.Transactions) (proto.Transactions, proto.Transactions)
 	GetBlockchainInfo() *BlockchainInfo
+	// ViewChanged is called when consensus votes a new primary
+	ViewChanged(primaryID string, seqNo uint32, height uint32)
 }
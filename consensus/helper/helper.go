@@ -40,3 +40,7 @@ func (stack *Stack) GetBlockchainInfo() *consensus.BlockchainInfo {
 func (stack *Stack) VerifyTxs(txs proto.Transactions) (proto.Transactions, proto.Transactions) {
 	return txs, nil
 }
+
+// ViewChanged Implenment consensus.IStack
+func (stack *Stack) ViewChanged(primaryID string, seqNo uint32, height uint32) {
+}
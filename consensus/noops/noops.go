@@ -23,7 +23,9 @@ import (
 
 	"encoding/json"
 
+	"github.com/zipper-project/zipper/common/crypto"
 	"github.com/zipper-project/zipper/common/log"
+	"github.com/zipper-project/zipper/common/utils"
 	"github.com/zipper-project/zipper/consensus"
 	"github.com/zipper-project/zipper/proto"
 )
@@ -134,7 +136,14 @@ func (noops *Noops) processBlock(txs proto.Transactions, seqNos []uint32, reason
 	if len(seqNos) != 0 {
 		noops.height++
 		log.Infof("Noops write block %d (%d transactions)  %v : %s", noops.height, len(txs), seqNos, reason)
-		noops.outputTxsChan <- &consensus.OutputTxs{Txs: txs, SeqNos: seqNos, Time: uint32(time.Now().Unix()), Height: noops.height}
+		noops.outputTxsChan <- &consensus.OutputTxs{
+			Txs:      txs,
+			SeqNos:   seqNos,
+			Time:     uint32(time.Now().Unix()),
+			Height:   noops.height,
+			Proposer: noops.options.ID,
+			MetaHash: crypto.DoubleSha256(utils.Serialize(seqNos)),
+		}
 	}
 }
 
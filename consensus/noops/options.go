@@ -34,6 +34,7 @@ func NewDefaultOptions() *Options {
 
 // Options Define noops options
 type Options struct {
+	ID           string
 	BatchSize    int
 	BatchTimeout time.Duration
 	BlockSize    int
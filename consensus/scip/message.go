@@ -29,10 +29,10 @@ import (
 
 //Request Define struct
 type Request struct {
-	ID     int64
-	Time   uint32
-	Height uint32
-	Txs    proto.Transactions
+	ID     int64              `enc:"1"`
+	Time   uint32             `enc:"2"`
+	Height uint32             `enc:"3"`
+	Txs    proto.Transactions `enc:"4"`
 }
 
 //Name key name
@@ -52,71 +52,72 @@ func (msg *Request) Name() string {
 
 //PrePrepare Define struct
 type PrePrepare struct {
-	PrimaryID string
-	SeqNo     uint32
-	Height    uint32
-	OptHash   string
+	PrimaryID string `enc:"1"`
+	SeqNo     uint32 `enc:"2"`
+	Height    uint32 `enc:"3"`
+	OptHash   string `enc:"4"`
 	// Digest    string
-	MerkleRoot string
-	Quorum     int
-	Request    *Request
-	Chain      string
-	ReplicaID  string
+	MerkleRoot string   `enc:"5"`
+	Quorum     int      `enc:"6"`
+	Request    *Request `enc:"7"`
+	Chain      string   `enc:"8"`
+	ReplicaID  string   `enc:"9"`
 }
 
 //Prepare Define struct
 type Prepare struct {
-	PrimaryID string
-	SeqNo     uint32
-	Height    uint32
-	OptHash   string
-	Digest    string
-	Quorum    int
-	Chain     string
-	ReplicaID string
+	PrimaryID string `enc:"1"`
+	SeqNo     uint32 `enc:"2"`
+	Height    uint32 `enc:"3"`
+	OptHash   string `enc:"4"`
+	Digest    string `enc:"5"`
+	Quorum    int    `enc:"6"`
+	Chain     string `enc:"7"`
+	ReplicaID string `enc:"8"`
 }
 
 //Commit Define struct
 type Commit struct {
-	PrimaryID string
-	SeqNo     uint32
-	Height    uint32
-	OptHash   string
-	Digest    string
-	Quorum    int
-	Chain     string
-	ReplicaID string
+	PrimaryID string `enc:"1"`
+	SeqNo     uint32 `enc:"2"`
+	Height    uint32 `enc:"3"`
+	OptHash   string `enc:"4"`
+	Digest    string `enc:"5"`
+	Quorum    int    `enc:"6"`
+	Chain     string `enc:"7"`
+	ReplicaID string `enc:"8"`
 }
 
 //Committed Define struct
 type Committed struct {
-	SeqNo     uint32
-	Height    uint32
-	Digest    string
-	Txs       proto.Transactions
-	ErrTxs    proto.Transactions
-	Chain     string
-	ReplicaID string
+	SeqNo     uint32             `enc:"1"`
+	Height    uint32             `enc:"2"`
+	Digest    string             `enc:"3"`
+	Txs       proto.Transactions `enc:"4"`
+	ErrTxs    proto.Transactions `enc:"5"`
+	Chain     string             `enc:"6"`
+	ReplicaID string             `enc:"7"`
+	PrimaryID string             `enc:"8"`
 }
 
 //FetchCommitted Define struct
 type FetchCommitted struct {
-	SeqNo     uint32
-	Chain     string
-	ReplicaID string
+	SeqNo     uint32 `enc:"1"`
+	Chain     string `enc:"2"`
+	ReplicaID string `enc:"3"`
 }
 
 //ViewChange Define struct
 type ViewChange struct {
-	ID            string
-	Priority      int64
-	PrimaryID     string
-	SeqNo         uint32
-	Height        uint32
-	OptHash       string
-	LastPrimaryID string
-	ReplicaID     string
-	Chain         string
+	ID            string `enc:"1"`
+	Priority      int64  `enc:"2"`
+	PrimaryID     string `enc:"3"`
+	SeqNo         uint32 `enc:"4"`
+	Height        uint32 `enc:"5"`
+	OptHash       string `enc:"6"`
+	LastPrimaryID string `enc:"7"`
+	ReplicaID     string `enc:"8"`
+	Chain         string `enc:"9"`
 }
 
 //MessageType
@@ -143,8 +144,8 @@ type Message struct {
 	//	*Committed
 	//	*FetchCommitted
 	//	*ViewChange
-	Type    MessageType
-	Payload []byte
+	Type    MessageType `enc:"1"`
+	Payload []byte      `enc:"2"`
 }
 
 //GetRequestBatch
@@ -46,22 +46,25 @@ func NewDefaultOptions() *Options {
 
 //Options Define nbft options
 type Options struct {
-	Chain string
-	ID    string
-	N     int
-	Q     int
-	K     int
+	Chain string `enc:"1"`
+	ID    string `enc:"2"`
+	N     int    `enc:"3"`
+	Q     int    `enc:"4"`
+	K     int    `enc:"5"`
 
-	BatchSize    int
-	BatchTimeout time.Duration
-	BlockSize    int
-	BlockTimeout time.Duration
-	Request      time.Duration
-	BufferSize   int
+	BatchSize    int           `enc:"6"`
+	BatchTimeout time.Duration `enc:"7"`
+	BlockSize    int           `enc:"8"`
+	BlockTimeout time.Duration `enc:"9"`
+	Request      time.Duration `enc:"10"`
+	BufferSize   int           `enc:"11"`
 
-	ViewChange       time.Duration
-	ResendViewChange time.Duration
-	ViewChangePeriod time.Duration
+	ViewChange       time.Duration `enc:"12"`
+	ResendViewChange time.Duration `enc:"13"`
+	ViewChangePeriod time.Duration `enc:"14"`
+
+	// Validators are the ids of the replicas allowed to vote, every replica may vote if empty
+	Validators []string `enc:"15"`
 }
 
 func (this *Options) Hash() string {
@@ -71,6 +74,7 @@ func (this *Options) Hash() string {
 	opt.N = this.N
 	opt.Q = this.Q
 	opt.K = this.K
+	opt.Validators = this.Validators
 
 	// opt.BatchSize = this.BatchSize
 	// opt.BatchTimeout = this.BatchTimeout
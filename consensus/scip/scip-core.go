@@ -410,6 +410,7 @@ func (scip *Scip) recvCommit(commit *Commit) *Message {
 		ErrTxs:    core.errTxs,
 		Chain:     scip.options.Chain,
 		ReplicaID: scip.options.ID,
+		PrimaryID: core.prePrepare.ReplicaID,
 	}
 
 	log.Debugf("Replica %s send Committed for consensus %s", scip.options.ID, commit.Digest)
@@ -552,7 +553,7 @@ func (scip *Scip) execute() {
 			scip.execHeight = request.Height + 1
 			var seqNos []uint32
 			seqNos = append(seqNos, seqNo)
-			scip.processBlock(request.Txs, seqNos, fmt.Sprintf("block timeout(%s), block size(%d)", scip.options.BatchTimeout, scip.options.BatchSize))
+			scip.processBlock(request, seqNos, fmt.Sprintf("block timeout(%s), block size(%d)", scip.options.BatchTimeout, scip.options.BatchSize))
 			nextExec = seqNo + 1
 		} else if seqNo > nextExec {
 			if seqNo-nextExec > uint32(scip.options.K) {
@@ -574,15 +575,25 @@ func (scip *Scip) execute() {
 	}
 }
 
-func (scip *Scip) processBlock(txs proto.Transactions, seqNos []uint32, reason string) {
+func (scip *Scip) processBlock(committed *Committed, seqNos []uint32, reason string) {
 	scip.blockTimer.Stop()
+	txs := committed.Txs
 	if len(seqNos) != 0 {
 		log.Infof("Replica %s write block %d (%d transactions)  %v : %s", scip.options.ID, scip.execHeight, len(txs), seqNos, reason)
 		t := uint32(time.Now().Unix())
 		if n := len(txs); n > 0 {
 			t = txs[len(txs)-1].CreateTime()
 		}
-		scip.outputTxsChan <- &consensus.OutputTxs{Txs: txs, SeqNos: seqNos, Time: t, Height: scip.execHeight}
+		// every replica builds the block, the consensus metadata is the same on all of them
+		meta := &Committed{SeqNo: committed.SeqNo, Height: committed.Height, Digest: committed.Digest, Chain: committed.Chain, PrimaryID: committed.PrimaryID}
+		scip.outputTxsChan <- &consensus.OutputTxs{
+			Txs:      txs,
+			SeqNos:   seqNos,
+			Time:     t,
+			Height:   scip.execHeight,
+			Proposer: committed.PrimaryID,
+			MetaHash: crypto.DoubleSha256(utils.Serialize(meta)),
+		}
 	} else {
 		panic("unreachable")
 	}
@@ -261,6 +261,20 @@ func (scip *Scip) hasPrimary() bool {
 	return strings.Compare("", scip.primaryID) != 0
 }
 
+// isValidator returns whether the replica may vote
+func (scip *Scip) isValidator(tp MessageType, replica string) bool {
+	if len(scip.options.Validators) == 0 {
+		return true
+	}
+	for _, validator := range scip.options.Validators {
+		if validator == replica {
+			return true
+		}
+	}
+	log.Warnf("Replica %s received consensus message type %v from %s: ignore, not a validator", scip.options.ID, tp, replica)
+	return false
+}
+
 func (scip *Scip) processConsensusMsg(msg *Message) *Message {
 	log.Debugf("scip handle consensus message type %v ", msg.Type)
 	switch tp := msg.Type; tp {
@@ -269,19 +283,19 @@ func (scip *Scip) processConsensusMsg(msg *Message) *Message {
 			return scip.recvRequest(request)
 		}
 	case MESSAGEPREPREPARE:
-		if preprepare := msg.GetPrePrepare(); preprepare != nil {
+		if preprepare := msg.GetPrePrepare(); preprepare != nil && scip.isValidator(tp, preprepare.ReplicaID) {
 			return scip.recvPrePrepare(preprepare)
 		}
 	case MESSAGEPREPARE:
-		if prepare := msg.GetPrepare(); prepare != nil {
+		if prepare := msg.GetPrepare(); prepare != nil && scip.isValidator(tp, prepare.ReplicaID) {
 			return scip.recvPrepare(prepare)
 		}
 	case MESSAGECOMMIT:
-		if commit := msg.GetCommit(); commit != nil {
+		if commit := msg.GetCommit(); commit != nil && scip.isValidator(tp, commit.ReplicaID) {
 			return scip.recvCommit(commit)
 		}
 	case MESSAGECOMMITTED:
-		if committed := msg.GetCommitted(); committed != nil {
+		if committed := msg.GetCommitted(); committed != nil && scip.isValidator(tp, committed.ReplicaID) {
 			return scip.recvCommitted(committed)
 		}
 	case MESSAGEFETCHCOMMITTED:
@@ -289,7 +303,7 @@ func (scip *Scip) processConsensusMsg(msg *Message) *Message {
 			return nil
 		}
 	case MESSAGEVIEWCHANGE:
-		if vc := msg.GetViewChange(); vc != nil {
+		if vc := msg.GetViewChange(); vc != nil && scip.isValidator(tp, vc.ReplicaID) {
 			return scip.recvViewChange(vc)
 		}
 	default:
@@ -375,6 +389,7 @@ func (scip *Scip) recvFetchCommitted(fct *FetchCommitted) *Message {
 			ErrTxs:    request.ErrTxs,
 			Chain:     scip.options.Chain,
 			ReplicaID: scip.options.ID,
+			PrimaryID: request.PrimaryID,
 		}
 		scip.broadcast(scip.options.Chain, &Message{
 			Type:    MESSAGECOMMITTED,
@@ -558,6 +573,7 @@ func (scip *Scip) newView(vc *ViewChange) {
 	}
 	scip.stopViewChangePeriodTimer()
 	scip.startViewChangePeriodTimer()
+	scip.stack.ViewChanged(scip.primaryID, scip.seqNo, scip.height)
 
 	for _, vcl := range scip.vcStore {
 		vcl.stop()
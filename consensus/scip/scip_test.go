@@ -33,3 +33,19 @@ func TestScip(t *testing.T) {
 	_ = scip
 
 }
+
+func TestValidators(t *testing.T) {
+	options := NewDefaultOptions()
+	scip := NewScip(options, helper.NewStack())
+	if !scip.isValidator(MESSAGEPREPARE, "any") {
+		t.Error("replica rejected without validators")
+	}
+
+	options.Validators = []string{"0:a", "0:b"}
+	if !scip.isValidator(MESSAGEPREPARE, "0:b") {
+		t.Error("validator rejected")
+	}
+	if scip.isValidator(MESSAGEPREPARE, "0:c") {
+		t.Error("replica outside the validators accepted")
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/zipper-project/zipper/common/utils"
+	pb "github.com/zipper-project/zipper/proto"
+)
+
+// block archive layout: magic, version, then length prefixed records (the meta, one block with its transactions
+// per height and an empty record ending them) followed by the sha256 of everything before
+const (
+	blockArchiveMagic   = "ZIPBLKS"
+	blockArchiveVersion = 1
+)
+
+// ErrBlockArchiveChecksum is returned when a block archive is corrupt
+var ErrBlockArchiveChecksum = errors.New("block archive checksum mismatch")
+
+type blockArchiveMeta struct {
+	From uint32 `enc:"1"`
+	To   uint32 `enc:"2"`
+}
+
+// ExportBlocks writes the blocks from height from up to and including height to with their transactions to w
+func (ledger *Ledger) ExportBlocks(w io.Writer, from, to uint32) error {
+	height, err := ledger.Height()
+	if err != nil {
+		return err
+	}
+	if from > to {
+		return fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+	if to > height {
+		return fmt.Errorf("block %d is above the current height %d", to, height)
+	}
+
+	aw := &recordWriter{w: w, sum: sha256.New()}
+	if err := aw.write(append([]byte(blockArchiveMagic), blockArchiveVersion)); err != nil {
+		return err
+	}
+	if err := aw.writeRecord(utils.Serialize(&blockArchiveMeta{From: from, To: to})); err != nil {
+		return err
+	}
+	for h := from; ; h++ {
+		header, err := ledger.block.GetBlockByNumber(h)
+		if err != nil {
+			return fmt.Errorf("block [%d] can't be read -- %s", h, err)
+		}
+		txs, err := ledger.block.GetTransactionsByNumber(h, 100)
+		if err != nil {
+			return fmt.Errorf("transactions of block [%d] can't be read -- %s", h, err)
+		}
+		block := &pb.Block{Header: header, Transactions: txs}
+		if err := aw.writeRecord(block.Serialize()); err != nil {
+			return err
+		}
+		if h == to {
+			break
+		}
+	}
+
+	if err := aw.writeRecord(nil); err != nil {
+		return err
+	}
+	_, err = w.Write(aw.sum.Sum(nil))
+	return err
+}
+
+// readBlockArchive reads a block archive and calls fn with each block in height order, fn may be nil.
+// The blocks are checked to link up, the checksum of the archive is only checked after the last block
+func readBlockArchive(r io.Reader, fn func(block *pb.Block) error) (*blockArchiveMeta, error) {
+	ar := &recordReader{r: r, sum: sha256.New()}
+	magic, err := ar.read(len(blockArchiveMagic) + 1)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(magic[:len(blockArchiveMagic)], []byte(blockArchiveMagic)) {
+		return nil, errors.New("not a block archive")
+	}
+	if magic[len(blockArchiveMagic)] != blockArchiveVersion {
+		return nil, fmt.Errorf("unsupported block archive version %d", magic[len(blockArchiveMagic)])
+	}
+
+	data, err := ar.readRecord()
+	if err != nil {
+		return nil, err
+	}
+	meta := &blockArchiveMeta{}
+	if err := utils.Deserialize(data, meta); err != nil {
+		return nil, err
+	}
+
+	var previous *pb.BlockHeader
+	for {
+		data, err := ar.readRecord()
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			break
+		}
+		block := &pb.Block{}
+		if err := block.Deserialize(data); err != nil {
+			return nil, err
+		}
+		if block.Header == nil {
+			return nil, errors.New("block archive has a block without header")
+		}
+		if previous == nil {
+			if block.Height() != meta.From {
+				return nil, fmt.Errorf("block archive starts at block %d, not %d", block.Height(), meta.From)
+			}
+		} else if block.Height() != previous.Height+1 || block.PreviousHash() != previous.Hash().String() {
+			return nil, fmt.Errorf("block %d %s of the archive doesn't link to block %d %s", block.Height(), block.Hash(), previous.Height, previous.Hash())
+		}
+		// fn may rewrite the header, the next block links to the archived one
+		header := *block.Header
+		previous = &header
+		if fn != nil {
+			if err := fn(block); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if previous == nil || previous.Height != meta.To {
+		return nil, fmt.Errorf("block archive ends before block %d", meta.To)
+	}
+
+	expected := ar.sum.Sum(nil)
+	checksum := make([]byte, len(expected))
+	if _, err := io.ReadFull(r, checksum); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(checksum, expected) {
+		return nil, ErrBlockArchiveChecksum
+	}
+	return meta, nil
+}
+
+// CheckBlockArchive reads a whole block archive and returns the height range it holds if it is intact
+func CheckBlockArchive(r io.Reader) (uint32, uint32, error) {
+	meta, err := readBlockArchive(r, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	return meta.From, meta.To, nil
+}
+
+// ReadBlocks reads a block archive and calls fn with each block in height order.
+// A corrupt archive is only detected after the last block, it should be checked with CheckBlockArchive first
+func ReadBlocks(r io.Reader, fn func(block *pb.Block) error) error {
+	_, err := readBlockArchive(r, fn)
+	return err
+}
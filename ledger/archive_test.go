@@ -0,0 +1,127 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	pb "github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+func TestBlockArchive(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	li := NewLedger(db.NewMemDB(db.DefaultConfig()))
+
+	height, err := li.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keypair, _ := crypto.GenerateKey()
+	issueTx := pb.NewTransaction(account.NewChainCoordinate([]byte{byte(0)}),
+		account.NewChainCoordinate([]byte{byte(0)}),
+		pb.TransactionType_Issue,
+		uint32(0),
+		account.PublicKeyToAddress(*keypair.Public()),
+		backfrontReciepent,
+		uint32(11),
+		big.NewInt(issueAmount),
+		big.NewInt(fee),
+		utils.CurrentTimestamp())
+	issueTx.Payload, _ = json.Marshal(map[string]interface{}{"id": 11})
+	signature, _ := keypair.Sign(issueTx.Hash().Bytes())
+	issueTx.GetHeader().Signature = signature.Bytes()
+
+	for i := uint32(1); i <= 2; i++ {
+		txs := []*pb.Transaction{}
+		if i == 1 {
+			txs = append(txs, issueTx)
+		}
+		previous, err := li.GetBlockByNumber(height + i - 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := li.AppendBlock(&pb.Block{Transactions: txs, Header: &pb.BlockHeader{Height: height + i, PreviousHash: previous.Hash().String()}}, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := li.ExportBlocks(&bytes.Buffer{}, 0, height+3); err == nil {
+		t.Error("exported a block above the current height")
+	}
+	buf := &bytes.Buffer{}
+	if err := li.ExportBlocks(buf, 0, height+2); err != nil {
+		t.Fatal(err)
+	}
+	archive := buf.Bytes()
+
+	from, to, err := CheckBlockArchive(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, from, uint32(0))
+	utils.AssertEquals(t, to, height+2)
+
+	// replay the archive into a fresh ledger
+	imported := NewLedger(db.NewMemDB(db.DefaultConfig()))
+	err = ReadBlocks(bytes.NewReader(archive), func(block *pb.Block) error {
+		if block.Height() == 0 {
+			genesis, err := imported.GetGenesisBlock()
+			if err != nil {
+				return err
+			}
+			utils.AssertEquals(t, block.Hash(), genesis.Hash())
+			return nil
+		}
+		return imported.AppendBlock(block, false)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for h := uint32(0); h <= height+2; h++ {
+		expected, _ := li.GetBlockHashByNumber(h)
+		hash, err := imported.GetBlockHashByNumber(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.AssertEquals(t, hash, expected)
+	}
+	txs, err := imported.GetTxsByBlockNumber(height+1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, len(txs), 1)
+	utils.AssertEquals(t, txs[0].Hash(), issueTx.Hash())
+
+	// a corrupt checksum or a truncated archive is rejected
+	corrupt := append([]byte{}, archive...)
+	corrupt[len(corrupt)-1] ^= 0xff
+	if _, _, err := CheckBlockArchive(bytes.NewReader(corrupt)); err != ErrBlockArchiveChecksum {
+		t.Errorf("corrupt archive -- %v", err)
+	}
+	if _, _, err := CheckBlockArchive(bytes.NewReader(archive[:len(archive)/2])); err == nil {
+		t.Error("truncated archive is intact")
+	}
+}
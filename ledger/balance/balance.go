@@ -17,6 +17,7 @@
 package balance
 
 import (
+	"math/big"
 	"sync"
 
 	"github.com/zipper-project/zipper/common/utils"
@@ -24,41 +25,44 @@ import (
 
 // Balance Contain all asset amounts and nonce
 type Balance struct {
-	Amounts map[uint32]int64
+	Amounts map[uint32]*big.Int `enc:"1"`
 	rw      sync.RWMutex
 }
 
 // NewBalance Create an balance object
 func NewBalance() *Balance {
 	return &Balance{
-		Amounts: make(map[uint32]int64),
+		Amounts: make(map[uint32]*big.Int),
 	}
 }
 
 //Set Set amount of asset
-func (b *Balance) Set(id uint32, amount int64) {
+func (b *Balance) Set(id uint32, amount *big.Int) {
 	b.rw.Lock()
 	defer b.rw.Unlock()
-	b.Amounts[id] = amount
+	b.Amounts[id] = new(big.Int).Set(amount)
 }
 
 //Get Get amount of asset
-func (b *Balance) Get(id uint32) int64 {
+func (b *Balance) Get(id uint32) *big.Int {
+	b.rw.RLock()
+	defer b.rw.RUnlock()
 	if amount, ok := b.Amounts[id]; ok {
-		return amount
+		return new(big.Int).Set(amount)
 	}
-	return 0
+	return new(big.Int)
 }
 
 //Add Set amount of asset to  sum +y and return.
-func (b *Balance) Add(id uint32, amount int64) int64 {
+func (b *Balance) Add(id uint32, amount *big.Int) *big.Int {
 	b.rw.Lock()
 	defer b.rw.Unlock()
-	if !utils.CheckInt64Border(b.Amounts[id], amount) {
-		panic("balance amount value out of range.")
+	sum := new(big.Int).Set(amount)
+	if v, ok := b.Amounts[id]; ok {
+		sum.Add(sum, v)
 	}
-	b.Amounts[id] = b.Amounts[id] + amount
-	return b.Amounts[id]
+	b.Amounts[id] = sum
+	return new(big.Int).Set(sum)
 }
 
 //Serialize returns the serialized bytes of a balance
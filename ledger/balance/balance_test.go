@@ -17,6 +17,8 @@
 package balance
 
 import (
+	"math"
+	"math/big"
 	"testing"
 
 	"github.com/zipper-project/zipper/common/utils"
@@ -24,23 +26,28 @@ import (
 
 func TestBalance(t *testing.T) {
 	b := NewBalance()
-	utils.AssertEquals(t, int64(0), b.Get(0))
+	utils.AssertEquals(t, int64(0), b.Get(0).Int64())
 
-	b.Set(0, 100)
-	utils.AssertEquals(t, int64(100), b.Get(0))
+	b.Set(0, big.NewInt(100))
+	utils.AssertEquals(t, int64(100), b.Get(0).Int64())
 
-	b.Add(0, 100)
-	utils.AssertEquals(t, int64(200), b.Get(0))
+	b.Add(0, big.NewInt(100))
+	utils.AssertEquals(t, int64(200), b.Get(0).Int64())
 
-	b.Add(0, -1000)
-	utils.AssertEquals(t, int64(-800), b.Get(0))
+	b.Add(0, big.NewInt(-1000))
+	utils.AssertEquals(t, int64(-800), b.Get(0).Int64())
+
+	// amounts go past the int64 range instead of overflowing
+	b.Set(1, big.NewInt(math.MaxInt64))
+	utils.AssertEquals(t, b.Add(1, big.NewInt(1)).String(), "9223372036854775808")
+	utils.AssertEquals(t, b.Get(1).String(), "9223372036854775808")
 }
 
 func TestSerializeAndDeserialize(t *testing.T) {
 	b := NewBalance()
-	b.Set(0, -100)
-	b.Set(1, 200)
-	b.Set(3, 300)
+	b.Set(0, big.NewInt(-100))
+	b.Set(1, big.NewInt(200))
+	b.Set(3, new(big.Int).Lsh(big.NewInt(300), 64))
 	balanceBytes := b.Serialize()
 
 	tb := NewBalance()
@@ -49,7 +56,7 @@ func TestSerializeAndDeserialize(t *testing.T) {
 		t.Error(err)
 	}
 
-	utils.AssertEquals(t, b.Get(0), tb.Get(0))
-	utils.AssertEquals(t, b.Get(2), tb.Get(2))
-	utils.AssertEquals(t, b.Get(3), tb.Get(3))
+	utils.AssertEquals(t, b.Get(0).String(), tb.Get(0).String())
+	utils.AssertEquals(t, b.Get(2).String(), tb.Get(2).String())
+	utils.AssertEquals(t, b.Get(3).String(), tb.Get(3).String())
 }
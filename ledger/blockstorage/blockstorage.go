@@ -19,6 +19,7 @@ package blockstorage
 import (
 	"errors"
 
+	"github.com/zipper-project/zipper/account"
 	"github.com/zipper-project/zipper/common/crypto"
 	"github.com/zipper-project/zipper/common/db"
 	"github.com/zipper-project/zipper/common/utils"
@@ -26,23 +27,28 @@ import (
 )
 
 const (
-	heightKey string = "blockLastHeight"
+	heightKey     string = "blockLastHeight"
+	baseHeightKey string = "blockBaseHeight"
 )
 
 // Blockchain represents block
 type Blockchain struct {
-	dbHandler               *db.BlockchainDB
+	dbHandler               db.Database
 	txPrefix                []byte
+	txIndexPrefix           []byte
+	addrPrefix              []byte
 	blockColumnFamily       string
 	transactionColumnFamily string
 	indexColumnFamily       string
 }
 
 // NewBlockchain initialization
-func NewBlockchain(db *db.BlockchainDB) *Blockchain {
+func NewBlockchain(db db.Database) *Blockchain {
 	return &Blockchain{
 		dbHandler:               db,
 		txPrefix:                []byte("tx_"),
+		txIndexPrefix:           []byte("txidx_"),
+		addrPrefix:              []byte("addr_"),
 		blockColumnFamily:       "block",
 		transactionColumnFamily: "transaction",
 		indexColumnFamily:       "index",
@@ -168,15 +174,49 @@ func (blockchain *Blockchain) AppendBlock(block *pb.Block) []*db.WriteBatch {
 	writeBatchs = append(writeBatchs, db.NewWriteBatch(blockchain.indexColumnFamily, db.OperationPut, []byte(heightKey), blockHeightBytes, blockchain.indexColumnFamily))      // update block height
 
 	//storage  tx hash
-	for _, tx := range block.Transactions {
+	for idx, tx := range block.Transactions {
 		txHashs = append(txHashs, tx.Hash())
 		writeBatchs = append(writeBatchs, db.NewWriteBatch(blockchain.transactionColumnFamily, db.OperationPut, tx.Hash().Bytes(), tx.Serialize(), blockchain.transactionColumnFamily)) // tx hash => tx detail
+		// prefix + tx hash => block height + tx index
+		writeBatchs = append(writeBatchs, db.NewWriteBatch(blockchain.indexColumnFamily, db.OperationPut, prependKeyPrefix(blockchain.txIndexPrefix, tx.Hash().Bytes()), encodeTxLocation(height, uint32(idx)), string(blockchain.txIndexPrefix)))
+		// prefix + address + asset id + block height + tx index => tx hash, for both sender and recipient
+		for _, addr := range []account.Address{tx.Sender(), tx.Recipient()} {
+			key := blockchain.addrTxKey(addr, tx.AssetID(), TxCursor{Height: height, Index: uint32(idx)})
+			writeBatchs = append(writeBatchs, db.NewWriteBatch(blockchain.indexColumnFamily, db.OperationPut, key, tx.Hash().Bytes(), string(blockchain.addrPrefix)))
+		}
 	}
 	writeBatchs = append(writeBatchs, db.NewWriteBatch(blockchain.indexColumnFamily, db.OperationPut, prependKeyPrefix(blockchain.txPrefix, blockHeightBytes), utils.Serialize(txHashs), string(blockchain.txPrefix))) // prefix + blockheight  => all tx hash
 
 	return writeBatchs
 }
 
+// GetTxLocation returns the height of the block containing the transaction and its index in the block
+func (blockchain *Blockchain) GetTxLocation(txHash []byte) (uint32, uint32, error) {
+	data, err := blockchain.dbHandler.Get(blockchain.indexColumnFamily, prependKeyPrefix(blockchain.txIndexPrefix, txHash))
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(data) != 8 {
+		return 0, 0, errors.New("not found transaction location")
+	}
+	return utils.BytesToUint32(data[:4]), utils.BytesToUint32(data[4:]), nil
+}
+
+// GetBaseHeight returns the height of the first block stored above the genesis block, which is above 0 if the chain
+// was imported from a snapshot
+func (blockchain *Blockchain) GetBaseHeight() uint32 {
+	heightBytes, _ := blockchain.dbHandler.Get(blockchain.indexColumnFamily, []byte(baseHeightKey))
+	if len(heightBytes) == 0 {
+		return 0
+	}
+	return utils.BytesToUint32(heightBytes)
+}
+
+// BaseHeightBatch returns the write batch recording height as the first block stored
+func (blockchain *Blockchain) BaseHeightBatch(height uint32) *db.WriteBatch {
+	return db.NewWriteBatch(blockchain.indexColumnFamily, db.OperationPut, []byte(baseHeightKey), utils.Uint32ToBytes(height), blockchain.indexColumnFamily)
+}
+
 //GetBlockHashByNumber get block hash by block number
 func (blockchain *Blockchain) GetBlockHashByNumber(blockNum uint32) ([]byte, error) {
 	currentHeight, err := blockchain.GetBlockchainHeight()
@@ -248,6 +288,10 @@ func (blockchain *Blockchain) GetTransactionInBlock(data []byte, typ string) (ma
 	return nil, false
 }
 
+func encodeTxLocation(height, idx uint32) []byte {
+	return append(utils.Uint32ToBytes(height), utils.Uint32ToBytes(idx)...)
+}
+
 func removeKeyPrefix(data []byte, prefix []byte) []byte {
 	prefixLen := len(prefix)
 	return data[prefixLen:]
@@ -31,7 +31,7 @@ import (
 )
 
 var (
-	testDb = db.NewDB(db.DefaultConfig())
+	testDb = db.NewMemDB(db.DefaultConfig())
 
 	lastid    uint32
 	sender    = account.HexToAddress("0xa122277be213f56221b6140998c03d860a60e1f8")
@@ -70,6 +70,10 @@ func TestAppendBlock(t *testing.T) {
 		header.TimeStamp = uint32(time.Now().Unix())
 		header.Nonce = rand.Uint32()
 		header.Height = uint32(i)
+		header.Version = pb.BlockVersion
+		header.ChainID = []byte{0}
+		header.Proposer = "0001_abc"
+		header.ConsensusMetaHash = crypto.Sha256([]byte{byte(i)}).String()
 
 		header.PreviousHash = previousHash.String()
 
@@ -78,7 +82,7 @@ func TestAppendBlock(t *testing.T) {
 
 		// transaction
 		var hashSlice []crypto.Hash
-		for j := 0; j < 1; j++ {
+		for j := 0; j < 3; j++ {
 			tx := &pb.Transaction{
 				Header: &pb.TxHeader{
 					FromChain:  account.NewChainCoordinate([]byte{byte(i + j)}),
@@ -117,7 +121,16 @@ func TestAppendBlock(t *testing.T) {
 		t.Log("tx len: ", len(nb.Transactions), "block height:", nb.GetHeader().GetHeight())
 		blockHeaderBytes = nb.Header.Serialize()
 	}
-	return
+
+	header, err := b.GetBlockByNumber(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, header.Version, pb.BlockVersion)
+	utils.AssertEquals(t, header.ChainID, []byte{0})
+	utils.AssertEquals(t, header.Proposer, "0001_abc")
+	utils.AssertEquals(t, header.ConsensusMetaHash, crypto.Sha256([]byte{2}).String())
+	utils.AssertEquals(t, header.Hash(), previousHash)
 }
 
 func TestGetBlockchainHeight(t *testing.T) {
@@ -149,6 +162,59 @@ func TestGetTransactionsByNumber(t *testing.T) {
 	utils.AssertEquals(t, blockHeader.Serialize(), blockHeaderBytes)
 }
 
+func TestGetTxProof(t *testing.T) {
+	b := NewBlockchain(testDb)
+	proof, err := b.GetTxProof(testTxHash.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockHeader, err := b.GetBlockByNumber(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, proof.Height, uint32(2))
+	utils.AssertEquals(t, proof.Index, uint32(2))
+	utils.AssertEquals(t, proof.Verify(blockHeader.TxsMerkleHash), true)
+
+	proof.Index = 1
+	utils.AssertEquals(t, proof.Verify(blockHeader.TxsMerkleHash), false)
+}
+
+func TestGetTxsByAddress(t *testing.T) {
+	b := NewBlockchain(testDb)
+	history, err := b.GetTxsByAddress(sender, 1, TxCursor{Height: 1}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, len(history.Txs), 4)
+	utils.AssertEquals(t, history.Txs[3].Height, uint32(2))
+	utils.AssertEquals(t, history.Txs[3].Index, uint32(0))
+
+	from, err := ParseTxCursor(history.Next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err = b.GetTxsByAddress(sender, 1, from, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, len(history.Txs), 2)
+	utils.AssertEquals(t, history.Txs[1].Tx.Hash(), testTxHash)
+	utils.AssertEquals(t, history.Next, "")
+
+	history, err = b.GetTxsByAddress(reciepent, 1, TxCursor{Height: 2}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, len(history.Txs), 3)
+
+	history, err = b.GetTxsByAddress(reciepent, 2, TxCursor{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, len(history.Txs), 0)
+}
+
 func TestGetTransactionByTxHash(t *testing.T) {
 	b := NewBlockchain(testDb)
 	tx, err := b.GetTransactionByTxHash(testTxHash.Bytes())
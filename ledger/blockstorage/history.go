@@ -0,0 +1,120 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package blockstorage
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+
+	"github.com/zipper-project/zipper/account"
+	pb "github.com/zipper-project/zipper/proto"
+)
+
+// MaxTxHistoryLimit is the max number of transactions returned by one history query
+const MaxTxHistoryLimit = 1000
+
+// TxCursor is the position of a transaction in the chain
+type TxCursor struct {
+	Height uint32
+	Index  uint32
+}
+
+// String returns the cursor in the form accepted by ParseTxCursor
+func (c TxCursor) String() string {
+	return hex.EncodeToString(c.Bytes())
+}
+
+// Bytes returns the cursor as big endian height and index, which sorts in chain order
+func (c TxCursor) Bytes() []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint32(buf, c.Height)
+	binary.BigEndian.PutUint32(buf[4:], c.Index)
+	return buf
+}
+
+// ParseTxCursor parses a cursor returned by TxCursor.String
+func ParseTxCursor(s string) (TxCursor, error) {
+	buf, err := hex.DecodeString(s)
+	if err != nil || len(buf) != 8 {
+		return TxCursor{}, errors.New("invalid cursor")
+	}
+	return TxCursor{
+		Height: binary.BigEndian.Uint32(buf),
+		Index:  binary.BigEndian.Uint32(buf[4:]),
+	}, nil
+}
+
+// AddressTx is a transaction of an address with its position in the chain
+type AddressTx struct {
+	Height uint32          `json:"height"`
+	Index  uint32          `json:"index"`
+	Tx     *pb.Transaction `json:"tx"`
+}
+
+// TxHistory is a page of the transactions of an address, Next is the cursor of the
+// following page and is empty on the last page
+type TxHistory struct {
+	Txs  []*AddressTx `json:"txs"`
+	Next string       `json:"next"`
+}
+
+func (blockchain *Blockchain) addrTxPrefix(addr account.Address, assetID uint32) []byte {
+	key := prependKeyPrefix(blockchain.addrPrefix, addr.Bytes())
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, assetID)
+	return append(key, buf...)
+}
+
+func (blockchain *Blockchain) addrTxKey(addr account.Address, assetID uint32, c TxCursor) []byte {
+	return append(blockchain.addrTxPrefix(addr, assetID), c.Bytes()...)
+}
+
+// GetTxsByAddress returns up to limit transactions of assetID sent or received by addr,
+// in chain order starting at from
+func (blockchain *Blockchain) GetTxsByAddress(addr account.Address, assetID uint32, from TxCursor, limit int) (*TxHistory, error) {
+	if limit <= 0 || limit > MaxTxHistoryLimit {
+		limit = MaxTxHistoryLimit
+	}
+
+	prefix := blockchain.addrTxPrefix(addr, assetID)
+	iter := blockchain.dbHandler.NewIterator(blockchain.indexColumnFamily, prefix, blockchain.addrTxKey(addr, assetID, from))
+	defer iter.Release()
+
+	history := &TxHistory{Txs: make([]*AddressTx, 0)}
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != len(prefix)+8 {
+			continue
+		}
+		c := TxCursor{
+			Height: binary.BigEndian.Uint32(key[len(prefix):]),
+			Index:  binary.BigEndian.Uint32(key[len(prefix)+4:]),
+		}
+		if len(history.Txs) == limit {
+			history.Next = c.String()
+			break
+		}
+
+		tx, err := blockchain.GetTransactionByTxHash(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		history.Txs = append(history.Txs, &AddressTx{Height: c.Height, Index: c.Index, Tx: tx})
+	}
+	return history, nil
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package blockstorage
+
+import (
+	"errors"
+
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/utils"
+)
+
+// TxProof proves a transaction is included in the block at Height, the Index of TxCount transactions.
+// Siblings are the merkle branch from the transaction up to TxsMerkleHash
+type TxProof struct {
+	Height        uint32        `json:"height"`
+	Index         uint32        `json:"index"`
+	TxCount       uint32        `json:"txCount"`
+	TxHash        crypto.Hash   `json:"txHash"`
+	TxsMerkleHash string        `json:"txsMerkleHash"`
+	Siblings      []crypto.Hash `json:"siblings"`
+}
+
+// Verify reports whether the proof leads to txsMerkleHash, the TxsMerkleHash of a trusted block header
+func (proof *TxProof) Verify(txsMerkleHash string) bool {
+	return crypto.VerifyMerkleBranch(proof.TxHash, int(proof.Index), int(proof.TxCount), proof.Siblings, crypto.HexToHash(txsMerkleHash))
+}
+
+// GetTxProof returns the proof of the transaction against the TxsMerkleHash of its block
+func (blockchain *Blockchain) GetTxProof(txHash []byte) (*TxProof, error) {
+	height, idx, err := blockchain.GetTxLocation(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	blockHeader, err := blockchain.GetBlockByNumber(height)
+	if err != nil {
+		return nil, err
+	}
+
+	txHashsBytes, err := blockchain.GetTransactionHashList(height)
+	if err != nil {
+		return nil, err
+	}
+	txHashs := []crypto.Hash{}
+	if err := utils.Deserialize(txHashsBytes, &txHashs); err != nil {
+		return nil, err
+	}
+
+	proof := &TxProof{
+		Height:        height,
+		Index:         idx,
+		TxCount:       uint32(len(txHashs)),
+		TxHash:        crypto.NewHash(txHash),
+		TxsMerkleHash: blockHeader.TxsMerkleHash,
+		Siblings:      crypto.ComputeMerkleBranch(txHashs, int(idx)),
+	}
+	if !proof.Verify(blockHeader.TxsMerkleHash) {
+		return nil, errors.New("transaction doesn't match the merkle hash of its block")
+	}
+	return proof, nil
+}
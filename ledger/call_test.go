@@ -0,0 +1,155 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	pb "github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+var registryContract = `
+local ZIP = require("ZIP")
+
+function Init(args)
+    return true
+end
+
+function Invoke(func, args)
+    if func == "register" then
+        if ZIP.GetState(args[0]) then
+            error("name is taken")
+        end
+        ZIP.PutState(args[0], ZIP.TxInfo().Sender)
+        return true
+    end
+    if func == "owner" then
+        return ZIP.GetState(args[0])
+    end
+    if func == "deep" then
+        return ZIP.Call(args[0], "deep", {args[0]})
+    end
+    if func == "catch" then
+        pcall(ZIP.Call, args[0], "register", {args[1]})
+        return true
+    end
+    if func == "refuse" then
+        ZIP.Call(args[0], "register", {args[1]})
+        return false
+    end
+    if func == "unknown" then
+        ZIP.Call(args[0], "register", {args[1]})
+        ZIP.Call(args[0], "unknown", {})
+        return true
+    end
+    return false
+end
+
+function Query(args)
+    return ZIP.GetState(args[0]) or ""
+end
+`
+
+var registryClientContract = `
+function Init(args) {
+    return true;
+}
+
+function Invoke(func, args) {
+    ZIP.PutState("name", args[0]);
+    ZIP.Call("REGISTRY", "register", [args[0]]);
+    ZIP.PutState("owner", ZIP.Call("REGISTRY", "owner", [args[0]]));
+    if (func == "fail") {
+        throw "failed";
+    }
+    if (func == "refuse") {
+        return false;
+    }
+    return true;
+}
+
+function Query(args) {
+    return ZIP.GetState(args[0]);
+}
+`
+
+func TestContractCall(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	li := NewLedger(db.NewMemDB(db.DefaultConfig()))
+	registry := account.HexToAddress("0xc332277be213f56221b6140998c03d860a60e1f8")
+	client := account.HexToAddress("0xc432277be213f56221b6140998c03d860a60e1f8")
+
+	nonce := uint32(0)
+	height := uint32(0)
+	execute := func(tp pb.TransactionType, addr account.Address, code string, args ...string) bool {
+		tx := newContractTx(tp, addr, code, args...)
+		tx.Header.Nonce = nonce
+		nonce++
+		height++
+		if err := li.AppendBlock(&pb.Block{Transactions: []*pb.Transaction{tx}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: height}}, true); err != nil {
+			t.Fatal(err)
+		}
+		receipt, err := li.GetReceipt(tx.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return receipt.Success
+	}
+	query := func(addr account.Address, key string) string {
+		result, err := li.QueryContract(newContractTx(pb.TransactionType_ContractQuery, addr, "", key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(result)
+	}
+
+	utils.AssertEquals(t, execute(pb.TransactionType_LuaContractInit, registry, registryContract), true)
+	utils.AssertEquals(t, execute(pb.TransactionType_JSContractInit, client, strings.Replace(registryClientContract, "REGISTRY", registry.String(), -1)), true)
+
+	// the registry sees the client contract as the caller and the client sees the change of the registry
+	utils.AssertEquals(t, execute(pb.TransactionType_ContractInvoke, client, "", "register", "alice"), true)
+	utils.AssertEquals(t, query(registry, "alice"), client.String())
+	utils.AssertEquals(t, query(client, "owner"), client.String())
+
+	// a failed call fails the transaction with the changes of every contract
+	utils.AssertEquals(t, execute(pb.TransactionType_ContractInvoke, client, "", "register", "alice"), false)
+	utils.AssertEquals(t, execute(pb.TransactionType_ContractInvoke, client, "", "fail", "bob"), false)
+	utils.AssertEquals(t, query(registry, "bob"), "")
+	utils.AssertEquals(t, query(client, "name"), "alice")
+
+	// a contract returning false after a successful call fails the transaction with the changes of the call
+	utils.AssertEquals(t, execute(pb.TransactionType_ContractInvoke, client, "", "refuse", "carol"), false)
+	utils.AssertEquals(t, query(registry, "carol"), "")
+	utils.AssertEquals(t, query(client, "name"), "alice")
+	utils.AssertEquals(t, execute(pb.TransactionType_ContractInvoke, registry, "", "refuse", registry.String(), "carol"), false)
+	utils.AssertEquals(t, query(registry, "carol"), "")
+
+	// so does a called contract returning false
+	utils.AssertEquals(t, execute(pb.TransactionType_ContractInvoke, registry, "", "unknown", registry.String(), "carol"), false)
+	utils.AssertEquals(t, query(registry, "carol"), "")
+
+	// a contract can't catch a failed call to carry on
+	utils.AssertEquals(t, execute(pb.TransactionType_ContractInvoke, registry, "", "catch", registry.String(), "alice"), false)
+
+	// the call depth is bounded
+	utils.AssertEquals(t, execute(pb.TransactionType_ContractInvoke, registry, "", "deep", registry.String()), false)
+}
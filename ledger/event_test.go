@@ -0,0 +1,126 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger/state"
+	pb "github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+var eventContract = `
+local ZIP = require("ZIP")
+
+function Init(args)
+    ZIP.Emit("deployed")
+    return true
+end
+
+function Invoke(func, args)
+    ZIP.Emit("transfer", {to = args[0], amount = 10})
+    ZIP.Emit("memo", args[0])
+    if func == "fail" then
+        error("failed")
+    end
+    return true
+end
+
+function Query(args)
+    ZIP.Emit("query")
+    return "ok"
+end
+`
+
+func TestContractEvents(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	li := NewLedger(db.NewMemDB(db.DefaultConfig()))
+	addr := account.HexToAddress("0xc232277be213f56221b6140998c03d860a60e1f8")
+
+	deploy := newContractTx(pb.TransactionType_LuaContractInit, addr, eventContract)
+	if err := li.AppendBlock(&pb.Block{Transactions: []*pb.Transaction{deploy}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: 1}}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	invoke := newContractTx(pb.TransactionType_ContractInvoke, addr, "", "pay", "alice")
+	invoke.Header.Nonce = 1
+	if err := li.AppendBlock(&pb.Block{Transactions: []*pb.Transaction{invoke}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: 2}}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	receipt, err := li.GetReceipt(invoke.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, receipt.Success, true)
+	utils.AssertEquals(t, len(receipt.Events), 2)
+	utils.AssertEquals(t, receipt.Events[0].Contract, addr.String())
+	utils.AssertEquals(t, receipt.Events[0].Name, "transfer")
+	utils.AssertEquals(t, string(receipt.Events[0].Data), `{"amount":10,"to":"alice"}`)
+	utils.AssertEquals(t, receipt.Events[0].BlockHeight, uint32(2))
+	utils.AssertEquals(t, receipt.Events[0].TxHash, invoke.Hash())
+	utils.AssertEquals(t, receipt.Events[1].Index, uint32(1))
+	utils.AssertEquals(t, string(receipt.Events[1].Data), `"alice"`)
+
+	// events of all names are paged in chain order
+	page, err := li.GetEvents(&state.EventFilter{Contract: addr.String(), Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, len(page.Events), 2)
+	utils.AssertEquals(t, page.Events[0].Name, "deployed")
+	utils.AssertEquals(t, string(page.Events[0].Data), "null")
+	utils.AssertEquals(t, page.Events[1].Name, "transfer")
+	page, err = li.GetEvents(&state.EventFilter{Contract: addr.String(), Limit: 2, Cursor: page.Next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, len(page.Events), 1)
+	utils.AssertEquals(t, page.Events[0].Name, "memo")
+	utils.AssertEquals(t, page.Next, "")
+
+	page, err = li.GetEvents(&state.EventFilter{Contract: addr.String(), Name: "deployed", FromHeight: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, len(page.Events), 0)
+	page, err = li.GetEvents(&state.EventFilter{Contract: addr.String(), FromHeight: 1, ToHeight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, len(page.Events), 1)
+
+	// the events of a failed transaction are discarded
+	failed := newContractTx(pb.TransactionType_ContractInvoke, addr, "", "fail", "bob")
+	failed.Header.Nonce = 2
+	if err := li.AppendBlock(&pb.Block{Transactions: []*pb.Transaction{failed}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: 3}}, true); err != nil {
+		t.Fatal(err)
+	}
+	page, err = li.GetEvents(&state.EventFilter{Contract: addr.String(), FromHeight: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, len(page.Events), 0)
+
+	if _, err := li.QueryContract(newContractTx(pb.TransactionType_ContractQuery, addr, "")); err != state.ErrReadOnly {
+		t.Errorf("query emitting an event returned %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger/state"
+	pb "github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+func TestValidUntil(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+
+	keypair, _ := crypto.GenerateKey()
+	sender := account.PublicKeyToAddress(*keypair.Public())
+	genesis := DefaultGenesis()
+	genesis.Assets = []*state.Asset{{ID: 1, Name: "coin"}}
+	genesis.Balances = []*GenesisBalance{{Address: sender, AssetID: 1, Amount: big.NewInt(100)}}
+	testDb := db.NewMemDB(db.DefaultConfig())
+	if _, err := InitGenesis(testDb, genesis); err != nil {
+		t.Fatal(err)
+	}
+	li := NewLedger(testDb)
+
+	withValidUntil := func(tx *pb.Transaction, height uint32) *pb.Transaction {
+		tx.GetHeader().ValidUntil = height
+		tx.GetHeader().Signature = nil
+		signature, _ := keypair.Sign(tx.Hash().Bytes())
+		tx.GetHeader().Signature = signature.Bytes()
+		return tx
+	}
+	blocks := []*pb.Block{
+		{Transactions: []*pb.Transaction{withValidUntil(newFeeTx(keypair, 0, atmoicReciepent, 1, 10, 0), 1)}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: 1}},
+		{Transactions: []*pb.Transaction{withValidUntil(newFeeTx(keypair, 1, atmoicReciepent, 1, 10, 0), 1)}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: 2}},
+	}
+	// the transaction executed above its height fails without using its nonce
+	success := []bool{true, false}
+	for i, block := range blocks {
+		if err := li.AppendBlock(block, true); err != nil {
+			t.Fatal(err)
+		}
+		receipt, err := li.GetReceipt(block.Transactions[0].Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.AssertEquals(t, receipt.Success, success[i])
+	}
+	nonce, err := li.GetNonce(sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, nonce, uint32(1))
+	b, err := li.GetBalance(sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, b.Get(1).Int64(), int64(90))
+}
@@ -0,0 +1,133 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger/state"
+	pb "github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+func newFeeTx(keypair *crypto.PrivateKey, nonce uint32, recipient account.Address, assetID uint32, amount, fee int64) *pb.Transaction {
+	tx := pb.NewTransaction(account.NewChainCoordinate([]byte{byte(0)}),
+		account.NewChainCoordinate([]byte{byte(0)}),
+		pb.TransactionType_Atomic,
+		nonce,
+		account.PublicKeyToAddress(*keypair.Public()),
+		recipient,
+		assetID,
+		big.NewInt(amount),
+		big.NewInt(fee),
+		utils.CurrentTimestamp())
+	signature, _ := keypair.Sign(tx.Hash().Bytes())
+	tx.GetHeader().Signature = signature.Bytes()
+	return tx
+}
+
+func TestFeePolicy(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+
+	keypair, _ := crypto.GenerateKey()
+	sender := account.PublicKeyToAddress(*keypair.Public())
+	pool := account.HexToAddress("0xb032277be213f56221b6140998c03d860a60e1f8")
+	proposer := account.HexToAddress("0xb132277be213f56221b6140998c03d860a60e1f8")
+
+	genesis := DefaultGenesis()
+	genesis.Assets = []*state.Asset{{ID: 1, Name: "fee"}, {ID: 2, Name: "coin"}}
+	genesis.Balances = []*GenesisBalance{
+		{Address: sender, AssetID: 1, Amount: big.NewInt(100)},
+		{Address: sender, AssetID: 2, Amount: big.NewInt(50)},
+	}
+	genesis.FeePolicy = &state.FeePolicy{
+		AssetID:    1,
+		Pool:       pool,
+		ToProposer: true,
+		Proposers:  map[string]account.Address{"0001_abc": proposer},
+		MinFees:    map[string]*big.Int{"Atomic": big.NewInt(2)},
+	}
+	testDb := db.NewMemDB(db.DefaultConfig())
+	if _, err := InitGenesis(testDb, genesis); err != nil {
+		t.Fatal(err)
+	}
+	li := NewLedger(testDb)
+
+	paid := newFeeTx(keypair, 0, atmoicReciepent, 2, 10, 3)
+	low := newFeeTx(keypair, 1, atmoicReciepent, 2, 10, 1)
+	blocks := []*pb.Block{
+		{Transactions: []*pb.Transaction{paid, low}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: 1, Proposer: "0001_abc"}},
+		{Transactions: []*pb.Transaction{newFeeTx(keypair, 2, atmoicReciepent, 1, 5, 2)}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: 2, Proposer: "0002_abc"}},
+	}
+	for _, block := range blocks {
+		if err := li.AppendBlock(block, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	balances := []struct {
+		addr    account.Address
+		assetID uint32
+		amount  int64
+	}{
+		{sender, 1, 100 - 3 - 5 - 2},
+		{sender, 2, 40},
+		{atmoicReciepent, 1, 5},
+		{atmoicReciepent, 2, 10},
+		{proposer, 1, 3},
+		{pool, 1, 2},
+	}
+	for _, b := range balances {
+		balance, err := li.GetBalance(b.addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.AssertEquals(t, balance.Get(b.assetID).Int64(), b.amount)
+	}
+
+	receipt, err := li.GetReceipt(paid.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, receipt.Fee.AssetID, uint32(1))
+	utils.AssertEquals(t, receipt.Fee.Amount.Int64(), int64(3))
+	utils.AssertEquals(t, receipt.Fee.Collector, proposer.String())
+	if receipt, err = li.GetReceipt(low.Hash()); err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, receipt.Success, false)
+
+	policy, err := li.GetFeePolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, policy.MinFee(pb.TransactionType_Atomic).Int64(), int64(2))
+	utils.AssertEquals(t, policy.MinFee(pb.TransactionType_Issue).Sign(), 0)
+
+	d, err := li.Reexecute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != nil {
+		t.Errorf("fee policy chain diverges on re-execution: %+v", d)
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/ledger/blockstorage"
+	"github.com/zipper-project/zipper/ledger/state"
+	"github.com/zipper-project/zipper/params"
+	pb "github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+const genesisColumnFamily = "ledger"
+
+var genesisKey = []byte("genesis")
+
+// Genesis defines the genesis block and the initial state of a chain
+type Genesis struct {
+	ChainID        string            `json:"chainId"` // hex chain coordinate, empty for the default one
+	Timestamp      uint32            `json:"timestamp"`
+	Nonce          uint32            `json:"nonce"`
+	Admin          account.Address   `json:"admin"`
+	Assets         []*state.Asset    `json:"assets"`
+	Balances       []*GenesisBalance `json:"balances"`
+	GlobalContract *GenesisContract  `json:"globalContract"`
+	Validators     []string          `json:"validators"`
+	FeePolicy      *state.FeePolicy  `json:"feePolicy,omitempty"`
+}
+
+// GenesisBalance is an initial balance of an address
+type GenesisBalance struct {
+	Address account.Address `json:"address"`
+	AssetID uint32          `json:"assetId"`
+	Amount  *big.Int        `json:"amount"`
+}
+
+// GenesisContract is the global contract
+type GenesisContract struct {
+	Type string `json:"type"`
+	Code string `json:"code"`
+}
+
+// DefaultGenesis returns the genesis used when the data dir wasn't initialised with a genesis file
+func DefaultGenesis() *Genesis {
+	return &Genesis{
+		Timestamp: 0,
+		Nonce:     100,
+		Admin:     state.DefaultAdminAddr,
+		GlobalContract: &GenesisContract{
+			Type: state.DefaultGlobalContractType,
+			Code: string(state.DefaultGlobalContractCode),
+		},
+	}
+}
+
+// ReadGenesis reads and checks a genesis file
+func ReadGenesis(r io.Reader) (*Genesis, error) {
+	genesis := &Genesis{}
+	if err := json.NewDecoder(r).Decode(genesis); err != nil {
+		return nil, fmt.Errorf("invalid genesis file -- %s", err)
+	}
+	if err := genesis.validate(); err != nil {
+		return nil, err
+	}
+	return genesis, nil
+}
+
+func (genesis *Genesis) validate() error {
+	if _, err := hex.DecodeString(genesis.ChainID); err != nil {
+		return fmt.Errorf("genesis chainId %q isn't a hex chain coordinate", genesis.ChainID)
+	}
+	if genesis.GlobalContract == nil || genesis.GlobalContract.Type == "" {
+		return errors.New("genesis has no global contract")
+	}
+
+	assets := make(map[uint32]bool)
+	for _, asset := range genesis.Assets {
+		if assets[asset.ID] {
+			return fmt.Errorf("genesis asset %d is defined twice", asset.ID)
+		}
+		assets[asset.ID] = true
+	}
+	for _, b := range genesis.Balances {
+		if !assets[b.AssetID] {
+			return fmt.Errorf("genesis balance of %s is in undefined asset %d", b.Address, b.AssetID)
+		}
+		if b.Amount == nil || b.Amount.Sign() <= 0 {
+			return fmt.Errorf("genesis balance of %s in asset %d isn't positive", b.Address, b.AssetID)
+		}
+	}
+	for _, asset := range genesis.supplies() {
+		if err := asset.CheckSupply(); err != nil {
+			return fmt.Errorf("genesis %s", err)
+		}
+	}
+	if policy := genesis.FeePolicy; policy != nil {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("genesis %s", err)
+		}
+		if !assets[policy.AssetID] {
+			return fmt.Errorf("genesis fee policy charges fees in undefined asset %d", policy.AssetID)
+		}
+	}
+	return nil
+}
+
+// supplies returns the genesis assets with their supply, the sum of their genesis balances
+func (genesis *Genesis) supplies() []*state.Asset {
+	supplies := make(map[uint32]*big.Int)
+	for _, b := range genesis.Balances {
+		if supplies[b.AssetID] == nil {
+			supplies[b.AssetID] = new(big.Int)
+		}
+		supplies[b.AssetID].Add(supplies[b.AssetID], b.Amount)
+	}
+
+	assets := make([]*state.Asset, 0, len(genesis.Assets))
+	for _, asset := range genesis.Assets {
+		a := *asset
+		a.TotalSupply = supplies[asset.ID]
+		if a.TotalSupply == nil {
+			a.TotalSupply = new(big.Int)
+		}
+		assets = append(assets, &a)
+	}
+	return assets
+}
+
+// InitGenesis writes the genesis block and initial state of genesis into an empty database
+func InitGenesis(kvdb db.Database, genesis *Genesis) (*pb.BlockHeader, error) {
+	if err := genesis.validate(); err != nil {
+		return nil, err
+	}
+	ledger := &Ledger{
+		dbHandler: kvdb,
+		block:     blockstorage.NewBlockchain(kvdb),
+		state:     state.NewBLKRWSet(kvdb),
+	}
+	if _, err := ledger.Height(); err == nil {
+		return nil, errors.New("the database already holds a chain")
+	}
+	return ledger.initGenesis(genesis)
+}
+
+func (ledger *Ledger) initGenesis(genesis *Genesis) (*pb.BlockHeader, error) {
+	ledger.state.SetBlock(0, 0)
+
+	globalStates := map[string]interface{}{
+		params.AdminKey:          genesis.Admin,
+		params.GlobalContractKey: &vm.ContractCode{Code: []byte(genesis.GlobalContract.Code), Type: genesis.GlobalContract.Type},
+	}
+	if genesis.ChainID != "" {
+		globalStates[params.ChainIDKey] = genesis.ChainID
+	}
+	if len(genesis.Validators) > 0 {
+		globalStates[params.ValidatorsKey] = genesis.Validators
+	}
+	if genesis.FeePolicy != nil {
+		globalStates[params.FeePolicyKey] = genesis.FeePolicy
+	}
+	for key, value := range globalStates {
+		buf, err := state.ConcrateStateJson(value)
+		if err != nil {
+			return nil, err
+		}
+		ledger.state.SetChainCodeState(params.GlobalStateKey, key, buf.Bytes())
+	}
+
+	for _, asset := range genesis.supplies() {
+		ledger.state.SetAssetState(asset.ID, asset)
+	}
+	for _, b := range genesis.Balances {
+		ledger.state.SetBalacneState(b.Address.String(), b.AssetID, b.Amount)
+	}
+
+	writeBatchs, _, _, err := ledger.state.ApplyChanges()
+	if err != nil {
+		return nil, err
+	}
+
+	// genesis block
+	blockHeader := new(pb.BlockHeader)
+	blockHeader.TimeStamp = genesis.Timestamp
+	blockHeader.Nonce = genesis.Nonce
+	blockHeader.Height = 0
+	blockHeader.StateHash = ledger.state.RootHash().String()
+
+	genesisBlock := new(pb.Block)
+	genesisBlock.Header = blockHeader
+	writeBatchs = append(writeBatchs, ledger.block.AppendBlock(genesisBlock)...)
+
+	data, err := json.Marshal(genesis)
+	if err != nil {
+		return nil, err
+	}
+	writeBatchs = append(writeBatchs, db.NewWriteBatch(genesisColumnFamily, db.OperationPut, genesisKey, data, genesisColumnFamily))
+	if err := ledger.dbHandler.AtomicWrite(writeBatchs); err != nil {
+		return nil, err
+	}
+	return blockHeader, nil
+}
+
+// Genesis returns the genesis the chain was initialised with
+func (ledger *Ledger) Genesis() (*Genesis, error) {
+	data, err := ledger.dbHandler.Get(genesisColumnFamily, genesisKey)
+	if err != nil {
+		return nil, err
+	}
+	// chains initialised before the genesis was stored use the default one
+	if data == nil {
+		return DefaultGenesis(), nil
+	}
+	genesis := &Genesis{}
+	if err := json.Unmarshal(data, genesis); err != nil {
+		return nil, err
+	}
+	return genesis, nil
+}
+
+// GetValidators returns the consensus validator list of the genesis
+func (ledger *Ledger) GetValidators() ([]string, error) {
+	value, err := ledger.state.GetChainCodeState(params.GlobalStateKey, params.ValidatorsKey, true)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	data, err := state.DoContractStateData(value)
+	if err != nil {
+		return nil, err
+	}
+	var validators []string
+	if err := json.Unmarshal(data, &validators); err != nil {
+		return nil, err
+	}
+	return validators, nil
+}
@@ -0,0 +1,130 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/vm"
+)
+
+var testGenesis = `{
+	"chainId": "0001",
+	"timestamp": 1500000000,
+	"nonce": 7,
+	"admin": "29763bb368f2d4f62416a1d7a82d16885c206a36",
+	"assets": [
+		{"id": 1, "name": "zip", "precision": 100, "issuer": "29763bb368f2d4f62416a1d7a82d16885c206a36", "owner": "29763bb368f2d4f62416a1d7a82d16885c206a36"}
+	],
+	"balances": [
+		{"address": "a432277be213f56221b6140998c03d860a60e1f8", "assetId": 1, "amount": 1000},
+		{"address": "29763bb368f2d4f62416a1d7a82d16885c206a36", "assetId": 1, "amount": 100000000000000000000000}
+	],
+	"globalContract": {"type": "luavm", "code": "function Init(args) return true end"},
+	"validators": ["node-0", "node-1"]
+}`
+
+func TestGenesis(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+
+	// the default genesis is the one NewLedger writes
+	defaultDb := db.NewMemDB(db.DefaultConfig())
+	header, err := InitGenesis(defaultDb, DefaultGenesis())
+	if err != nil {
+		t.Fatal(err)
+	}
+	li := NewLedger(db.NewMemDB(db.DefaultConfig()))
+	defaultHeader, err := li.GetGenesisBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, header.Hash(), defaultHeader.Hash())
+	if _, err := InitGenesis(defaultDb, DefaultGenesis()); err == nil {
+		t.Error("genesis written over a chain")
+	}
+
+	genesis, err := ReadGenesis(strings.NewReader(testGenesis))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDb := db.NewMemDB(db.DefaultConfig())
+	header, err = InitGenesis(testDb, genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Hash() == defaultHeader.Hash() {
+		t.Error("custom genesis has the default genesis hash")
+	}
+	utils.AssertEquals(t, header.TimeStamp, uint32(1500000000))
+
+	li = NewLedger(testDb)
+	genesisHeader, err := li.GetGenesisBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, genesisHeader.Hash(), header.Hash())
+	b, err := li.GetBalance(backfrontReciepent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, b.Get(1).Int64(), int64(1000))
+	b, err = li.GetBalance(account.HexToAddress("29763bb368f2d4f62416a1d7a82d16885c206a36"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, b.Get(1).String(), "100000000000000000000000")
+	asset, err := li.GetAsset(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, asset.Name, "zip")
+	validators, err := li.GetValidators()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, validators, []string{"node-0", "node-1"})
+
+	stored, err := li.Genesis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, stored.ChainID, "0001")
+	d, err := li.Reexecute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != nil {
+		t.Errorf("custom genesis diverges on re-execution: %+v", d)
+	}
+
+	for _, invalid := range []string{
+		strings.Replace(testGenesis, `"assetId": 1`, `"assetId": 2`, 1),
+		strings.Replace(testGenesis, `"amount": 1000`, `"amount": 0`, 1),
+		strings.Replace(testGenesis, `"chainId": "0001"`, `"chainId": "xyz"`, 1),
+		strings.Replace(testGenesis, `"type": "luavm"`, `"type": ""`, 1),
+		strings.Replace(testGenesis, `"validators"`, `"feePolicy": {"assetId": 2, "pool": "a432277be213f56221b6140998c03d860a60e1f8"}, "validators"`, 1),
+		strings.Replace(testGenesis, `"validators"`, `"feePolicy": {"assetId": 1}, "validators"`, 1),
+	} {
+		if _, err := ReadGenesis(strings.NewReader(invalid)); err == nil {
+			t.Errorf("invalid genesis accepted: %s", invalid)
+		}
+	}
+}
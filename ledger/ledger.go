@@ -19,58 +19,63 @@ package ledger
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/blockchain/event"
 	"github.com/zipper-project/zipper/common/crypto"
 	"github.com/zipper-project/zipper/common/db"
 	"github.com/zipper-project/zipper/common/log"
 	"github.com/zipper-project/zipper/common/mpool"
+	"github.com/zipper-project/zipper/common/trie"
 	"github.com/zipper-project/zipper/common/utils"
 	"github.com/zipper-project/zipper/ledger/balance"
 	"github.com/zipper-project/zipper/ledger/blockstorage"
 	"github.com/zipper-project/zipper/ledger/state"
-	"github.com/zipper-project/zipper/params"
 	pb "github.com/zipper-project/zipper/proto"
 	"github.com/zipper-project/zipper/vm"
 	"github.com/zipper-project/zipper/vm/bsvm"
 )
 
-var (
-	ledgerInstance *Ledger
-)
-
 // Ledger represents the ledger in blockchain
 type Ledger struct {
-	dbHandler *db.BlockchainDB
+	dbHandler db.Database
 	block     *blockstorage.Blockchain
 	state     *state.BLKRWSet
 	mdbChan   chan []*db.WriteBatch
 	vmEnv     map[string]*mpool.VirtualMachine
+	events    *event.Bus
 }
 
-// NewLedger returns the ledger instance
-func NewLedger(kvdb *db.BlockchainDB) *Ledger {
-	if ledgerInstance == nil {
-		ledgerInstance = &Ledger{
-			dbHandler: kvdb,
-			block:     blockstorage.NewBlockchain(kvdb),
-			state:     state.NewBLKRWSet(kvdb),
-		}
-		ledgerInstance.init()
-		_, err := ledgerInstance.Height()
-		if err != nil {
-			log.Error(err)
-		}
-		ledgerInstance.initVmEnv()
+// NewLedger returns a ledger on kvdb
+func NewLedger(kvdb db.Database) *Ledger {
+	ledger := &Ledger{
+		dbHandler: kvdb,
+		block:     blockstorage.NewBlockchain(kvdb),
+		state:     state.NewBLKRWSet(kvdb),
+		events:    event.NewBus(),
+	}
+	if err := ledger.init(); err != nil {
+		log.Error(err)
 	}
-	return ledgerInstance
+	_, err := ledger.Height()
+	if err != nil {
+		log.Error(err)
+	}
+	ledger.initVmEnv()
+	return ledger
 }
 
-func (ledger *Ledger) DBHandler() *db.BlockchainDB {
+func (ledger *Ledger) DBHandler() db.Database {
 	return ledger.dbHandler
 }
 
+// Events returns the bus the ledger publishes committed blocks and state changes on
+func (ledger *Ledger) Events() *event.Bus {
+	return ledger.events
+}
+
 func (ledger *Ledger) initVmEnv() {
 	ledger.vmEnv = make(map[string]*mpool.VirtualMachine)
 	bsWorkers := make([]mpool.VmWorker, vm.VMConf.BsWorkerCnt)
@@ -87,33 +92,38 @@ func (ledger *Ledger) initVmEnv() {
 	addNewEnv("bs", bsWorkers)
 }
 
-// VerifyChain verifys the blockchain data
-func (ledger *Ledger) VerifyChain() {
+// VerifyChain checks that every block header links to the header below it
+func (ledger *Ledger) VerifyChain() error {
 	height, err := ledger.Height()
 	if err != nil {
-		log.Panicf("VerifyChain -- Height %s", err)
+		return err
 	}
 	currentBlockHeader, err := ledger.block.GetBlockByNumber(height)
-	for i := height; i >= 1; i-- {
+	if err != nil {
+		return fmt.Errorf("block [%d] can't be read -- %s", height, err)
+	}
+	// a chain imported from a snapshot starts at the snapshot block
+	for i := height; i > ledger.block.GetBaseHeight(); i-- {
 		previousBlockHeader, err := ledger.block.GetBlockByNumber(i - 1) // storage
-		if previousBlockHeader != nil && err != nil {
-			log.Panicf("VerifyChain -- GetBlockByNumber %s", err)
+		if err != nil {
+			return fmt.Errorf("block [%d] can't be read -- %s", i-1, err)
 		}
 		// verify previous block
 		if previousBlockHeader.Hash().String() != currentBlockHeader.PreviousHash {
-			log.Panicf("VerifyChain -- block [%d] mismatch, veifychain breaks", i)
+			return fmt.Errorf("block [%d] doesn't link to block [%d]", i, i-1)
 		}
 		currentBlockHeader = previousBlockHeader
 	}
+	return nil
 }
 
 // GetGenesisBlock returns the genesis block of the ledger
-func (ledger *Ledger) GetGenesisBlock() *pb.BlockHeader {
+func (ledger *Ledger) GetGenesisBlock() (*pb.BlockHeader, error) {
 	genesisBlockHeader, err := ledger.GetBlockByNumber(0)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("genesis block can't be read -- %s", err)
 	}
-	return genesisBlockHeader
+	return genesisBlockHeader, nil
 }
 
 // AppendBlock appends a new block to the ledger,flag = true pack up block ,flag = false sync block
@@ -122,6 +132,7 @@ func (ledger *Ledger) AppendBlock(block *pb.Block, flag bool) error {
 	//go ledger.Validator.RemoveTxsInVerification(block.Transactions)
 
 	ledger.state.SetBlock(block.GetHeader().GetHeight(), uint32(len(block.Transactions)))
+	ledger.state.SetBlockProposer(block.GetHeader().GetProposer())
 
 	wokerData := func(tx *pb.Transaction, txIdx int) *vm.WorkerProc {
 		return &vm.WorkerProc{
@@ -140,22 +151,61 @@ func (ledger *Ledger) AppendBlock(block *pb.Block, flag bool) error {
 		})
 	}
 
-	writeBatches, oktxs, errtxs, err := ledger.state.ApplyChanges()
+	writeBatches, txs, errtxs, err := ledger.state.ApplyChanges()
 	if err != nil || len(errtxs) != 0 {
-		//TODO
 		log.Errorf("AppendBlock Err: %+v, errtxs: %+v", err, len(errtxs))
 	}
+	if err != nil {
+		return err
+	}
 
 	execTime := time.Now().Sub(startTime)
 	blkHt, _ := ledger.Height()
-	log.Warnf("appendBlock cnt: %+v, oktxs: %+v, errtxs: %+v, blkht: %+v, execTime: %s ...........", len(block.Transactions), len(oktxs), len(errtxs), blkHt, execTime)
+	log.Warnf("appendBlock cnt: %+v, txs: %+v, errtxs: %+v, blkht: %+v, execTime: %s ...........", len(block.Transactions), len(txs), len(errtxs), blkHt, execTime)
 
-	block.Transactions = oktxs
+	// failed transactions stay in the block, their receipts record why they failed
+	block.Transactions = txs
 	block.Header.TxsMerkleHash = merkleRootHash(block.Transactions).String()
 	block.Header.StateHash = ledger.state.RootHash().String()
+	block.Header.ReceiptsRoot = ledger.state.ReceiptsRoot().String()
 	blkWriteBatches := ledger.block.AppendBlock(block)
 	writeBatches = append(writeBatches, blkWriteBatches...)
-	return ledger.dbHandler.AtomicWrite(writeBatches)
+
+	undoBatch, err := ledger.undoJournal(block.GetHeader().GetHeight(), writeBatches)
+	if err != nil {
+		return err
+	}
+	writeBatches = append(writeBatches, undoBatch)
+	if err := ledger.dbHandler.AtomicWrite(writeBatches); err != nil {
+		return err
+	}
+	ledger.publishBlock(block, writeBatches)
+	if log.IsDebug() {
+		ledger.logSupplyMismatches(block.GetHeader().GetHeight())
+	}
+	return nil
+}
+
+// publishBlock publishes a committed block with its receipts and the state keys it wrote
+func (ledger *Ledger) publishBlock(block *pb.Block, writeBatches []*db.WriteBatch) {
+	ledger.events.Publish(&event.BlockCommitted{Block: block, Receipts: ledger.state.Receipts()})
+
+	stateCFs := make(map[string]bool)
+	for _, cf := range ledger.stateCFs() {
+		stateCFs[cf] = true
+	}
+	changed := &event.StateChanged{Height: block.Height()}
+	for _, wb := range writeBatches {
+		if !stateCFs[wb.CfName] {
+			continue
+		}
+		change := &event.StateChange{CfName: wb.CfName, Key: wb.Key}
+		if wb.Operation == db.OperationPut {
+			change.Value = wb.Value
+		}
+		changed.Changes = append(changed.Changes, change)
+	}
+	ledger.events.Publish(changed)
 }
 
 // GetBlockByNumber gets the block by the given number
@@ -236,6 +286,47 @@ func (ledger *Ledger) GetTxByTxHash(txHashBytes []byte) (*pb.Transaction, error)
 	return ledger.block.GetTransactionByTxHash(txHashBytes)
 }
 
+// GetTxProof returns the proof of the transaction against the TxsMerkleHash of its block
+func (ledger *Ledger) GetTxProof(txHash crypto.Hash) (*blockstorage.TxProof, error) {
+	return ledger.block.GetTxProof(txHash.Bytes())
+}
+
+// GetTxsByAddress returns up to limit transactions of assetID sent or received by addr from fromHeight on
+func (ledger *Ledger) GetTxsByAddress(addr account.Address, assetID uint32, fromHeight uint32, limit int) (*blockstorage.TxHistory, error) {
+	return ledger.block.GetTxsByAddress(addr, assetID, blockstorage.TxCursor{Height: fromHeight}, limit)
+}
+
+// GetTxsByAddressCursor continues GetTxsByAddress at the cursor of the previous page
+func (ledger *Ledger) GetTxsByAddressCursor(addr account.Address, assetID uint32, cursor string, limit int) (*blockstorage.TxHistory, error) {
+	from, err := blockstorage.ParseTxCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	return ledger.block.GetTxsByAddress(addr, assetID, from, limit)
+}
+
+// GetReceipt returns the receipt of the transaction
+func (ledger *Ledger) GetReceipt(txHash crypto.Hash) (*state.Receipt, error) {
+	receipt, err := ledger.state.GetReceipt(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, fmt.Errorf("not found receipt of transaction %s", txHash)
+	}
+	return receipt, nil
+}
+
+// GetEvents returns a page of the contract events matching filter in chain order
+func (ledger *Ledger) GetEvents(filter *state.EventFilter) (*state.EventPage, error) {
+	return ledger.state.GetEvents(filter)
+}
+
+// GetContract returns the info and code versions of the contract at addr
+func (ledger *Ledger) GetContract(addr account.Address) (*state.ContractInfo, []*state.ContractVersion, error) {
+	return ledger.state.GetContract(addr.String())
+}
+
 // GetBalance returns balance by account
 func (ledger *Ledger) GetBalance(addr account.Address) (*balance.Balance, error) {
 	return ledger.state.GetBalances(addr.String())
@@ -251,55 +342,114 @@ func (ledger *Ledger) GetAssets() (map[uint32]*state.Asset, error) {
 	return ledger.state.GetAssets()
 }
 
-//QueryContract processes new contract query transaction
-func (ledger *Ledger) QueryContract(tx *pb.Transaction) ([]byte, error) {
-	//return ledger.state.QueryContract(tx)
-	return nil, nil
+// GetNonce returns the next nonce of the committed transactions of addr
+func (ledger *Ledger) GetNonce(addr account.Address) (uint32, error) {
+	return ledger.state.GetNonce(addr.String(), true)
 }
 
-// init generates the genesis block
-func (ledger *Ledger) init() error {
-	// genesis block
-	blockHeader := new(pb.BlockHeader)
-	blockHeader.TimeStamp = uint32(0)
-	blockHeader.Nonce = uint32(100)
-	blockHeader.Height = 0
-
-	genesisBlock := new(pb.Block)
-	genesisBlock.Header = blockHeader
-	writeBatchs := ledger.block.AppendBlock(genesisBlock)
-
-	// admin address
-	buf, err := state.ConcrateStateJson(state.DefaultAdminAddr)
+// GetFeePolicy returns the fee policy, nil if fees are paid to the recipient of a transaction
+func (ledger *Ledger) GetFeePolicy() (*state.FeePolicy, error) {
+	return ledger.state.GetFeePolicy(true)
+}
+
+// GetContractState returns the value of key in the contract state
+func (ledger *Ledger) GetContractState(contractAddr string, key string) ([]byte, error) {
+	return ledger.state.GetChainCodeState(contractAddr, key, true)
+}
+
+func (ledger *Ledger) checkHeight(height uint32) error {
+	current, err := ledger.Height()
 	if err != nil {
 		return err
 	}
+	if height > current {
+		return fmt.Errorf("height %d is above the current height %d", height, current)
+	}
+	return nil
+}
+
+// GetBalanceAt returns the balance of addr as of the block at height
+func (ledger *Ledger) GetBalanceAt(addr account.Address, height uint32) (*balance.Balance, error) {
+	if err := ledger.checkHeight(height); err != nil {
+		return nil, err
+	}
+	return ledger.state.GetBalancesAt(addr.String(), height)
+}
+
+// GetAssetAt returns the asset as of the block at height
+func (ledger *Ledger) GetAssetAt(id uint32, height uint32) (*state.Asset, error) {
+	if err := ledger.checkHeight(height); err != nil {
+		return nil, err
+	}
+	return ledger.state.GetAssetAt(id, height)
+}
+
+// GetContractStateAt returns the value of key in the contract state as of the block at height
+func (ledger *Ledger) GetContractStateAt(contractAddr string, key string, height uint32) ([]byte, error) {
+	if err := ledger.checkHeight(height); err != nil {
+		return nil, err
+	}
+	return ledger.state.GetChainCodeStateAt(contractAddr, key, height)
+}
+
+// GetBalanceProof returns the proof of the balance of addr for assetID against the state of the block at height
+func (ledger *Ledger) GetBalanceProof(addr account.Address, assetID uint32, height uint32) (*state.StateProof, error) {
+	return ledger.getStateProof(height, ledger.state.GetBalanceCF(), state.BalanceKey(addr.String(), assetID))
+}
 
-	writeBatchs = append(writeBatchs,
-		db.NewWriteBatch(ledger.state.GetChainCodeCF(),
-			db.OperationPut,
-			[]byte(state.ConstructCompositeKey(params.GlobalStateKey, params.AdminKey)),
-			buf.Bytes(), ledger.state.GetChainCodeCF()))
+// GetAssetProof returns the proof of the asset against the state of the block at height
+func (ledger *Ledger) GetAssetProof(id uint32, height uint32) (*state.StateProof, error) {
+	return ledger.getStateProof(height, ledger.state.GetAssetCF(), state.AssetKey(id))
+}
+
+// GetContractStateProof returns the proof of the contract state key against the state of the block at height
+func (ledger *Ledger) GetContractStateProof(contractAddr string, key string, height uint32) (*state.StateProof, error) {
+	return ledger.getStateProof(height, ledger.state.GetChainCodeCF(), state.ConstructCompositeKey(contractAddr, key))
+}
 
-	// global contract
-	buf, err = state.ConcrateStateJson(&vm.ContractCode{
-		state.DefaultGlobalContractCode,
-		state.DefaultGlobalContractType,
-	})
+func (ledger *Ledger) getStateProof(height uint32, cf string, key string) (*state.StateProof, error) {
+	header, err := ledger.GetBlockByNumber(height)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	writeBatchs = append(writeBatchs,
-		db.NewWriteBatch(ledger.state.GetChainCodeCF(),
-			db.OperationPut,
-			[]byte(state.ConstructCompositeKey(params.GlobalStateKey, params.GlobalContractKey)),
-			buf.Bytes(), ledger.state.GetChainCodeCF()))
+	kp, err := ledger.state.GetStateProof(crypto.HexToHash(header.StateHash), height, cf, key)
+	if err != nil {
+		if err == trie.ErrMissingNode {
+			return nil, fmt.Errorf("state of block %d isn't committed to a state trie", height)
+		}
+		return nil, err
+	}
+	return &state.StateProof{
+		Height:    height,
+		StateHash: header.StateHash,
+		KeyProof:  kp,
+	}, nil
+}
 
-	err = ledger.dbHandler.AtomicWrite(writeBatchs)
+// QueryContract runs the Query function of the contract a query transaction is addressed to against the committed state.
+// The query doesn't go through consensus, it fails if the contract changes the state or transfers
+func (ledger *Ledger) QueryContract(tx *pb.Transaction) ([]byte, error) {
+	if tx.GetType() != pb.TransactionType_ContractQuery {
+		return nil, fmt.Errorf("transaction of type %s isn't a contract query", tx.GetType())
+	}
+	height, err := ledger.Height()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	wp := &vm.WorkerProc{
+		ContractData: vm.NewContractData(tx),
+		SCHandler:    state.NewQuerySet(ledger.state, tx, height),
+	}
+	return bsvm.NewBsWorker(vm.VMConf, 0).QueryContract(wp)
+}
+
+// init generates the genesis block of the default genesis if the database is empty
+func (ledger *Ledger) init() error {
+	if _, err := ledger.Height(); err == nil {
+		return nil
 	}
+	_, err := ledger.initGenesis(DefaultGenesis())
 	return err
 }
 
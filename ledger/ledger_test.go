@@ -18,13 +18,15 @@ package ledger
 
 import (
 	"encoding/json"
-	"os"
+	"math/big"
 	"testing"
 
 	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/blockchain/event"
 	"github.com/zipper-project/zipper/common/crypto"
 	"github.com/zipper-project/zipper/common/db"
 	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger/state"
 	pb "github.com/zipper-project/zipper/proto"
 	"github.com/zipper-project/zipper/vm"
 )
@@ -43,9 +45,8 @@ var (
 
 func TestExecuteIssueTx(t *testing.T) {
 	vm.VMConf = vm.DefaultConfig()
-	testDb := db.NewDB(db.DefaultConfig())
+	testDb := db.NewMemDB(db.DefaultConfig())
 	li := NewLedger(testDb)
-	defer os.RemoveAll("/tmp/rocksdb-test")
 	//config.ChainID = []byte{byte(0)}
 
 	issueTxKeypair, _ := crypto.GenerateKey()
@@ -57,8 +58,8 @@ func TestExecuteIssueTx(t *testing.T) {
 		addr,
 		issueReciepent,
 		uint32(0),
-		issueAmount,
-		fee,
+		big.NewInt(issueAmount),
+		big.NewInt(fee),
 		utils.CurrentTimestamp())
 	issueCoin := make(map[string]interface{})
 	issueCoin["id"] = 0
@@ -75,6 +76,51 @@ func TestExecuteIssueTx(t *testing.T) {
 	t.Log(li.GetBalance(issueReciepent))
 }
 
+func TestLedgerEvents(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	li := NewLedger(db.NewMemDB(db.DefaultConfig()))
+	blocks := li.Events().Subscribe(1, event.TypeBlockCommitted)
+	changes := li.Events().Subscribe(1, event.TypeStateChanged)
+
+	keypair, _ := crypto.GenerateKey()
+	issueTx := pb.NewTransaction(account.NewChainCoordinate([]byte{byte(0)}),
+		account.NewChainCoordinate([]byte{byte(0)}),
+		pb.TransactionType_Issue,
+		uint32(0),
+		account.PublicKeyToAddress(*keypair.Public()),
+		issueReciepent,
+		uint32(12),
+		big.NewInt(issueAmount),
+		big.NewInt(fee),
+		utils.CurrentTimestamp())
+	issueTx.Payload, _ = json.Marshal(map[string]interface{}{"id": 12})
+	signature, _ := keypair.Sign(issueTx.Hash().Bytes())
+	issueTx.GetHeader().Signature = signature.Bytes()
+
+	if err := li.AppendBlock(&pb.Block{Transactions: []*pb.Transaction{issueTx}, Header: &pb.BlockHeader{Height: 1}}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	committed := (<-blocks.Chan()).(*event.BlockCommitted)
+	utils.AssertEquals(t, committed.Block.Height(), uint32(1))
+	utils.AssertEquals(t, len(committed.Receipts), 1)
+	utils.AssertEquals(t, committed.Receipts[0].TxHash, issueTx.Hash())
+
+	changed := (<-changes.Chan()).(*event.StateChanged)
+	utils.AssertEquals(t, changed.Height, uint32(1))
+	balanceKey := state.BalanceKey(issueReciepent.String(), 12)
+	found := false
+	for _, change := range changed.Changes {
+		if change.CfName == "balance" && string(change.Key) == balanceKey {
+			found = true
+			utils.AssertEquals(t, change.Value, utils.Serialize(issueAmount))
+		}
+	}
+	if !found {
+		t.Errorf("no state change of %s", balanceKey)
+	}
+}
+
 // func TestExecuteAtmoicTx(t *testing.T) {
 
 // 	testDb := db.NewDB(db.DefaultConfig())
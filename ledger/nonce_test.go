@@ -0,0 +1,88 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger/state"
+	pb "github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+func TestNonce(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+
+	keypair, _ := crypto.GenerateKey()
+	sender := account.PublicKeyToAddress(*keypair.Public())
+	genesis := DefaultGenesis()
+	genesis.Assets = []*state.Asset{{ID: 1, Name: "coin"}}
+	genesis.Balances = []*GenesisBalance{{Address: sender, AssetID: 1, Amount: big.NewInt(100)}}
+	testDb := db.NewMemDB(db.DefaultConfig())
+	if _, err := InitGenesis(testDb, genesis); err != nil {
+		t.Fatal(err)
+	}
+	li := NewLedger(testDb)
+
+	first := newFeeTx(keypair, 0, atmoicReciepent, 1, 10, 0)
+	time.Sleep(time.Second)
+	replayed := newFeeTx(keypair, 0, atmoicReciepent, 1, 10, 0)
+	gap := newFeeTx(keypair, 2, atmoicReciepent, 1, 10, 0)
+	overdrawn := newFeeTx(keypair, 1, atmoicReciepent, 1, 1000, 0)
+	blocks := []*pb.Block{
+		{Transactions: []*pb.Transaction{first}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: 1}},
+		{Transactions: []*pb.Transaction{replayed, gap}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: 2}},
+		{Transactions: []*pb.Transaction{overdrawn}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: 3}},
+	}
+	nonces := []uint32{1, 1, 2}
+	for i, block := range blocks {
+		if err := li.AppendBlock(block, true); err != nil {
+			t.Fatal(err)
+		}
+		nonce, err := li.GetNonce(sender)
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.AssertEquals(t, nonce, nonces[i])
+	}
+	for _, tx := range []*pb.Transaction{replayed, gap, overdrawn} {
+		receipt, err := li.GetReceipt(tx.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.AssertEquals(t, receipt.Success, false)
+	}
+	b, err := li.GetBalance(sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, b.Get(1).Int64(), int64(90))
+
+	d, err := li.Reexecute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != nil {
+		t.Errorf("chain diverges on re-execution: %+v", d)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger/state"
+	pb "github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+var queryContract = `
+local ZIP = require("ZIP")
+
+function Init(args)
+    ZIP.PutState("greeting", args[0])
+    return true
+end
+
+function Invoke(func, args)
+    return true
+end
+
+function Query(args)
+    if args[0] == "write" then
+        ZIP.PutState("greeting", "changed")
+    end
+    return ZIP.GetState("greeting")
+end
+`
+
+func newContractTx(tp pb.TransactionType, addr account.Address, code string, args ...string) *pb.Transaction {
+	tx := pb.NewTransaction(account.NewChainCoordinate([]byte{byte(0)}),
+		account.NewChainCoordinate([]byte{byte(0)}),
+		tp,
+		0,
+		issueReciepent,
+		addr,
+		0,
+		big.NewInt(0),
+		big.NewInt(0),
+		utils.CurrentTimestamp())
+	tx.ContractSpec = &pb.ContractSpec{Addr: addr.Bytes(), Code: []byte(code), Params: args}
+	return tx
+}
+
+func TestQueryContract(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	li := NewLedger(db.NewMemDB(db.DefaultConfig()))
+	addr := account.HexToAddress("0xc032277be213f56221b6140998c03d860a60e1f8")
+
+	deploy := newContractTx(pb.TransactionType_LuaContractInit, addr, queryContract, "hello")
+	if err := li.AppendBlock(&pb.Block{Transactions: []*pb.Transaction{deploy}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: 1}}, true); err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := li.GetReceipt(deploy.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, receipt.Success, true)
+
+	result, err := li.QueryContract(newContractTx(pb.TransactionType_ContractQuery, addr, "", "read"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, string(result), "hello")
+
+	if _, err := li.QueryContract(newContractTx(pb.TransactionType_ContractQuery, addr, "", "write")); err != state.ErrReadOnly {
+		t.Errorf("query writing the state returned %v", err)
+	}
+	if _, err := li.QueryContract(newContractTx(pb.TransactionType_ContractInvoke, addr, "")); err == nil {
+		t.Error("invoke transaction queried")
+	}
+}
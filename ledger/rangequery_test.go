@@ -0,0 +1,99 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	pb "github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+var listContract = `
+local ZIP = require("ZIP")
+
+local function list(records, next)
+    local items = {}
+    for i, record in ipairs(records) do
+        items[i] = record.key .. "=" .. record.value
+    end
+    return table.concat(items, ",") .. ";" .. (next or "")
+end
+
+function Init(args)
+    for _, key in ipairs({"item:d", "item:a", "item:c", "item:b", "other"}) do
+        ZIP.PutState(key, string.sub(key, -1))
+    end
+    return true
+end
+
+function Invoke(func, args)
+    ZIP.PutState("item:bb", "x")
+    ZIP.DelState("item:c")
+    ZIP.PutState("listing", list(ZIP.GetByRange("item:", "item:z")))
+    return true
+end
+
+function Query(args)
+    if args[0] == "listing" then
+        return ZIP.GetState("listing")
+    end
+    if args[0] == "range" then
+        return list(ZIP.GetByRange(args[1], args[2], 2))
+    end
+    return list(ZIP.GetByPrefix("item:", 2, args[1]))
+end
+`
+
+func TestContractRangeQuery(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	li := NewLedger(db.NewMemDB(db.DefaultConfig()))
+	addr := account.HexToAddress("0xc132277be213f56221b6140998c03d860a60e1f8")
+
+	query := func(args ...string) string {
+		result, err := li.QueryContract(newContractTx(pb.TransactionType_ContractQuery, addr, "", args...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(result)
+	}
+
+	deploy := newContractTx(pb.TransactionType_LuaContractInit, addr, listContract)
+	if err := li.AppendBlock(&pb.Block{Transactions: []*pb.Transaction{deploy}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: 1}}, true); err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, query("prefix", ""), "item:a=a,item:b=b;item:c")
+	utils.AssertEquals(t, query("prefix", "item:c"), "item:c=c,item:d=d;")
+	utils.AssertEquals(t, query("range", "item:b", "other"), "item:b=b,item:c=c;item:d")
+
+	// the uncommitted changes of the invocation are merged into its listing
+	invoke := newContractTx(pb.TransactionType_ContractInvoke, addr, "", "list")
+	invoke.Header.Nonce = 1
+	if err := li.AppendBlock(&pb.Block{Transactions: []*pb.Transaction{invoke}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: 2}}, true); err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := li.GetReceipt(invoke.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, receipt.Success, true)
+	utils.AssertEquals(t, query("prefix", "item:b"), "item:b=b,item:bb=x;item:d")
+	utils.AssertEquals(t, query("listing"), "item:a=a,item:b=b,item:bb=x,item:d=d;")
+}
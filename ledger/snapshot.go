@@ -0,0 +1,369 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger/blockstorage"
+	"github.com/zipper-project/zipper/ledger/state"
+	pb "github.com/zipper-project/zipper/proto"
+)
+
+// snapshot file layout: magic, version, then length prefixed records (the meta, the block, the genesis block header,
+// the genesis, the state entries and an empty record ending them) followed by the sha256 of everything before
+const (
+	snapshotMagic   = "ZIPSNAP"
+	snapshotVersion = 2
+
+	maxRecordSize = 64 << 20
+)
+
+var (
+	// ErrSnapshotChecksum is returned when a snapshot file is corrupt
+	ErrSnapshotChecksum = errors.New("snapshot checksum mismatch")
+	// ErrSnapshotStateMismatch is returned when the state of a snapshot doesn't match the state root of its block
+	ErrSnapshotStateMismatch = errors.New("snapshot state doesn't match the state hash of its block")
+)
+
+type snapshotMeta struct {
+	Height      uint32      `enc:"1"`
+	BlockHash   crypto.Hash `enc:"2"`
+	GenesisHash crypto.Hash `enc:"3"`
+}
+
+// snapshot is the content of a snapshot file
+type snapshot struct {
+	block   *pb.Block
+	genesis *pb.BlockHeader
+	config  []byte // the genesis the chain was initialised with as JSON
+	states  []*db.WriteBatch
+}
+
+type snapshotEntry struct {
+	CfName string `enc:"1"`
+	Key    []byte `enc:"2"`
+	Value  []byte `enc:"3"`
+}
+
+type recordWriter struct {
+	w   io.Writer
+	sum hash.Hash
+}
+
+func (sw *recordWriter) write(data []byte) error {
+	sw.sum.Write(data)
+	_, err := sw.w.Write(data)
+	return err
+}
+
+func (sw *recordWriter) writeRecord(data []byte) error {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	if err := sw.write(size[:]); err != nil {
+		return err
+	}
+	return sw.write(data)
+}
+
+type recordReader struct {
+	r   io.Reader
+	sum hash.Hash
+}
+
+func (sr *recordReader) read(n int) ([]byte, error) {
+	data := make([]byte, n)
+	if _, err := io.ReadFull(sr.r, data); err != nil {
+		return nil, err
+	}
+	sr.sum.Write(data)
+	return data, nil
+}
+
+func (sr *recordReader) readRecord() ([]byte, error) {
+	size, err := sr.read(4)
+	if err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size)
+	if n > maxRecordSize {
+		return nil, fmt.Errorf("record of %d bytes is too large", n)
+	}
+	return sr.read(int(n))
+}
+
+// stateCFs returns the column families a snapshot carries
+func (ledger *Ledger) stateCFs() []string {
+	return []string{ledger.state.GetBalanceCF(), ledger.state.GetAssetCF(), ledger.state.GetChainCodeCF()}
+}
+
+// priorState returns, per column family, the values the keys written above height had at height
+func (ledger *Ledger) priorState(height, current uint32) (map[string]map[string]*undoEntry, error) {
+	prior := make(map[string]map[string]*undoEntry)
+	for h := current; h > height; h-- {
+		data, err := ledger.dbHandler.Get(undoColumnFamily, utils.Uint32ToBytes(h))
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			return nil, fmt.Errorf("block %d has no undo journal", h)
+		}
+		var entries []*undoEntry
+		if err := utils.Deserialize(data, &entries); err != nil {
+			return nil, fmt.Errorf("block %d has a corrupt undo journal -- %s", h, err)
+		}
+
+		// lower blocks overwrite higher ones, so the value before the first change above height wins
+		for _, entry := range entries {
+			if prior[entry.CfName] == nil {
+				prior[entry.CfName] = make(map[string]*undoEntry)
+			}
+			prior[entry.CfName][string(entry.Key)] = entry
+		}
+	}
+	return prior, nil
+}
+
+// forEachStateAt calls fn with the balance, asset and contract state at height, column family by column family.
+// The state of a past block is rebuilt from the undo journals of the blocks above it
+func (ledger *Ledger) forEachStateAt(height, current uint32, fn func(cf string, key, value []byte) error) error {
+	prior, err := ledger.priorState(height, current)
+	if err != nil {
+		return err
+	}
+
+	for _, cf := range ledger.stateCFs() {
+		changed := prior[cf]
+		iter := ledger.dbHandler.NewIterator(cf, nil, nil)
+		for iter.Next() {
+			value := iter.Value()
+			if entry, ok := changed[string(iter.Key())]; ok {
+				delete(changed, string(iter.Key()))
+				if !entry.Exists {
+					continue
+				}
+				value = entry.Value
+			}
+			if err := fn(cf, iter.Key(), value); err != nil {
+				iter.Release()
+				return err
+			}
+		}
+		iter.Release()
+
+		// keys deleted above height
+		keys := make([]string, 0, len(changed))
+		for key, entry := range changed {
+			if entry.Exists {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := fn(cf, []byte(key), changed[key].Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ExportSnapshot writes the balance, asset and contract state at height with the block at height to w
+func (ledger *Ledger) ExportSnapshot(w io.Writer, height uint32) error {
+	current, err := ledger.Height()
+	if err != nil {
+		return err
+	}
+	if height > current {
+		return fmt.Errorf("snapshot height %d is above the current height %d", height, current)
+	}
+
+	header, err := ledger.block.GetBlockByNumber(height)
+	if err != nil {
+		return err
+	}
+	txs, err := ledger.block.GetTransactionsByNumber(height, 100)
+	if err != nil {
+		return err
+	}
+	genesis, err := ledger.block.GetBlockByNumber(0)
+	if err != nil {
+		return err
+	}
+	g, err := ledger.Genesis()
+	if err != nil {
+		return err
+	}
+	config, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+
+	sw := &recordWriter{w: w, sum: sha256.New()}
+	if err := sw.write(append([]byte(snapshotMagic), snapshotVersion)); err != nil {
+		return err
+	}
+	if err := sw.writeRecord(utils.Serialize(&snapshotMeta{Height: height, BlockHash: header.Hash(), GenesisHash: genesis.Hash()})); err != nil {
+		return err
+	}
+	block := &pb.Block{Header: header, Transactions: txs}
+	if err := sw.writeRecord(block.Serialize()); err != nil {
+		return err
+	}
+	if err := sw.writeRecord(genesis.Serialize()); err != nil {
+		return err
+	}
+	if err := sw.writeRecord(config); err != nil {
+		return err
+	}
+
+	err = ledger.forEachStateAt(height, current, func(cf string, key, value []byte) error {
+		return sw.writeRecord(utils.Serialize(&snapshotEntry{CfName: cf, Key: key, Value: value}))
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := sw.writeRecord(nil); err != nil {
+		return err
+	}
+	_, err = w.Write(sw.sum.Sum(nil))
+	return err
+}
+
+// readSnapshot reads and checks the blocks, genesis and state entries of a snapshot
+func readSnapshot(r io.Reader) (*snapshot, error) {
+	sr := &recordReader{r: r, sum: sha256.New()}
+	magic, err := sr.read(len(snapshotMagic) + 1)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(magic[:len(snapshotMagic)], []byte(snapshotMagic)) {
+		return nil, errors.New("not a snapshot file")
+	}
+	if magic[len(snapshotMagic)] != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", magic[len(snapshotMagic)])
+	}
+
+	data, err := sr.readRecord()
+	if err != nil {
+		return nil, err
+	}
+	meta := &snapshotMeta{}
+	if err := utils.Deserialize(data, meta); err != nil {
+		return nil, err
+	}
+	if data, err = sr.readRecord(); err != nil {
+		return nil, err
+	}
+	snap := &snapshot{block: &pb.Block{}, genesis: &pb.BlockHeader{}}
+	if err := snap.block.Deserialize(data); err != nil {
+		return nil, err
+	}
+	if snap.block.Header == nil || snap.block.Hash() != meta.BlockHash || snap.block.Height() != meta.Height {
+		return nil, fmt.Errorf("snapshot block isn't block %d %s", meta.Height, meta.BlockHash)
+	}
+	if data, err = sr.readRecord(); err != nil {
+		return nil, err
+	}
+	if err := snap.genesis.Deserialize(data); err != nil {
+		return nil, err
+	}
+	if snap.genesis.Height != 0 || snap.genesis.Hash() != meta.GenesisHash || (meta.Height == 0 && meta.GenesisHash != meta.BlockHash) {
+		return nil, fmt.Errorf("snapshot genesis block isn't block 0 %s", meta.GenesisHash)
+	}
+	if snap.config, err = sr.readRecord(); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(snap.config, &Genesis{}); err != nil {
+		return nil, fmt.Errorf("snapshot genesis is invalid -- %s", err)
+	}
+
+	for {
+		data, err := sr.readRecord()
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			break
+		}
+		entry := &snapshotEntry{}
+		if err := utils.Deserialize(data, entry); err != nil {
+			return nil, err
+		}
+		snap.states = append(snap.states, db.NewWriteBatch(entry.CfName, db.OperationPut, entry.Key, entry.Value, entry.CfName))
+	}
+
+	expected := sr.sum.Sum(nil)
+	checksum := make([]byte, len(expected))
+	if _, err := io.ReadFull(r, checksum); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(checksum, expected) {
+		return nil, ErrSnapshotChecksum
+	}
+	return snap, nil
+}
+
+// ImportSnapshot writes the snapshot read from r into an empty database, its block becomes the first block of the chain
+// above the genesis block. The state is rejected if its state trie root doesn't match the state hash of the block, unless allowUnverified is set
+// for blocks appended before the state hash was a state trie root
+func ImportSnapshot(kvdb db.Database, r io.Reader, allowUnverified bool) (*pb.BlockHeader, error) {
+	blockchain := blockstorage.NewBlockchain(kvdb)
+	if height, err := blockchain.GetBlockchainHeight(); err == nil {
+		return nil, fmt.Errorf("database isn't empty, it has blocks up to %d", height)
+	}
+
+	snap, err := readSnapshot(r)
+	if err != nil {
+		return nil, err
+	}
+	block, states := snap.block, snap.states
+	blk := state.NewBLKRWSet(kvdb)
+	trieBatches, root, err := blk.BuildStateTrie(states)
+	if err != nil {
+		return nil, err
+	}
+	if root.String() != block.Header.StateHash && !allowUnverified {
+		return nil, ErrSnapshotStateMismatch
+	}
+
+	writeBatches := append(states, trieBatches...)
+	writeBatches = append(writeBatches, blk.HistoryBatches(states, block.Height())...)
+	// the genesis block goes first, the snapshot block sets the height of the chain
+	if block.Height() > 0 {
+		writeBatches = append(writeBatches, blockchain.AppendBlock(&pb.Block{Header: snap.genesis})...)
+	}
+	writeBatches = append(writeBatches, db.NewWriteBatch(genesisColumnFamily, db.OperationPut, genesisKey, snap.config, genesisColumnFamily))
+	writeBatches = append(writeBatches, blockchain.AppendBlock(block)...)
+	writeBatches = append(writeBatches, blockchain.BaseHeightBatch(block.Height()))
+	if err := kvdb.AtomicWrite(writeBatches); err != nil {
+		return nil, err
+	}
+	return block.Header, nil
+}
@@ -0,0 +1,165 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger/state"
+	pb "github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+func snapshotBalance(t *testing.T, states []*db.WriteBatch, key string) int64 {
+	for _, wb := range states {
+		if wb.CfName == "balance" && string(wb.Key) == key {
+			var amount int64
+			if err := utils.Deserialize(wb.Value, &amount); err != nil {
+				t.Fatal(err)
+			}
+			return amount
+		}
+	}
+	return 0
+}
+
+func TestSnapshot(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	testDb := db.NewMemDB(db.DefaultConfig())
+	li := NewLedger(testDb)
+
+	height, err := li.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keypair, _ := crypto.GenerateKey()
+	issueTx := pb.NewTransaction(account.NewChainCoordinate([]byte{byte(0)}),
+		account.NewChainCoordinate([]byte{byte(0)}),
+		pb.TransactionType_Issue,
+		uint32(0),
+		account.PublicKeyToAddress(*keypair.Public()),
+		backfrontReciepent,
+		uint32(8),
+		big.NewInt(issueAmount),
+		big.NewInt(fee),
+		utils.CurrentTimestamp())
+	issueTx.Payload, _ = json.Marshal(map[string]interface{}{"id": 8})
+	signature, _ := keypair.Sign(issueTx.Hash().Bytes())
+	issueTx.GetHeader().Signature = signature.Bytes()
+
+	for i := uint32(1); i <= 2; i++ {
+		txs := []*pb.Transaction{}
+		if i == 2 {
+			txs = append(txs, issueTx)
+		}
+		if err := li.AppendBlock(&pb.Block{Transactions: txs, Header: &pb.BlockHeader{Height: height + i}}, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	balanceKey := state.BalanceKey(backfrontReciepent.String(), 8)
+	for i, expected := range []int64{0, issueAmount} {
+		header, err := li.GetBlockByNumber(height + 1 + uint32(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buf := new(bytes.Buffer)
+		if err := li.ExportSnapshot(buf, header.Height); err != nil {
+			t.Fatal(err)
+		}
+		snap, err := readSnapshot(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.AssertEquals(t, snap.block.Hash(), header.Hash())
+		utils.AssertEquals(t, snapshotBalance(t, snap.states, balanceKey), expected)
+
+		_, root, err := li.state.BuildStateTrie(snap.states)
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.AssertEquals(t, root.String(), header.StateHash)
+
+		data := buf.Bytes()
+		data[len(data)/2] ^= 0xff
+		if _, err := readSnapshot(bytes.NewReader(data)); err == nil {
+			t.Error("corrupt snapshot accepted")
+		}
+	}
+
+	if err := li.ExportSnapshot(new(bytes.Buffer), height+3); err == nil {
+		t.Error("snapshot above the current height exported")
+	}
+	if _, err := ImportSnapshot(testDb, new(bytes.Buffer), false); err == nil {
+		t.Error("snapshot imported into a database that isn't empty")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := li.ExportSnapshot(buf, height+2); err != nil {
+		t.Fatal(err)
+	}
+	importDb := db.NewMemDB(db.DefaultConfig())
+	header, err := ImportSnapshot(importDb, buf, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported := NewLedger(importDb)
+	if err := imported.VerifyChain(); err != nil {
+		t.Fatal(err)
+	}
+	importedHeight, err := imported.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, importedHeight, height+2)
+	utils.AssertEquals(t, imported.block.GetBaseHeight(), height+2)
+	lastHash, err := imported.GetLastBlockHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, lastHash, header.Hash())
+	b, err := imported.GetBalance(backfrontReciepent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, b.Get(8).Int64(), issueAmount)
+
+	// the imported chain keeps the genesis block and genesis of the exported one
+	genesis, err := imported.GetGenesisBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceGenesis, err := li.GetGenesisBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, genesis.Hash(), sourceGenesis.Hash())
+	g, err := imported.Genesis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, g, DefaultGenesis())
+}
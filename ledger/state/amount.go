@@ -0,0 +1,49 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package state
+
+import (
+	"math/big"
+
+	"github.com/zipper-project/zipper/common/utils"
+)
+
+// EncodeAmount returns the state value of a balance. Amounts in the int64 range keep the int64 encoding,
+// so the state written before balances had arbitrary precision hashes the same
+func EncodeAmount(amount *big.Int) []byte {
+	if amount.IsInt64() {
+		return utils.Serialize(amount.Int64())
+	}
+	return utils.Serialize(amount)
+}
+
+// DecodeAmount decodes the state value of a balance, a nil value is a zero balance
+func DecodeAmount(value []byte) (*big.Int, error) {
+	if value == nil {
+		return new(big.Int), nil
+	}
+	// the encoding of a big.Int never decodes as an int64, its sign byte is followed by the length
+	var amount int64
+	if err := utils.Deserialize(value, &amount); err == nil {
+		return big.NewInt(amount), nil
+	}
+	v := new(big.Int)
+	if err := utils.Deserialize(value, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
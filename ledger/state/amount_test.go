@@ -0,0 +1,46 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/zipper-project/zipper/common/utils"
+)
+
+func TestAmountEncoding(t *testing.T) {
+	large, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	for _, amount := range []*big.Int{big.NewInt(0), big.NewInt(10000), big.NewInt(-1), large} {
+		v, err := DecodeAmount(EncodeAmount(amount))
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.AssertEquals(t, v.String(), amount.String())
+	}
+
+	// balances written as int64 decode unchanged and keep their encoding
+	utils.AssertEquals(t, EncodeAmount(big.NewInt(10000)), utils.Serialize(int64(10000)))
+	v, err := DecodeAmount(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, v.Sign(), 0)
+	if _, err := DecodeAmount(utils.Serialize("amount")); err == nil {
+		t.Error("invalid amount decoded")
+	}
+}
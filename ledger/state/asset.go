@@ -20,20 +20,25 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math/big"
 
 	"github.com/zipper-project/zipper/account"
+	pb "github.com/zipper-project/zipper/proto"
 )
 
 //Asset Attributes
 type Asset struct {
-	ID         uint32 `json:"id"`         // id
-	Name       string `json:"name"`       // name
-	Descr      string `json:"descr"`      // description
-	Precision  uint64 `json:"precision"`  // divisible, precision
-	Expiration uint32 `json:"expiration"` // expriation datetime
-
-	Issuer account.Address `json:"issuer"` // issuer address
-	Owner  account.Address `json:"owner"`  // owner address
+	ID         uint32 `json:"id" enc:"1"`         // id
+	Name       string `json:"name" enc:"2"`       // name
+	Descr      string `json:"descr" enc:"3"`      // description
+	Precision  uint64 `json:"precision" enc:"4"`  // divisible, precision
+	Expiration uint32 `json:"expiration" enc:"5"` // expriation datetime
+
+	Issuer account.Address `json:"issuer" enc:"6"` // issuer address
+	Owner  account.Address `json:"owner" enc:"7"`  // owner address
+
+	MaxSupply   *big.Int `json:"maxSupply" enc:"8"`   // supply cap, unlimited if nil
+	TotalSupply *big.Int `json:"totalSupply" enc:"9"` // minted less burned, nil if issued before supply tracking
 }
 
 //Update update asset
@@ -47,12 +52,17 @@ func (asset *Asset) Update(jsonStr string) (*Asset, error) {
 		return nil, fmt.Errorf("asset update failed: invalid json string for asset - %s", err)
 	}
 
+	// numbers are kept as they are written, supplies don't fit a float64
 	var newVal map[string]interface{}
-	json.Unmarshal([]byte(jsonStr), &newVal)
+	decoder := json.NewDecoder(bytes.NewReader([]byte(jsonStr)))
+	decoder.UseNumber()
+	decoder.Decode(&newVal)
 
 	oldJSONAStr, _ := json.Marshal(asset)
 	var oldVal map[string]interface{}
-	json.Unmarshal(oldJSONAStr, &oldVal)
+	decoder = json.NewDecoder(bytes.NewReader(oldJSONAStr))
+	decoder.UseNumber()
+	decoder.Decode(&oldVal)
 
 	for k, val := range newVal {
 		if _, ok := oldVal[k]; ok {
@@ -69,5 +79,79 @@ func (asset *Asset) Update(jsonStr string) (*Asset, error) {
 		!bytes.Equal(asset.Owner.Bytes(), newAsset.Owner.Bytes()) {
 		return nil, fmt.Errorf("asset update failed: id, issuer, owner are readonly attribute, can't modified -- %#v to %#v", asset, newAsset)
 	}
+	newAsset.TotalSupply = asset.TotalSupply
+	if err := newAsset.CheckSupply(); err != nil {
+		return nil, fmt.Errorf("asset update failed: %s", err)
+	}
 	return newAsset, nil
 }
+
+// CheckSupply returns an error if the max supply is negative or the supply exceeds it
+func (asset *Asset) CheckSupply() error {
+	if asset.MaxSupply == nil {
+		return nil
+	}
+	if asset.MaxSupply.Sign() < 0 {
+		return fmt.Errorf("max supply of asset %d is negative", asset.ID)
+	}
+	if asset.TotalSupply != nil && asset.TotalSupply.Cmp(asset.MaxSupply) > 0 {
+		return fmt.Errorf("supply %s of asset %d exceeds the max supply %s", asset.TotalSupply, asset.ID, asset.MaxSupply)
+	}
+	return nil
+}
+
+// Mint returns the asset with amount added to the supply, it fails if the supply would exceed the max supply
+func (asset *Asset) Mint(amount *big.Int) (*Asset, error) {
+	if asset.TotalSupply == nil {
+		return nil, fmt.Errorf("asset %d was issued before supply tracking", asset.ID)
+	}
+	newAsset := *asset
+	newAsset.TotalSupply = new(big.Int).Add(asset.TotalSupply, amount)
+	if err := newAsset.CheckSupply(); err != nil {
+		return nil, err
+	}
+	return &newAsset, nil
+}
+
+// Burn returns the asset with amount removed from the supply
+func (asset *Asset) Burn(amount *big.Int) (*Asset, error) {
+	if asset.TotalSupply == nil {
+		return nil, fmt.Errorf("asset %d was issued before supply tracking", asset.ID)
+	}
+	if asset.TotalSupply.Cmp(amount) < 0 {
+		return nil, fmt.Errorf("burning %s of asset %d exceeds the supply %s", amount, asset.ID, asset.TotalSupply)
+	}
+	newAsset := *asset
+	newAsset.TotalSupply = new(big.Int).Sub(asset.TotalSupply, amount)
+	return &newAsset, nil
+}
+
+// ChangeSupply returns the asset after the mint or burn transaction tx, only the owner of the asset may mint or burn
+func (asset *Asset) ChangeSupply(tx *pb.Transaction) (*Asset, error) {
+	if !bytes.Equal(asset.Owner.Bytes(), tx.Sender().Bytes()) {
+		return nil, fmt.Errorf("%s isn't the owner of asset %d", tx.Sender(), asset.ID)
+	}
+	if tx.GetType() == pb.TransactionType_Burn {
+		return asset.Burn(tx.Amount())
+	}
+	return asset.Mint(tx.Amount())
+}
+
+// TransferAmounts returns the amounts tx takes from the sender and gives to the recipient in the asset of tx.
+// Issue and mint transactions create their amount and burn transactions destroy it.
+// The fee is included unless a fee policy charges it separately
+func TransferAmounts(tx *pb.Transaction, policy *FeePolicy) (debit, credit *big.Int) {
+	fee := tx.Fee()
+	if policy != nil {
+		fee = new(big.Int)
+	}
+	debit = new(big.Int).Add(tx.Amount(), fee)
+	credit = new(big.Int).Add(tx.Amount(), fee)
+	switch tx.GetType() {
+	case pb.TransactionType_Issue, pb.TransactionType_Mint:
+		debit = fee
+	case pb.TransactionType_Burn:
+		credit = fee
+	}
+	return debit, credit
+}
@@ -17,6 +17,7 @@
 package state
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/zipper-project/zipper/account"
@@ -41,3 +42,40 @@ func TestUpdate(t *testing.T) {
 
 	utils.AssertEquals(t, result, asset)
 }
+
+func TestMintBurn(t *testing.T) {
+	asset := &Asset{ID: 1, MaxSupply: big.NewInt(100)}
+	if _, err := asset.Mint(big.NewInt(1)); err == nil {
+		t.Error("minted an asset without a tracked supply")
+	}
+
+	asset.TotalSupply = big.NewInt(60)
+	minted, err := asset.Mint(big.NewInt(40))
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, minted.TotalSupply.String(), "100")
+	utils.AssertEquals(t, asset.TotalSupply.String(), "60")
+	if _, err := minted.Mint(big.NewInt(1)); err == nil {
+		t.Error("minted above the max supply")
+	}
+
+	burned, err := minted.Burn(big.NewInt(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, burned.TotalSupply.Sign(), 0)
+	if _, err := burned.Burn(big.NewInt(1)); err == nil {
+		t.Error("burned more than the supply")
+	}
+
+	if _, err := asset.Update(`{"maxSupply":50}`); err == nil {
+		t.Error("lowered the max supply below the supply")
+	}
+	updated, err := asset.Update(`{"maxSupply":100000000000000000000000,"totalSupply":1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, updated.MaxSupply.String(), "100000000000000000000000")
+	utils.AssertEquals(t, updated.TotalSupply.String(), "60")
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 	"sync"
@@ -28,13 +29,15 @@ import (
 	"github.com/zipper-project/zipper/common/db"
 	"github.com/zipper-project/zipper/common/log"
 	"github.com/zipper-project/zipper/common/treap"
+	"github.com/zipper-project/zipper/common/trie"
 	"github.com/zipper-project/zipper/common/utils"
 	"github.com/zipper-project/zipper/ledger/balance"
+	"github.com/zipper-project/zipper/params"
 	pb "github.com/zipper-project/zipper/proto"
 )
 
 // NewBLKRWSet create object
-func NewBLKRWSet(db *db.BlockchainDB) *BLKRWSet {
+func NewBLKRWSet(db db.Database) *BLKRWSet {
 	return &BLKRWSet{
 		chainCodeSet: NewKVRWSet(),
 		balanceSet:   NewKVRWSet(),
@@ -42,6 +45,10 @@ func NewBLKRWSet(db *db.BlockchainDB) *BLKRWSet {
 		chainCodeCF:  "scontract",
 		balanceCF:    "balance",
 		assetCF:      "asset",
+		stateTrieCF:  "statetrie",
+		receiptCF:    "receipt",
+		historyCF:    "history",
+		eventCF:      "event",
 		dbHandler:    db,
 		exit:         make(chan struct{}, 1),
 	}
@@ -50,6 +57,9 @@ func NewBLKRWSet(db *db.BlockchainDB) *BLKRWSet {
 var assetIDKeyPrefix = "asset"
 var assetIDKeySuffix = "$"
 
+// stateRootKey stores the root of the state trie committed by the last block
+var stateRootKey = []byte("stateRoot")
+
 //BLKRWSet encapsulates the read-write set during transactions of block simulation
 type BLKRWSet struct {
 	chainCodeSet *KVRWSet
@@ -59,18 +69,29 @@ type BLKRWSet struct {
 	assetSet     *KVRWSet
 	assetRW      sync.RWMutex
 
-	dbHandler   *db.BlockchainDB
+	dbHandler   db.Database
 	chainCodeCF string
 	balanceCF   string
 	assetCF     string
+	stateTrieCF string
+	receiptCF   string
+	historyCF   string
+	eventCF     string
+	rootHash    crypto.Hash
+
+	receipts     []*Receipt
+	receiptsRoot crypto.Hash
 
 	txs         pb.Transactions
 	transferTxs pb.Transactions
 	errTxs      pb.Transactions
 
-	BlockIndex uint32
-	TxIndex    uint32
-	curTxIndex uint32
+	BlockIndex    uint32
+	BlockProposer string
+	feePolicy     *FeePolicy
+	feePolicyErr  error
+	TxIndex       uint32
+	curTxIndex    uint32
 
 	waiting   bool
 	waitingRW sync.RWMutex
@@ -95,61 +116,43 @@ func (blk *BLKRWSet) GetChainCodeState(chaincodeAddr string, key string, committ
 	return blk.dbHandler.Get(blk.chainCodeCF, []byte(ckey))
 }
 
-// GetChainCodeStateByRange get state for chaincode address and key. If committed is false, this first looks in memory
-// and if missing, pulls from db.  If committed is true, this pulls from the db only.
-func (blk *BLKRWSet) GetChainCodeStateByRange(chaincodeAddr string, startKey string, endKey string, committed bool) (map[string][]byte, error) {
+// GetChainCodeStates returns the states of chaincode address in r in ascending key order, keyed without the address.
+// If committed is false, the block writes are merged in.  If committed is true, this pulls from the db only.
+func (blk *BLKRWSet) GetChainCodeStates(chaincodeAddr string, r *KeyRange, committed bool) ([]*db.KeyValue, error) {
 	blk.chainCodeRW.RLock()
 	defer blk.chainCodeRW.RUnlock()
-	chaincodePrefix := ConstructCompositeKey(chaincodeAddr, "")
-	ckeyStart := ConstructCompositeKey(chaincodeAddr, startKey)
-	ckeyEnd := ConstructCompositeKey(chaincodeAddr, endKey)
-	ret := make(map[string][]byte)
-	if len(endKey) > 0 {
-		dbValues := blk.dbHandler.GetByRange(blk.chainCodeCF, []byte(ckeyStart), []byte(ckeyEnd))
-		for _, kv := range dbValues {
-			_, key := DecodeCompositeKey(string(kv.Key))
-			ret[key] = kv.Value
-		}
-	} else {
-		dbValues := blk.dbHandler.GetByPrefix(blk.chainCodeCF, []byte(ckeyStart))
-		for _, kv := range dbValues {
-			_, key := DecodeCompositeKey(string(kv.Key))
-			ret[key] = kv.Value
-		}
-	}
+	return blk.chainCodeStates(chaincodeAddr, r, committed), nil
+}
 
+func (blk *BLKRWSet) chainCodeStates(chaincodeAddr string, r *KeyRange, committed bool) []*db.KeyValue {
+	var writes []*db.KeyValue
 	if !committed {
-		cache := treap.NewImmutable()
-		for ckey, kvr := range blk.chainCodeSet.Reads {
-			if strings.HasPrefix(ckey, chaincodePrefix) {
-				cache = cache.Put([]byte(ckey), kvr.Value)
-			}
+		writes = rangeWrites(blk.chainCodeSet, chaincodeAddr, r)
+	}
+	// a write hides at most one stored state, so count plus the writes stored states are enough
+	limit := 0
+	if r.Count > 0 {
+		limit = r.Count + len(writes)
+	}
+
+	prefix := ConstructCompositeKey(chaincodeAddr, "")
+	var states []*db.KeyValue
+	iter := blk.dbHandler.NewIterator(blk.chainCodeCF, []byte(prefix+r.Prefix), []byte(prefix+r.first()))
+	defer iter.Release()
+	for iter.Next() {
+		key := string(iter.Key()[len(prefix):])
+		if !r.Contains(key) {
+			break
 		}
-		for ckey, kvw := range blk.chainCodeSet.Writes {
-			if strings.HasPrefix(ckey, chaincodePrefix) {
-				cache = cache.Put([]byte(ckey), kvw.Value)
-			}
+		if ReservedContractKey(key) {
+			continue
 		}
-		if len(endKey) > 0 {
-			for iter := cache.Iterator([]byte(ckeyStart), []byte(ckeyEnd)); iter.Next(); {
-				if val := iter.Value(); val != nil {
-					_, key := DecodeCompositeKey(string(iter.Key()))
-					ret[key] = val
-				}
-			}
-		} else {
-			for iter := cache.Iterator([]byte(ckeyStart), nil); iter.Next(); {
-				if !bytes.HasPrefix(iter.Key(), []byte(ckeyStart)) {
-					break
-				}
-				if val := iter.Value(); val != nil {
-					_, key := DecodeCompositeKey(string(iter.Key()))
-					ret[key] = val
-				}
-			}
+		states = append(states, &db.KeyValue{Key: []byte(key), Value: append([]byte(nil), iter.Value()...)})
+		if limit > 0 && len(states) == limit {
+			break
 		}
 	}
-	return ret, nil
+	return MergeStates(states, writes, r.Count)
 }
 
 // SetChainCodeState set state to given value for chaincode address and key. Does not immideatly writes to DB
@@ -177,36 +180,32 @@ func (blk *BLKRWSet) DelChainCodeState(chaincodeAddr string, key string) {
 
 // GetBalanceState get balance for address and assetID. If committed is false, this first looks in memory
 // and if missing, pulls from db.  If committed is true, this pulls from the db only.
-func (blk *BLKRWSet) GetBalanceState(addr string, assetID uint32, committed bool) (int64, error) {
+func (blk *BLKRWSet) GetBalanceState(addr string, assetID uint32, committed bool) (*big.Int, error) {
 	blk.balanceRW.RLock()
 	defer blk.balanceRW.RUnlock()
-	var amount int64
-	ckey := ConstructCompositeKey(addr, strconv.FormatUint(uint64(assetID), 10)+assetIDKeySuffix)
+	ckey := BalanceKey(addr, assetID)
 	if !committed {
 		if kvw, ok := blk.balanceSet.Writes[ckey]; ok {
 			if kvw.IsDelete {
-				return 0, nil
+				return new(big.Int), nil
 			}
-			utils.Deserialize(kvw.Value, &amount)
-			return amount, nil
+			return DecodeAmount(kvw.Value)
 		}
 
 		if kvr, ok := blk.balanceSet.Reads[ckey]; ok {
-			utils.Deserialize(kvr.Value, &amount)
-			return amount, nil
+			return DecodeAmount(kvr.Value)
 		}
 	}
 	value, err := blk.dbHandler.Get(blk.balanceCF, []byte(ckey))
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	utils.Deserialize(value, &amount)
-	return amount, nil
+	return DecodeAmount(value)
 }
 
 // GetBalanceStates get balances for address. If committed is false, this first looks in memory
 // and if missing, pulls from db.  If committed is true, this pulls from the db only.
-func (blk *BLKRWSet) GetBalanceStates(addr string, committed bool) (map[uint32]int64, error) {
+func (blk *BLKRWSet) GetBalanceStates(addr string, committed bool) (map[uint32]*big.Int, error) {
 	blk.balanceRW.RLock()
 	defer blk.balanceRW.RUnlock()
 	prefix := ConstructCompositeKey(addr, "")
@@ -238,7 +237,7 @@ func (blk *BLKRWSet) GetBalanceStates(addr string, committed bool) (map[uint32]i
 		}
 	}
 
-	balances := make(map[uint32]int64)
+	balances := make(map[uint32]*big.Int)
 	for k, v := range ret {
 		if v != nil {
 			_, key := DecodeCompositeKey(k)
@@ -246,8 +245,8 @@ func (blk *BLKRWSet) GetBalanceStates(addr string, committed bool) (map[uint32]i
 			if err != nil {
 				return nil, err
 			}
-			var amount int64
-			if err := utils.Deserialize(v, &amount); err != nil {
+			amount, err := DecodeAmount(v)
+			if err != nil {
 				return nil, err
 			}
 			balances[uint32(assetID)] = amount
@@ -256,12 +255,32 @@ func (blk *BLKRWSet) GetBalanceStates(addr string, committed bool) (map[uint32]i
 	return balances, nil
 }
 
+// SumBalances returns the committed balances of all addresses summed per asset
+func (blk *BLKRWSet) SumBalances() (map[uint32]*big.Int, error) {
+	sums := make(map[uint32]*big.Int)
+	iter := blk.dbHandler.NewIterator(blk.balanceCF, nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		_, assetID := decodeBalanceKey(string(iter.Key()))
+		amount, err := DecodeAmount(iter.Value())
+		if err != nil {
+			return nil, fmt.Errorf("balance %s is corrupt -- %s", iter.Key(), err)
+		}
+		if sum, ok := sums[assetID]; ok {
+			sum.Add(sum, amount)
+		} else {
+			sums[assetID] = amount
+		}
+	}
+	return sums, nil
+}
+
 // SetBalacneState set balance to given value for chaincode address and key. Does not immideatly writes to DB
-func (blk *BLKRWSet) SetBalacneState(addr string, assetID uint32, amount int64) error {
+func (blk *BLKRWSet) SetBalacneState(addr string, assetID uint32, amount *big.Int) error {
 	blk.balanceRW.Lock()
 	defer blk.balanceRW.Unlock()
-	value := utils.Serialize(amount)
-	ckey := ConstructCompositeKey(addr, strconv.FormatUint(uint64(assetID), 10)+assetIDKeySuffix)
+	value := EncodeAmount(amount)
+	ckey := BalanceKey(addr, assetID)
 	blk.balanceSet.Writes[ckey] = &KVWrite{
 		Value:    value,
 		IsDelete: false,
@@ -273,7 +292,7 @@ func (blk *BLKRWSet) SetBalacneState(addr string, assetID uint32, amount int64)
 func (blk *BLKRWSet) DelBalanceState(addr string, assetID uint32) {
 	blk.balanceRW.Lock()
 	defer blk.balanceRW.Unlock()
-	ckey := ConstructCompositeKey(addr, strconv.FormatUint(uint64(assetID), 10)+assetIDKeySuffix)
+	ckey := BalanceKey(addr, assetID)
 	blk.balanceSet.Writes[ckey] = &KVWrite{
 		Value:    nil,
 		IsDelete: true,
@@ -286,7 +305,7 @@ func (blk *BLKRWSet) GetAssetState(assetID uint32, committed bool) (*Asset, erro
 	blk.assetRW.RLock()
 	defer blk.assetRW.RUnlock()
 	assetInfo := &Asset{}
-	ckey := ConstructCompositeKey(assetIDKeyPrefix, strconv.FormatUint(uint64(assetID), 10)+assetIDKeySuffix)
+	ckey := AssetKey(assetID)
 	if !committed {
 		if kvw, ok := blk.assetSet.Writes[ckey]; ok {
 			if kvw.IsDelete {
@@ -370,7 +389,7 @@ func (blk *BLKRWSet) SetAssetState(assetID uint32, assetInfo *Asset) error {
 	blk.assetRW.Lock()
 	defer blk.assetRW.Unlock()
 	value := utils.Serialize(assetInfo)
-	ckey := ConstructCompositeKey(assetIDKeyPrefix, strconv.FormatUint(uint64(assetID), 10)+assetIDKeySuffix)
+	ckey := AssetKey(assetID)
 	blk.assetSet.Writes[ckey] = &KVWrite{
 		Value:    value,
 		IsDelete: false,
@@ -382,7 +401,7 @@ func (blk *BLKRWSet) SetAssetState(assetID uint32, assetInfo *Asset) error {
 func (blk *BLKRWSet) DelAssetState(assetID uint32) {
 	blk.assetRW.Lock()
 	defer blk.assetRW.Unlock()
-	ckey := ConstructCompositeKey(assetIDKeyPrefix, strconv.FormatUint(uint64(assetID), 10)+assetIDKeySuffix)
+	ckey := AssetKey(assetID)
 	blk.assetSet.Writes[ckey] = &KVWrite{
 		Value:    nil,
 		IsDelete: true,
@@ -425,13 +444,123 @@ func (blk *BLKRWSet) ApplyChanges() ([]*db.WriteBatch, pb.Transactions, pb.Trans
 		}
 	}
 
+	trieBatchs, err := blk.updateStateTrie()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	writeBatchs = append(writeBatchs, trieBatchs...)
+
+	historyBatchs, err := blk.historyBatches()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	writeBatchs = append(writeBatchs, historyBatchs...)
+
+	// transfers made by contracts are recorded after the transactions of the block,
+	// their state changes belong to the receipt of the contract call
 	errTxs := blk.errTxs
 	txs := blk.txs
 	txs = append(txs, blk.transferTxs...)
+	for range blk.transferTxs {
+		blk.receipts = append(blk.receipts, &Receipt{BlockHeight: blk.BlockIndex, Success: true})
+	}
+	for idx, tx := range txs {
+		blk.receipts[idx].TxHash = tx.Hash()
+		blk.receipts[idx].TxIndex = uint32(idx)
+		for i, e := range blk.receipts[idx].Events {
+			e.BlockHeight, e.TxHash, e.TxIndex, e.Index = blk.BlockIndex, tx.Hash(), uint32(idx), uint32(i)
+		}
+	}
+	blk.receiptsRoot = ReceiptsRoot(blk.receipts)
+	writeBatchs = append(writeBatchs, blk.receiptBatches()...)
+	writeBatchs = append(writeBatchs, blk.eventBatches()...)
 	return writeBatchs, txs, errTxs, nil
 }
 
-func (blk *BLKRWSet) merge(chainCodeSet *KVRWSet, assetSet *KVRWSet, balanceSet *KVRWSet, tx *pb.Transaction, ttxs pb.Transactions, txIndex uint32) error {
+// GetNode returns the state trie node by hash, implements trie.NodeStore
+func (blk *BLKRWSet) GetNode(hash trie.Hash) ([]byte, error) {
+	return blk.dbHandler.Get(blk.stateTrieCF, hash.Bytes())
+}
+
+// CommittedRootHash returns the state root committed by the last block
+func (blk *BLKRWSet) CommittedRootHash() (crypto.Hash, bool, error) {
+	value, err := blk.dbHandler.Get(blk.stateTrieCF, stateRootKey)
+	if err != nil || value == nil {
+		return crypto.Hash{}, false, err
+	}
+	return crypto.NewHash(value), true, nil
+}
+
+// updateStateTrie applies the block write sets to the state trie and returns the new nodes and root.
+// A database written before the state trie existed gets its trie built from the whole state first.
+func (blk *BLKRWSet) updateStateTrie() ([]*db.WriteBatch, error) {
+	root, ok, err := blk.CommittedRootHash()
+	if err != nil {
+		return nil, err
+	}
+
+	t := trie.New(trie.Hash(root), blk)
+	if !ok {
+		for _, cf := range []string{blk.chainCodeCF, blk.assetCF, blk.balanceCF} {
+			for _, kv := range blk.dbHandler.GetByPrefix(cf, nil) {
+				if err := t.Update(trie.KeyHash(cf, kv.Key), trie.ValueHash(kv.Value)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	sets := map[string]*KVRWSet{
+		blk.chainCodeCF: blk.chainCodeSet,
+		blk.assetCF:     blk.assetSet,
+		blk.balanceCF:   blk.balanceSet,
+	}
+	for cf, set := range sets {
+		for ckey, wset := range set.Writes {
+			key := trie.KeyHash(cf, []byte(ckey))
+			if wset.IsDelete {
+				err = t.Delete(key)
+			} else {
+				err = t.Update(key, trie.ValueHash(wset.Value))
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	writeBatchs := make([]*db.WriteBatch, 0)
+	for hash, node := range t.Commit() {
+		writeBatchs = append(writeBatchs, db.NewWriteBatch(blk.stateTrieCF, db.OperationPut, hash.Bytes(), node, blk.stateTrieCF))
+	}
+	blk.rootHash = crypto.Hash(t.Root())
+	writeBatchs = append(writeBatchs, db.NewWriteBatch(blk.stateTrieCF, db.OperationPut, stateRootKey, blk.rootHash.Bytes(), blk.stateTrieCF))
+	return writeBatchs, nil
+}
+
+// BuildStateTrie builds the state trie of a whole state given as put batches of the state column families,
+// and returns the write batches committing it with its root
+func (blk *BLKRWSet) BuildStateTrie(states []*db.WriteBatch) ([]*db.WriteBatch, crypto.Hash, error) {
+	t := trie.New(trie.Hash{}, blk)
+	for _, wb := range states {
+		if wb.CfName != blk.chainCodeCF && wb.CfName != blk.assetCF && wb.CfName != blk.balanceCF {
+			return nil, crypto.Hash{}, fmt.Errorf("%s isn't a state column family", wb.CfName)
+		}
+		if err := t.Update(trie.KeyHash(wb.CfName, wb.Key), trie.ValueHash(wb.Value)); err != nil {
+			return nil, crypto.Hash{}, err
+		}
+	}
+
+	writeBatchs := make([]*db.WriteBatch, 0)
+	for hash, node := range t.Commit() {
+		writeBatchs = append(writeBatchs, db.NewWriteBatch(blk.stateTrieCF, db.OperationPut, hash.Bytes(), node, blk.stateTrieCF))
+	}
+	root := crypto.Hash(t.Root())
+	writeBatchs = append(writeBatchs, db.NewWriteBatch(blk.stateTrieCF, db.OperationPut, stateRootKey, root.Bytes(), blk.stateTrieCF))
+	return writeBatchs, root, nil
+}
+
+func (blk *BLKRWSet) merge(chainCodeSet *KVRWSet, assetSet *KVRWSet, balanceSet *KVRWSet, tx *pb.Transaction, ttxs pb.Transactions, txIndex uint32, execErr error, result interface{}, fee *FeeCharge, nonce []byte, events []*ContractEvent) error {
 	blk.chainCodeRW.Lock()
 	defer blk.chainCodeRW.Unlock()
 	blk.assetRW.Lock()
@@ -440,8 +569,23 @@ func (blk *BLKRWSet) merge(chainCodeSet *KVRWSet, assetSet *KVRWSet, balanceSet
 	defer blk.balanceRW.Unlock()
 
 	if chainCodeSet == nil && assetSet == nil && balanceSet == nil && ttxs == nil {
+		if execErr == nil {
+			execErr = errors.New("transaction execution failed")
+		}
 		blk.errTxs = append(blk.errTxs, tx)
+		blk.txs = append(blk.txs, tx)
+		blk.receipts = append(blk.receipts, blk.newReceipt(execErr, result, nil, nil, nil, nil))
+		if nonce != nil {
+			// a failed transaction still uses up its nonce
+			blk.chainCodeSet.Writes[ConstructCompositeKey(params.NonceStateKey, tx.Sender().String())] = &KVWrite{Value: nonce, txIndex: txIndex}
+		}
 	} else {
+		for _, rr := range chainCodeSet.RangeReads {
+			if !sameStates(rr.States, blk.chainCodeStates(rr.ChaincodeAddr, &rr.Range, false)) {
+				return fmt.Errorf("chaincode range read conflict -- %s %q", rr.ChaincodeAddr, rr.Range.first())
+			}
+		}
+
 		for ckey, rset := range chainCodeSet.Reads {
 			if trset, ok := blk.chainCodeSet.Reads[ckey]; ok {
 				if bytes.Compare(trset.Value, rset.Value) != 0 {
@@ -469,15 +613,22 @@ func (blk *BLKRWSet) merge(chainCodeSet *KVRWSet, assetSet *KVRWSet, balanceSet
 			}
 		}
 
+		receipt := blk.newReceipt(nil, result, chainCodeSet, assetSet, balanceSet, fee)
+		receipt.Events = events
+		blk.receipts = append(blk.receipts, receipt)
+
 		for ckey, wset := range chainCodeSet.Writes {
+			wset.txIndex = txIndex
 			blk.chainCodeSet.Writes[ckey] = wset
 		}
 
 		for ckey, wset := range assetSet.Writes {
+			wset.txIndex = txIndex
 			blk.assetSet.Writes[ckey] = wset
 		}
 
 		for ckey, wset := range balanceSet.Writes {
+			wset.txIndex = txIndex
 			blk.balanceSet.Writes[ckey] = wset
 		}
 		blk.transferTxs = append(blk.transferTxs, ttxs...)
@@ -508,6 +659,18 @@ func (blk *BLKRWSet) GetBalanceCF() string {
 	return blk.balanceCF
 }
 
+func (blk *BLKRWSet) GetStateTrieCF() string {
+	return blk.stateTrieCF
+}
+
+func (blk *BLKRWSet) GetReceiptCF() string {
+	return blk.receiptCF
+}
+
+func (blk *BLKRWSet) GetHistoryCF() string {
+	return blk.historyCF
+}
+
 func (blk *BLKRWSet) ComplexQuery(key string) ([]byte, error) {
 	return nil, errors.New("vp can't support complex qery")
 }
@@ -552,12 +715,28 @@ func (blk *BLKRWSet) SetBlock(blkIndex, txNum uint32) {
 	blk.txs = nil
 	blk.errTxs = nil
 	blk.transferTxs = nil
+	blk.rootHash = crypto.Hash{}
+	blk.receipts = nil
+	blk.receiptsRoot = crypto.Hash{}
+	blk.feePolicy, blk.feePolicyErr = blk.GetFeePolicy(true)
 }
 
+// SetBlockProposer sets the replica ID of the block proposer, the fee policy may credit the fees of the block to it
+func (blk *BLKRWSet) SetBlockProposer(proposer string) {
+	blk.BlockProposer = proposer
+}
+
+// RootHash returns the root of the state trie after the changes of the block are applied
 func (blk *BLKRWSet) RootHash() crypto.Hash {
-	hashs := make([]crypto.Hash, 3)
-	hashs[0] = crypto.DoubleSha256(utils.Serialize(blk.chainCodeSet))
-	hashs[1] = crypto.DoubleSha256(utils.Serialize(blk.assetSet))
-	hashs[2] = crypto.DoubleSha256(utils.Serialize(blk.balanceSet))
-	return crypto.ComputeMerkleHash(hashs)[0]
+	return blk.rootHash
+}
+
+// Receipts returns the receipts of the transactions of the block after the changes are applied
+func (blk *BLKRWSet) Receipts() []*Receipt {
+	return blk.receipts
+}
+
+// ReceiptsRoot returns the merkle root of the receipts of the block after the changes are applied
+func (blk *BLKRWSet) ReceiptsRoot() crypto.Hash {
+	return blk.receiptsRoot
 }
@@ -17,12 +17,15 @@
 package state
 
 import (
-	"os"
+	"math/big"
 	"testing"
 
 	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/crypto"
 	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/trie"
 	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/params"
 )
 
 var (
@@ -31,8 +34,7 @@ var (
 )
 
 func TestSetChainCodeStateAndGetChainCodeState(t *testing.T) {
-	testDB := db.NewDB(db.DefaultConfig())
-	defer os.RemoveAll("/tmp/rocksdb-test")
+	testDB := db.NewMemDB(db.DefaultConfig())
 	testKey := "test"
 	testValue := []byte("TestSetChainCodeState")
 	b := NewBLKRWSet(testDB)
@@ -48,47 +50,112 @@ func TestSetChainCodeStateAndGetChainCodeState(t *testing.T) {
 	utils.AssertEquals(t, testValue, value)
 }
 
-func TestGetChainCodeStateByRange(t *testing.T) {
-	testDB := db.NewDB(db.DefaultConfig())
-	defer os.RemoveAll("/tmp/rocksdb-test")
-	testKey := "test"
-	testValue := []byte("TestSetChainCodeState")
+func TestGetChainCodeStates(t *testing.T) {
+	testDB := db.NewMemDB(db.DefaultConfig())
+	b := NewBLKRWSet(testDB)
+	for _, key := range []string{"test1", "other", "test"} {
+		if err := b.SetChainCodeState(chaincodeAddr, key, []byte("value_"+key)); err != nil {
+			t.Error(err)
+		}
+	}
+	writeBatchs, _, _, err := b.ApplyChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDB.AtomicWrite(writeBatchs)
+	b.SetBlock(1, 0)
+	b.SetChainCodeState(chaincodeAddr, "test2", []byte("value_test2"))
+	b.DelChainCodeState(chaincodeAddr, "test1")
 
-	testKey1 := "test1"
-	testValue1 := []byte("TestSetChainCodeState1")
+	keys := func(r *KeyRange, committed bool) []string {
+		values, err := b.GetChainCodeStates(chaincodeAddr, r, committed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret := []string{}
+		for _, kv := range values {
+			utils.AssertEquals(t, string(kv.Value), "value_"+string(kv.Key))
+			ret = append(ret, string(kv.Key))
+		}
+		return ret
+	}
+	utils.AssertEquals(t, keys(&KeyRange{Prefix: "test"}, true), []string{"test", "test1"})
+	utils.AssertEquals(t, keys(&KeyRange{Prefix: "test"}, false), []string{"test", "test2"})
+	utils.AssertEquals(t, keys(&KeyRange{Prefix: "test", Start: "test1"}, false), []string{"test2"})
+	utils.AssertEquals(t, keys(&KeyRange{Start: "other", End: "test2"}, false), []string{"other", "test"})
+	utils.AssertEquals(t, keys(&KeyRange{Count: 2}, false), []string{"other", "test"})
+	utils.AssertEquals(t, keys(&KeyRange{Start: "test0", Count: 1}, false), []string{"test2"})
+}
 
+func TestGetChainCodeStatesSkipsReservedKeys(t *testing.T) {
+	testDB := db.NewMemDB(db.DefaultConfig())
 	b := NewBLKRWSet(testDB)
-	if err := b.SetChainCodeState(chaincodeAddr, testKey, testValue); err != nil {
-		t.Error(err)
+	for _, key := range []string{params.ContractCodeKey, params.ContractInfoKey, params.ContractVersionKey + "1", "a"} {
+		b.SetChainCodeState(chaincodeAddr, key, []byte("value"))
 	}
-	if err := b.SetChainCodeState(chaincodeAddr, testKey1, testValue1); err != nil {
-		t.Error(err)
+	writeBatchs, _, _, err := b.ApplyChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDB.AtomicWrite(writeBatchs)
+	b.SetBlock(1, 0)
+	b.SetChainCodeState(chaincodeAddr, params.ContractVersionKey+"2", []byte("value"))
+	b.SetChainCodeState(chaincodeAddr, "b", []byte("value"))
+	tx := NewTXRWSet(b, nil, 0)
+	tx.SetChainCodeState(chaincodeAddr, params.ContractInfoKey, []byte("value"))
+	tx.SetChainCodeState(chaincodeAddr, "c", []byte("value"))
+
+	keys := func(set interface {
+		GetChainCodeStates(string, *KeyRange, bool) ([]*db.KeyValue, error)
+	}, r *KeyRange, committed bool) []string {
+		values, err := set.GetChainCodeStates(chaincodeAddr, r, committed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret := []string{}
+		for _, kv := range values {
+			ret = append(ret, string(kv.Key))
+		}
+		return ret
+	}
+	utils.AssertEquals(t, keys(b, &KeyRange{}, true), []string{"a"})
+	utils.AssertEquals(t, keys(b, &KeyRange{Count: 1}, false), []string{"a"})
+	utils.AssertEquals(t, keys(b, &KeyRange{Prefix: "__"}, false), []string{})
+	utils.AssertEquals(t, keys(tx, &KeyRange{}, false), []string{"a", "b", "c"})
+	utils.AssertEquals(t, keys(tx, &KeyRange{Count: 2}, false), []string{"a", "b"})
+}
+
+func TestRangeReadConflict(t *testing.T) {
+	testDB := db.NewMemDB(db.DefaultConfig())
+	b := NewBLKRWSet(testDB)
+	b.SetChainCodeState(chaincodeAddr, "a", []byte("1"))
+	writeBatchs, _, _, err := b.ApplyChanges()
+	if err != nil {
+		t.Fatal(err)
 	}
+	testDB.AtomicWrite(writeBatchs)
+	b.SetBlock(1, 2)
 
-	values, err := b.GetChainCodeStateByRange(chaincodeAddr, testKey, "", false)
+	reader := NewTXRWSet(b, nil, 1)
+	values, err := reader.GetChainCodeStates(chaincodeAddr, &KeyRange{}, false)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
+	utils.AssertEquals(t, len(values), 1)
 
-	if len(values) == 0 {
-		t.Error("values if not exist")
+	// a transaction merged first adds a key to the range the reader listed
+	writer := NewTXRWSet(b, nil, 0)
+	writer.SetChainCodeState(chaincodeAddr, "b", []byte("2"))
+	if err := writer.ApplyChanges(); err != nil {
+		t.Fatal(err)
 	}
-	for k, v := range values {
-		switch k {
-		case testKey:
-			utils.AssertEquals(t, v, testValue)
-		case testKey1:
-			utils.AssertEquals(t, v, testValue1)
-		default:
-			t.Errorf("have not set key: %s,value: %s.", k, v)
-		}
+	if err := reader.ApplyChanges(); err == nil {
+		t.Error("stale range read merged")
 	}
-
 }
 
 func TestDelChainCodeState(t *testing.T) {
-	testDB := db.NewDB(db.DefaultConfig())
-	defer os.RemoveAll("/tmp/rocksdb-test")
+	testDB := db.NewMemDB(db.DefaultConfig())
 	testKey := "test"
 	testValue := []byte("TestSetChainCodeState")
 	b := NewBLKRWSet(testDB)
@@ -107,10 +174,9 @@ func TestDelChainCodeState(t *testing.T) {
 }
 
 func TestSetBalacneStateAndGetBalanceState(t *testing.T) {
-	testDB := db.NewDB(db.DefaultConfig())
-	defer os.RemoveAll("/tmp/rocksdb-test")
+	testDB := db.NewMemDB(db.DefaultConfig())
 	testAssetID := uint32(123456)
-	testAmount := int64(10000)
+	testAmount := big.NewInt(10000)
 	b := NewBLKRWSet(testDB)
 	if err := b.SetBalacneState(balanceAddr, testAssetID, testAmount); err != nil {
 		t.Error(err)
@@ -119,17 +185,16 @@ func TestSetBalacneStateAndGetBalanceState(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	utils.AssertEquals(t, testAmount, amount)
+	utils.AssertEquals(t, amount.String(), testAmount.String())
 
 }
 
 func TestGetBalanceStates(t *testing.T) {
-	testDB := db.NewDB(db.DefaultConfig())
-	defer os.RemoveAll("/tmp/rocksdb-test")
+	testDB := db.NewMemDB(db.DefaultConfig())
 	testAssetID := uint32(123456)
-	testAmount := int64(10000)
+	testAmount := big.NewInt(10000)
 	testAssetID1 := uint32(1111)
-	testAmount1 := int64(10000)
+	testAmount1 := big.NewInt(10000)
 	b := NewBLKRWSet(testDB)
 	if err := b.SetBalacneState(balanceAddr, testAssetID, testAmount); err != nil {
 		t.Error(err)
@@ -149,9 +214,9 @@ func TestGetBalanceStates(t *testing.T) {
 	for k, v := range amounts {
 		switch k {
 		case testAssetID:
-			utils.AssertEquals(t, v, testAmount)
+			utils.AssertEquals(t, v.String(), testAmount.String())
 		case testAssetID1:
-			utils.AssertEquals(t, v, testAmount1)
+			utils.AssertEquals(t, v.String(), testAmount1.String())
 		default:
 			t.Errorf("have not set assetID: %v,amount: %v.", k, v)
 		}
@@ -159,10 +224,9 @@ func TestGetBalanceStates(t *testing.T) {
 }
 
 func TestDelBalanceState(t *testing.T) {
-	testDB := db.NewDB(db.DefaultConfig())
-	defer os.RemoveAll("/tmp/rocksdb-test")
+	testDB := db.NewMemDB(db.DefaultConfig())
 	testAssetID := uint32(123456)
-	testAmount := int64(10000)
+	testAmount := big.NewInt(10000)
 	b := NewBLKRWSet(testDB)
 	if err := b.SetBalacneState(balanceAddr, testAssetID, testAmount); err != nil {
 		t.Error(err)
@@ -173,15 +237,14 @@ func TestDelBalanceState(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	if amount != 0 {
+	if amount.Sign() != 0 {
 		t.Errorf("amount must be 0 ,not %v", amount)
 	}
 
 }
 
 func TestSetAssetStateAndGetAssetState(t *testing.T) {
-	testDB := db.NewDB(db.DefaultConfig())
-	defer os.RemoveAll("/tmp/rocksdb-test")
+	testDB := db.NewMemDB(db.DefaultConfig())
 	b := NewBLKRWSet(testDB)
 	testAssetID := uint32(123456)
 	testAssetInfo := &Asset{
@@ -204,8 +267,7 @@ func TestSetAssetStateAndGetAssetState(t *testing.T) {
 }
 
 func TestGetAssetStates(t *testing.T) {
-	testDB := db.NewDB(db.DefaultConfig())
-	defer os.RemoveAll("/tmp/rocksdb-test")
+	testDB := db.NewMemDB(db.DefaultConfig())
 	b := NewBLKRWSet(testDB)
 	testAssetID := uint32(123456)
 	testAssetInfo := &Asset{
@@ -256,8 +318,7 @@ func TestGetAssetStates(t *testing.T) {
 }
 
 func TestDelAssetState(t *testing.T) {
-	testDB := db.NewDB(db.DefaultConfig())
-	defer os.RemoveAll("/tmp/rocksdb-test")
+	testDB := db.NewMemDB(db.DefaultConfig())
 	b := NewBLKRWSet(testDB)
 	testAssetID := uint32(123456)
 	testAssetInfo := &Asset{
@@ -281,3 +342,118 @@ func TestDelAssetState(t *testing.T) {
 		t.Errorf("info must be nil,not %v", info)
 	}
 }
+
+func TestRootHashCoversState(t *testing.T) {
+	testDB := db.NewMemDB(db.DefaultConfig())
+	b := NewBLKRWSet(testDB)
+	testAssetID := uint32(123456)
+
+	b.SetBlock(1, 0)
+	b.SetBalacneState(balanceAddr, testAssetID, big.NewInt(10000))
+	writeBatchs, _, _, err := b.ApplyChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.AtomicWrite(writeBatchs); err != nil {
+		t.Fatal(err)
+	}
+	root := b.RootHash()
+	utils.AssertNotEquals(t, root, crypto.Hash{})
+
+	// a block without writes keeps the root of the whole state
+	b.SetBlock(2, 0)
+	writeBatchs, _, _, err = b.ApplyChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDB.AtomicWrite(writeBatchs)
+	utils.AssertEquals(t, b.RootHash(), root)
+
+	b.SetBlock(3, 0)
+	b.SetBalacneState(balanceAddr, testAssetID, big.NewInt(1))
+	writeBatchs, _, _, err = b.ApplyChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDB.AtomicWrite(writeBatchs)
+	utils.AssertNotEquals(t, b.RootHash(), root)
+}
+
+func TestGetStateProof(t *testing.T) {
+	testDB := db.NewMemDB(db.DefaultConfig())
+	b := NewBLKRWSet(testDB)
+	testAssetID := uint32(123456)
+
+	b.SetBlock(1, 0)
+	b.SetBalacneState(balanceAddr, testAssetID, big.NewInt(10000))
+	writeBatchs, _, _, err := b.ApplyChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDB.AtomicWrite(writeBatchs)
+	root := b.RootHash()
+
+	kp, err := b.GetStateProof(root, 1, b.GetBalanceCF(), BalanceKey(balanceAddr, testAssetID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, kp.Exists, true)
+	if err := kp.Verify(trie.Hash(root)); err != nil {
+		t.Error(err)
+	}
+	var amount int64
+	utils.Deserialize(kp.Value, &amount)
+	utils.AssertEquals(t, amount, int64(10000))
+
+	kp, err = b.GetStateProof(root, 1, b.GetBalanceCF(), BalanceKey(balanceAddr, testAssetID+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, kp.Exists, false)
+	if err := kp.Verify(trie.Hash(root)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetStateProofAtOldHeight(t *testing.T) {
+	testDB := db.NewMemDB(db.DefaultConfig())
+	b := NewBLKRWSet(testDB)
+	testAssetID := uint32(123456)
+	key := BalanceKey(balanceAddr, testAssetID)
+
+	roots := make([]crypto.Hash, 0)
+	for height, amount := range []int64{10000, 1} {
+		b.SetBlock(uint32(height+1), 0)
+		b.SetBalacneState(balanceAddr, testAssetID, big.NewInt(amount))
+		writeBatchs, _, _, err := b.ApplyChanges()
+		if err != nil {
+			t.Fatal(err)
+		}
+		testDB.AtomicWrite(writeBatchs)
+		roots = append(roots, b.RootHash())
+	}
+
+	// the balance was overwritten at height 2, the proof at height 1 still carries the old value
+	kp, err := b.GetStateProof(roots[0], 1, b.GetBalanceCF(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kp.Verify(trie.Hash(roots[0])); err != nil {
+		t.Error(err)
+	}
+	var amount int64
+	utils.Deserialize(kp.Value, &amount)
+	utils.AssertEquals(t, amount, int64(10000))
+
+	kp, err = b.GetStateProof(roots[1], 2, b.GetBalanceCF(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.Deserialize(kp.Value, &amount)
+	utils.AssertEquals(t, amount, int64(1))
+
+	// a root doesn't prove the value of another height
+	if _, err := b.GetStateProof(roots[0], 2, b.GetBalanceCF(), key); err != ErrStateValueUnavailable {
+		t.Errorf("proof of a mismatched height: %v", err)
+	}
+}
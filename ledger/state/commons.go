@@ -18,6 +18,7 @@
 package state
 
 import (
+	"strconv"
 	"strings"
 )
 
@@ -36,6 +37,23 @@ func DecodeCompositeKey(compositeKey string) (string, string) {
 	return split[0], split[1]
 }
 
+// BalanceKey returns the key of the balance of addr for assetID in the balance column family
+func BalanceKey(addr string, assetID uint32) string {
+	return ConstructCompositeKey(addr, strconv.FormatUint(uint64(assetID), 10)+assetIDKeySuffix)
+}
+
+// decodeBalanceKey decodes the key constructed by BalanceKey back to the address and assetID
+func decodeBalanceKey(ckey string) (string, uint32) {
+	addr, key := DecodeCompositeKey(ckey)
+	assetID, _ := strconv.ParseUint(strings.TrimSuffix(key, assetIDKeySuffix), 10, 32)
+	return addr, uint32(assetID)
+}
+
+// AssetKey returns the key of assetID in the asset column family
+func AssetKey(assetID uint32) string {
+	return ConstructCompositeKey(assetIDKeyPrefix, strconv.FormatUint(uint64(assetID), 10)+assetIDKeySuffix)
+}
+
 // Copy returns a copy of given bytes
 func Copy(src []byte) []byte {
 	dest := make([]byte, len(src))
@@ -0,0 +1,183 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package state
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+)
+
+// MaxEventLimit is the max number of events returned by one event query
+const MaxEventLimit = 1000
+
+var (
+	eventContractPrefix = []byte("c")
+	eventNamePrefix     = []byte("n")
+)
+
+// ContractEvent is an event a contract emitted, Data is its JSON encoding. Index is the position of the event
+// among the events of its transaction
+type ContractEvent struct {
+	Contract    string          `json:"contract" enc:"1"`
+	Name        string          `json:"name" enc:"2"`
+	Data        json.RawMessage `json:"data" enc:"3"`
+	BlockHeight uint32          `json:"blockHeight" enc:"4"`
+	TxHash      crypto.Hash     `json:"txHash" enc:"5"`
+	TxIndex     uint32          `json:"txIndex" enc:"6"`
+	Index       uint32          `json:"index" enc:"7"`
+}
+
+// EventCursor is the position of an event in the chain
+type EventCursor struct {
+	Height  uint32
+	TxIndex uint32
+	Index   uint32
+}
+
+// String returns the cursor in the form accepted by ParseEventCursor
+func (c EventCursor) String() string {
+	return hex.EncodeToString(c.Bytes())
+}
+
+// Bytes returns the cursor as big endian height, transaction index and event index, which sorts in chain order
+func (c EventCursor) Bytes() []byte {
+	buf := make([]byte, 12)
+	binary.BigEndian.PutUint32(buf, c.Height)
+	binary.BigEndian.PutUint32(buf[4:], c.TxIndex)
+	binary.BigEndian.PutUint32(buf[8:], c.Index)
+	return buf
+}
+
+// ParseEventCursor parses a cursor returned by EventCursor.String
+func ParseEventCursor(s string) (EventCursor, error) {
+	buf, err := hex.DecodeString(s)
+	if err != nil || len(buf) != 12 {
+		return EventCursor{}, errors.New("invalid cursor")
+	}
+	return EventCursor{
+		Height:  binary.BigEndian.Uint32(buf),
+		TxIndex: binary.BigEndian.Uint32(buf[4:]),
+		Index:   binary.BigEndian.Uint32(buf[8:]),
+	}, nil
+}
+
+// EventFilter selects the events of Contract, of all names if Name is empty, emitted from the block at FromHeight
+// up to the block at ToHeight, 0 for no upper bound. Cursor is the Next of the previous page and overrides FromHeight
+type EventFilter struct {
+	Contract   string `json:"contract"`
+	Name       string `json:"name"`
+	FromHeight uint32 `json:"fromHeight"`
+	ToHeight   uint32 `json:"toHeight"`
+	Cursor     string `json:"cursor"`
+	Limit      int    `json:"limit"`
+}
+
+// EventPage is a page of the events matching a filter, Next is the cursor of the
+// following page and is empty on the last page
+type EventPage struct {
+	Events []*ContractEvent `json:"events"`
+	Next   string           `json:"next"`
+}
+
+// eventPrefix returns the prefix of the index keys of the events of contract, of name only if it isn't empty
+func eventPrefix(contract, name string) []byte {
+	if name == "" {
+		return append(append(append([]byte{}, eventContractPrefix...), contract...), 0)
+	}
+	return append(append(append(append(append([]byte{}, eventNamePrefix...), contract...), 0), name...), 0)
+}
+
+// Emit records an event of the contract of the transaction
+func (tx *TXRWSet) Emit(name string, data []byte) error {
+	tx.events = append(tx.events, &ContractEvent{
+		Contract: tx.contractTx().Recipient().String(),
+		Name:     name,
+		Data:     data,
+	})
+	return nil
+}
+
+// eventBatches returns the write batches indexing the events of the receipts of the block
+// by contract and by contract and name
+func (blk *BLKRWSet) eventBatches() []*db.WriteBatch {
+	var writeBatchs []*db.WriteBatch
+	for _, r := range blk.receipts {
+		for _, e := range r.Events {
+			value := utils.Serialize(e)
+			c := EventCursor{Height: e.BlockHeight, TxIndex: e.TxIndex, Index: e.Index}
+			for _, prefix := range [][]byte{eventPrefix(e.Contract, ""), eventPrefix(e.Contract, e.Name)} {
+				writeBatchs = append(writeBatchs, db.NewWriteBatch(blk.eventCF, db.OperationPut, append(prefix, c.Bytes()...), value, blk.eventCF))
+			}
+		}
+	}
+	return writeBatchs
+}
+
+// GetEvents returns up to filter.Limit events matching filter in chain order
+func (blk *BLKRWSet) GetEvents(filter *EventFilter) (*EventPage, error) {
+	if filter.Contract == "" {
+		return nil, errors.New("event filter has no contract")
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > MaxEventLimit {
+		limit = MaxEventLimit
+	}
+	from := EventCursor{Height: filter.FromHeight}
+	if filter.Cursor != "" {
+		var err error
+		if from, err = ParseEventCursor(filter.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	prefix := eventPrefix(filter.Contract, filter.Name)
+	iter := blk.dbHandler.NewIterator(blk.eventCF, prefix, append(prefix, from.Bytes()...))
+	defer iter.Release()
+
+	page := &EventPage{Events: make([]*ContractEvent, 0)}
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != len(prefix)+12 {
+			continue
+		}
+		c := EventCursor{
+			Height:  binary.BigEndian.Uint32(key[len(prefix):]),
+			TxIndex: binary.BigEndian.Uint32(key[len(prefix)+4:]),
+			Index:   binary.BigEndian.Uint32(key[len(prefix)+8:]),
+		}
+		if filter.ToHeight != 0 && c.Height > filter.ToHeight {
+			break
+		}
+		if len(page.Events) == limit {
+			page.Next = c.String()
+			break
+		}
+
+		e := &ContractEvent{}
+		if err := utils.Deserialize(iter.Value(), e); err != nil {
+			return nil, err
+		}
+		page.Events = append(page.Events, e)
+	}
+	return page, nil
+}
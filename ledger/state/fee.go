@@ -0,0 +1,148 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/params"
+	pb "github.com/zipper-project/zipper/proto"
+)
+
+// FeePolicy defines how transaction fees are charged, it is kept in the global state under params.FeePolicyKey.
+// Without one the fee of a transaction is paid in its asset to its recipient. A block is executed with the policy
+// committed before it. A failed transaction uses its nonce but pays no fee, the charge is discarded with its other writes
+type FeePolicy struct {
+	AssetID    uint32                     `json:"assetId"`    // asset fees are charged in
+	Pool       account.Address            `json:"pool"`       // fee pool account
+	ToProposer bool                       `json:"toProposer"` // credit the fees of a block to its proposer instead of the pool
+	Proposers  map[string]account.Address `json:"proposers"`  // fee accounts of the replica IDs, fees of other proposers go to the pool
+	MinFees    map[string]*big.Int        `json:"minFees"`    // minimum fee per transaction type name
+}
+
+// FeeCharge is the fee a transaction paid and the account it was credited to
+type FeeCharge struct {
+	AssetID   uint32   `json:"assetId" enc:"1"`
+	Amount    *big.Int `json:"amount" enc:"2"`
+	Collector string   `json:"collector" enc:"3"`
+}
+
+// Validate returns an error if the fee policy is incomplete
+func (p *FeePolicy) Validate() error {
+	if p.Pool == (account.Address{}) {
+		return fmt.Errorf("fee policy has no pool account")
+	}
+	for name, fee := range p.MinFees {
+		if _, ok := pb.TransactionType_value[name]; !ok {
+			return fmt.Errorf("fee policy has a minimum fee for unknown transaction type %s", name)
+		}
+		if fee == nil || fee.Sign() < 0 {
+			return fmt.Errorf("fee policy minimum fee of %s transactions is negative", name)
+		}
+	}
+	return nil
+}
+
+// MinFee returns the minimum fee of transactions of type tp
+func (p *FeePolicy) MinFee(tp pb.TransactionType) *big.Int {
+	if fee, ok := p.MinFees[tp.String()]; ok && fee != nil {
+		return fee
+	}
+	return new(big.Int)
+}
+
+// CheckFee returns an error if the fee of tx is below the minimum of its type
+func (p *FeePolicy) CheckFee(tx *pb.Transaction) error {
+	min := p.MinFee(tx.GetType())
+	if tx.Fee().Cmp(min) < 0 {
+		return fmt.Errorf("fee %s is below the minimum %s of %s transactions", tx.Fee(), min, tx.GetType())
+	}
+	return nil
+}
+
+// Collector returns the account the fees of a block proposed by the replica proposer are credited to
+func (p *FeePolicy) Collector(proposer string) account.Address {
+	if p.ToProposer {
+		if addr, ok := p.Proposers[proposer]; ok {
+			return addr
+		}
+	}
+	return p.Pool
+}
+
+// DecodeFeePolicy decodes the fee policy global state value, nil if there is none
+func DecodeFeePolicy(value []byte) (*FeePolicy, error) {
+	data, err := DoContractStateData(value)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	policy := &FeePolicy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid fee policy -- %s", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// GetFeePolicy returns the fee policy of the global state, nil if there is none
+func (blk *BLKRWSet) GetFeePolicy(committed bool) (*FeePolicy, error) {
+	value, err := blk.GetChainCodeState(params.GlobalStateKey, params.FeePolicyKey, committed)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeFeePolicy(value)
+}
+
+// chargeFee moves the fee of ttx from its sender to the fee collector of the block in the fee asset
+func (tx *TXRWSet) chargeFee(ttx *pb.Transaction, policy *FeePolicy) error {
+	fee := ttx.Fee()
+	if fee.Sign() == 0 {
+		return nil
+	}
+	if fee.Sign() < 0 {
+		return fmt.Errorf("fee %s is negative", fee)
+	}
+
+	sender := ttx.Sender().String()
+	collector := policy.Collector(tx.block.BlockProposer).String()
+	sbalance, err := tx.GetBalanceState(sender, policy.AssetID, false)
+	if err != nil {
+		return err
+	}
+	sbalance.Sub(sbalance, fee)
+	if sbalance.Sign() < 0 {
+		return ErrNegativeBalance
+	}
+	tx.SetBalacneState(sender, policy.AssetID, sbalance)
+
+	cbalance, err := tx.GetBalanceState(collector, policy.AssetID, false)
+	if err != nil {
+		return err
+	}
+	tx.SetBalacneState(collector, policy.AssetID, cbalance.Add(cbalance, fee))
+
+	if tx.fee == nil {
+		tx.fee = &FeeCharge{AssetID: policy.AssetID, Amount: new(big.Int), Collector: collector}
+	}
+	tx.fee.Amount.Add(tx.fee.Amount, fee)
+	return nil
+}
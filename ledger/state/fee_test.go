@@ -0,0 +1,57 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package state
+
+import (
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/params"
+)
+
+func TestPutFeePolicy(t *testing.T) {
+	testDB := db.NewMemDB(db.DefaultConfig())
+	b := NewBLKRWSet(testDB)
+	b.SetBlock(1, 1)
+	tx := NewTXRWSet(b, newTestTransfer(balanceAddr, chaincodeAddr, 0, 0), 0)
+
+	for _, v := range []interface{}{"not a policy", &FeePolicy{AssetID: 1}} {
+		value, _ := ConcrateStateJson(v)
+		if err := tx.PutGlobalState(params.FeePolicyKey, value.Bytes()); err == nil {
+			t.Errorf("fee policy %v stored", v)
+		}
+	}
+
+	value, _ := ConcrateStateJson(&FeePolicy{AssetID: 1, Pool: account.HexToAddress(balanceAddr)})
+	utils.AssertEquals(t, tx.PutGlobalState(params.FeePolicyKey, value.Bytes()), nil)
+	if err := tx.CallBack(&CallBackResponse{}); err != nil {
+		t.Fatal(err)
+	}
+	// the policy applies from the next block on
+	utils.AssertEquals(t, b.feePolicy == nil, true)
+	writeBatchs, _, _, err := b.ApplyChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDB.AtomicWrite(writeBatchs)
+
+	b.SetBlock(2, 0)
+	utils.AssertEquals(t, b.feePolicyErr, nil)
+	utils.AssertEquals(t, b.feePolicy.AssetID, uint32(1))
+}
@@ -0,0 +1,286 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger/balance"
+)
+
+// historyBaseKey stores the height the state history starts at
+var historyBaseKey = []byte("historyBase")
+
+// stateVersion is the value a block wrote to a state key, TxIndex is the transaction in the block that wrote it
+type stateVersion struct {
+	TxIndex uint32 `enc:"1"`
+	Deleted bool   `enc:"2"`
+	Value   []byte `enc:"3"`
+}
+
+// historyKeyPrefix returns the prefix of the history keys of the state keys with prefix in column family cf.
+// Zero bytes of the key are escaped so the history keys sort in the order of the state keys
+func historyKeyPrefix(cf string, prefix []byte) []byte {
+	buf := bytes.NewBufferString(cf)
+	buf.WriteByte(0)
+	for _, b := range prefix {
+		buf.WriteByte(b)
+		if b == 0 {
+			buf.WriteByte(1)
+		}
+	}
+	return buf.Bytes()
+}
+
+// historyKey returns the key of the version of key written at height, the versions of a key sort from the latest down
+func historyKey(cf string, key []byte, height uint32) []byte {
+	return append(historyVersionsPrefix(cf, key), invertedHeight(height)...)
+}
+
+// invertedHeight encodes height so that higher heights sort first
+func invertedHeight(height uint32) []byte {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, ^height)
+	return buf
+}
+
+// historyVersionsPrefix returns the prefix of the history keys of key only
+func historyVersionsPrefix(cf string, key []byte) []byte {
+	return append(historyKeyPrefix(cf, key), 0, 0)
+}
+
+// decodeHistoryKey returns the state key and height of a history key of column family cf
+func decodeHistoryKey(cf string, hkey []byte) ([]byte, uint32, error) {
+	if !bytes.HasPrefix(hkey, []byte(cf+"\x00")) {
+		return nil, 0, fmt.Errorf("history key %x isn't in %s", hkey, cf)
+	}
+	hkey = hkey[len(cf)+1:]
+
+	var key []byte
+	for i := 0; i+1 < len(hkey); i++ {
+		if hkey[i] != 0 {
+			key = append(key, hkey[i])
+			continue
+		}
+		if hkey[i+1] == 1 {
+			key = append(key, 0)
+			i++
+			continue
+		}
+		if hkey[i+1] == 0 && len(hkey) == i+6 {
+			return key, ^binary.BigEndian.Uint32(hkey[i+2:]), nil
+		}
+		break
+	}
+	return nil, 0, fmt.Errorf("invalid history key %x", hkey)
+}
+
+// stateSet returns the block write set of column family cf with its lock
+func (blk *BLKRWSet) stateSet(cf string) (*KVRWSet, *sync.RWMutex) {
+	switch cf {
+	case blk.chainCodeCF:
+		return blk.chainCodeSet, &blk.chainCodeRW
+	case blk.assetCF:
+		return blk.assetSet, &blk.assetRW
+	case blk.balanceCF:
+		return blk.balanceSet, &blk.balanceRW
+	}
+	return nil, nil
+}
+
+// HistoryBase returns the height the state history starts at, false if no block has recorded history yet
+func (blk *BLKRWSet) HistoryBase() (uint32, bool, error) {
+	value, err := blk.dbHandler.Get(blk.historyCF, historyBaseKey)
+	if err != nil || value == nil {
+		return 0, false, err
+	}
+	return utils.BytesToUint32(value), true, nil
+}
+
+// HistoryBatches returns the write batches recording a whole state, given as put batches of the state column families,
+// as the versions at height and starting the state history there
+func (blk *BLKRWSet) HistoryBatches(states []*db.WriteBatch, height uint32) []*db.WriteBatch {
+	writeBatchs := make([]*db.WriteBatch, 0, len(states)+1)
+	for _, wb := range states {
+		writeBatchs = append(writeBatchs, db.NewWriteBatch(blk.historyCF, db.OperationPut, historyKey(wb.CfName, wb.Key, height), utils.Serialize(&stateVersion{Value: wb.Value}), blk.historyCF))
+	}
+	return append(writeBatchs, db.NewWriteBatch(blk.historyCF, db.OperationPut, historyBaseKey, utils.Uint32ToBytes(height), blk.historyCF))
+}
+
+// historyBatches returns the write batches recording the block writes as the versions at the block height.
+// A database written before the state history existed gets the whole state recorded at the previous height first
+func (blk *BLKRWSet) historyBatches() ([]*db.WriteBatch, error) {
+	writeBatchs := make([]*db.WriteBatch, 0)
+	if _, ok, err := blk.HistoryBase(); err != nil {
+		return nil, err
+	} else if !ok {
+		var states []*db.WriteBatch
+		base := uint32(0)
+		if blk.BlockIndex > 0 {
+			base = blk.BlockIndex - 1
+			for _, cf := range []string{blk.chainCodeCF, blk.assetCF, blk.balanceCF} {
+				for _, kv := range blk.dbHandler.GetByPrefix(cf, nil) {
+					states = append(states, db.NewWriteBatch(cf, db.OperationPut, kv.Key, kv.Value, cf))
+				}
+			}
+		}
+		writeBatchs = append(writeBatchs, blk.HistoryBatches(states, base)...)
+	}
+
+	for _, cf := range []string{blk.chainCodeCF, blk.assetCF, blk.balanceCF} {
+		set, _ := blk.stateSet(cf)
+		for ckey, wset := range set.Writes {
+			version := &stateVersion{TxIndex: wset.txIndex, Deleted: wset.IsDelete, Value: wset.Value}
+			writeBatchs = append(writeBatchs, db.NewWriteBatch(blk.historyCF, db.OperationPut, historyKey(cf, []byte(ckey), blk.BlockIndex), utils.Serialize(version), blk.historyCF))
+		}
+	}
+	return writeBatchs, nil
+}
+
+// checkHistory returns an error if the state history doesn't cover height
+func (blk *BLKRWSet) checkHistory(height uint32) error {
+	base, ok, err := blk.HistoryBase()
+	if err != nil {
+		return err
+	}
+	if !ok || height < base {
+		return fmt.Errorf("state history isn't available at height %d", height)
+	}
+	return nil
+}
+
+// getVersion returns the latest version of key in column family cf written at or below height
+func (blk *BLKRWSet) getVersion(cf string, key []byte, height uint32) (*stateVersion, uint32, error) {
+	prefix := historyVersionsPrefix(cf, key)
+	iter := blk.dbHandler.NewIterator(blk.historyCF, prefix, historyKey(cf, key, height))
+	defer iter.Release()
+	if !iter.Next() {
+		return nil, 0, nil
+	}
+
+	version := &stateVersion{}
+	if err := utils.Deserialize(iter.Value(), version); err != nil {
+		return nil, 0, err
+	}
+	return version, ^binary.BigEndian.Uint32(iter.Key()[len(prefix):]), nil
+}
+
+// GetStateAt returns the value of key in column family cf as of the block at height, nil if the key was absent
+func (blk *BLKRWSet) GetStateAt(cf string, key string, height uint32) ([]byte, error) {
+	if err := blk.checkHistory(height); err != nil {
+		return nil, err
+	}
+	version, _, err := blk.getVersion(cf, []byte(key), height)
+	if err != nil || version == nil || version.Deleted {
+		return nil, err
+	}
+	return version.Value, nil
+}
+
+// getStatesAt returns the values of the keys with prefix in column family cf as of the block at height
+func (blk *BLKRWSet) getStatesAt(cf string, prefix string, height uint32) (map[string][]byte, error) {
+	if err := blk.checkHistory(height); err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string][]byte)
+	var last []byte
+	iter := blk.dbHandler.NewIterator(blk.historyCF, historyKeyPrefix(cf, []byte(prefix)), nil)
+	defer iter.Release()
+	for iter.Next() {
+		key, h, err := decodeHistoryKey(cf, iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		// the versions of a key sort from the latest down, the first one at or below height wins
+		if h > height || (last != nil && bytes.Equal(key, last)) {
+			continue
+		}
+		last = key
+
+		version := &stateVersion{}
+		if err := utils.Deserialize(iter.Value(), version); err != nil {
+			return nil, err
+		}
+		if !version.Deleted {
+			ret[string(key)] = version.Value
+		}
+	}
+	return ret, nil
+}
+
+// GetBalancesAt returns the balances of addr as of the block at height
+func (blk *BLKRWSet) GetBalancesAt(addr string, height uint32) (*balance.Balance, error) {
+	states, err := blk.getStatesAt(blk.balanceCF, ConstructCompositeKey(addr, ""), height)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[uint32]*big.Int)
+	for ckey, value := range states {
+		_, assetID := decodeBalanceKey(ckey)
+		amount, err := DecodeAmount(value)
+		if err != nil {
+			return nil, err
+		}
+		balances[assetID] = amount
+	}
+	return &balance.Balance{Amounts: balances}, nil
+}
+
+// GetAssetAt returns the asset as of the block at height, nil if it didn't exist
+func (blk *BLKRWSet) GetAssetAt(assetID uint32, height uint32) (*Asset, error) {
+	value, err := blk.GetStateAt(blk.assetCF, AssetKey(assetID), height)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	assetInfo := &Asset{}
+	if err := utils.Deserialize(value, assetInfo); err != nil {
+		return nil, err
+	}
+	return assetInfo, nil
+}
+
+// GetChainCodeStateAt returns the contract state of key as of the block at height
+func (blk *BLKRWSet) GetChainCodeStateAt(chaincodeAddr string, key string, height uint32) ([]byte, error) {
+	return blk.GetStateAt(blk.chainCodeCF, ConstructCompositeKey(chaincodeAddr, key), height)
+}
+
+// GetVersion returns the version of the value of key in column family cf the block reads,
+// nil if no block has written the key since the state history started
+func (blk *BLKRWSet) GetVersion(cf string, ckey string) (*Version, error) {
+	if set, lock := blk.stateSet(cf); set != nil {
+		lock.RLock()
+		wset, ok := set.Writes[ckey]
+		lock.RUnlock()
+		if ok {
+			return &Version{BlockNum: uint64(blk.BlockIndex), TxNum: uint64(wset.txIndex)}, nil
+		}
+	}
+
+	version, height, err := blk.getVersion(cf, []byte(ckey), ^uint32(0))
+	if err != nil || version == nil {
+		return nil, err
+	}
+	return &Version{BlockNum: uint64(height), TxNum: uint64(version.TxIndex)}, nil
+}
@@ -0,0 +1,46 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package state
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/zipper-project/zipper/common/utils"
+)
+
+func TestHistoryKey(t *testing.T) {
+	for _, key := range [][]byte{[]byte("a"), {0}, {'a', 0, 'b'}, {0, 0, 1}} {
+		for _, height := range []uint32{0, 1, 300, ^uint32(0)} {
+			k, h, err := decodeHistoryKey("balance", historyKey("balance", key, height))
+			if err != nil {
+				t.Fatal(err)
+			}
+			utils.AssertEquals(t, k, key)
+			utils.AssertEquals(t, h, height)
+		}
+	}
+
+	// later versions sort first, and the versions of a key sort before the longer keys it prefixes
+	utils.AssertEquals(t, bytes.Compare(historyKey("balance", []byte("a"), 2), historyKey("balance", []byte("a"), 1)), -1)
+	utils.AssertEquals(t, bytes.Compare(historyKey("balance", []byte("a"), 0), historyKey("balance", []byte{'a', 0}, 9)), -1)
+	utils.AssertEquals(t, bytes.Compare(historyKey("balance", []byte{'a', 0}, 0), historyKey("balance", []byte("ab"), 9)), -1)
+
+	if _, _, err := decodeHistoryKey("asset", historyKey("balance", []byte("a"), 1)); err == nil {
+		t.Error("history key of another column family decoded")
+	}
+}
@@ -28,9 +28,9 @@ type IKVRWSet interface {
 	// GetChainCodeState get state for chaincode address and key. If committed is false, this first looks in memory
 	// and if missing, pulls from db.  If committed is true, this pulls from the db only.
 	GetChainCodeState(chaincodeAddr string, key string, committed bool) ([]byte, error)
-	// GetChainCodeStateByRange get state for chaincode address and key. If committed is false, this first looks in memory
-	// and if missing, pulls from db.  If committed is true, this pulls from the db only.
-	GetChainCodeStateByRange(chaincodeAddr string, startKey string, endKey string, committed bool) (map[string][]byte, error)
+	// GetChainCodeStates returns the states of chaincode address in r in ascending key order. If committed is false,
+	// the writes in memory are merged in.  If committed is true, this pulls from the db only.
+	GetChainCodeStates(chaincodeAddr string, r *KeyRange, committed bool) ([]*db.KeyValue, error)
 	// SetChainCodeState set state to given value for chaincode address and key. Does not immideatly writes to DB
 	SetChainCodeState(chaincodeAddr string, key string, value []byte) error
 	// DelChainCodeState tracks the deletion of state for chaincode address and key. Does not immediately writes to DB
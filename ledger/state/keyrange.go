@@ -0,0 +1,129 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package state
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/params"
+)
+
+// KeyRange selects the contract state keys with Prefix from Start up to but excluding End in ascending key order.
+// An empty End leaves the range open and a Count of 0 doesn't limit the number of keys
+type KeyRange struct {
+	Prefix string `enc:"1"`
+	Start  string `enc:"2"`
+	End    string `enc:"3"`
+	Count  int    `enc:"4"`
+}
+
+// Contains reports whether key is in the range
+func (r *KeyRange) Contains(key string) bool {
+	return strings.HasPrefix(key, r.Prefix) && key >= r.Start && (r.End == "" || key < r.End)
+}
+
+// first returns the key the iteration over the range starts at
+func (r *KeyRange) first() string {
+	if r.Start > r.Prefix {
+		return r.Start
+	}
+	return r.Prefix
+}
+
+// ReservedContractKey reports whether key is a contract state key kept by the ledger, the code, info or a code version
+// of the contract. Range reads skip these keys
+func ReservedContractKey(key string) bool {
+	return key == params.ContractCodeKey || key == params.ContractInfoKey || strings.HasPrefix(key, params.ContractVersionKey)
+}
+
+// RangeRead captures a range read performed during transaction simulation with the states it returned
+type RangeRead struct {
+	ChaincodeAddr string         `enc:"1"`
+	Range         KeyRange       `enc:"2"`
+	States        []*db.KeyValue `enc:"3"`
+}
+
+// rangeWrites returns the writes of set to the contract states of chaincodeAddr in r in ascending key order,
+// a deletion has a nil value
+func rangeWrites(set *KVRWSet, chaincodeAddr string, r *KeyRange) []*db.KeyValue {
+	prefix := ConstructCompositeKey(chaincodeAddr, "")
+	var writes []*db.KeyValue
+	for ckey, kvw := range set.Writes {
+		if !strings.HasPrefix(ckey, prefix) {
+			continue
+		}
+		if key := ckey[len(prefix):]; r.Contains(key) && !ReservedContractKey(key) {
+			var value []byte
+			if !kvw.IsDelete {
+				value = kvw.Value
+			}
+			writes = append(writes, &db.KeyValue{Key: []byte(key), Value: value})
+		}
+	}
+	sort.Slice(writes, func(i, j int) bool {
+		return bytes.Compare(writes[i].Key, writes[j].Key) < 0
+	})
+	return writes
+}
+
+// MergeStates merges the ascending changes into the ascending states and returns at most count of them, 0 for all.
+// A change replaces the state of its key, a change with a nil value deletes it
+func MergeStates(states, changes []*db.KeyValue, count int) []*db.KeyValue {
+	merged := make([]*db.KeyValue, 0, len(states)+len(changes))
+	for i, j := 0, 0; i < len(states) || j < len(changes); {
+		if count > 0 && len(merged) == count {
+			break
+		}
+		var kv *db.KeyValue
+		switch {
+		case j == len(changes):
+			kv, i = states[i], i+1
+		case i == len(states):
+			kv, j = changes[j], j+1
+		default:
+			c := bytes.Compare(states[i].Key, changes[j].Key)
+			if c == 0 {
+				i++
+			}
+			if c < 0 {
+				kv, i = states[i], i+1
+			} else {
+				kv, j = changes[j], j+1
+			}
+		}
+		if kv.Value != nil {
+			merged = append(merged, kv)
+		}
+	}
+	return merged
+}
+
+// sameStates reports whether a and b hold the same keys with the same values
+func sameStates(a, b []*db.KeyValue) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i].Key, b[i].Key) || !bytes.Equal(a[i].Value, b[i].Value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package state
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/params"
+	pb "github.com/zipper-project/zipper/proto"
+)
+
+// NonceEnforced reports whether tx has to carry the next nonce of its sender,
+// transactions crossing chains keep relying on their hash for replay protection
+func NonceEnforced(tx *pb.Transaction) bool {
+	return tx.FromChain() == tx.ToChain() && tx.GetType() != pb.TransactionType_ContractQuery
+}
+
+func decodeNonce(value []byte) (uint32, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	if len(value) != 4 {
+		return 0, fmt.Errorf("invalid nonce %x", value)
+	}
+	return utils.BytesToUint32(value), nil
+}
+
+// GetNonce returns the next nonce of addr
+func (blk *BLKRWSet) GetNonce(addr string, committed bool) (uint32, error) {
+	value, err := blk.GetChainCodeState(params.NonceStateKey, addr, committed)
+	if err != nil {
+		return 0, err
+	}
+	return decodeNonce(value)
+}
+
+// useNonce checks that ttx carries the next nonce of its sender and advances it. The advanced nonce
+// is kept even if the transaction fails later on, so a failed transaction can't be replayed either
+func (tx *TXRWSet) useNonce(ttx *pb.Transaction) error {
+	sender := ttx.Sender().String()
+	value, err := tx.GetChainCodeState(params.NonceStateKey, sender, false)
+	if err != nil {
+		return err
+	}
+	next, err := decodeNonce(value)
+	if err != nil {
+		return err
+	}
+	if ttx.Nonce() != next {
+		return fmt.Errorf("nonce %d of %s isn't the next nonce %d", ttx.Nonce(), sender, next)
+	}
+	if next == math.MaxUint32 {
+		return fmt.Errorf("nonces of %s are exhausted", sender)
+	}
+	tx.nonce = utils.Uint32ToBytes(next + 1)
+	return tx.SetChainCodeState(params.NonceStateKey, sender, tx.nonce)
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package state
+
+import (
+	"errors"
+
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/trie"
+)
+
+// ErrStateValueUnavailable is returned when the state history doesn't hold the value proven by the requested root
+var ErrStateValueUnavailable = errors.New("state value at the requested root is unavailable")
+
+// StateProof proves a state key against the StateHash of the block at Height
+type StateProof struct {
+	Height    uint32 `json:"height"`
+	StateHash string `json:"stateHash"`
+	*trie.KeyProof
+}
+
+// GetStateProof returns the proof of key in column family cf against the state root of the block at height,
+// the proven value is read from the state history at that height
+func (blk *BLKRWSet) GetStateProof(root crypto.Hash, height uint32, cf string, key string) (*trie.KeyProof, error) {
+	proof, err := trie.New(trie.Hash(root), blk).Prove(trie.KeyHash(cf, []byte(key)))
+	if err != nil {
+		return nil, err
+	}
+
+	kp := &trie.KeyProof{
+		Namespace: cf,
+		Key:       []byte(key),
+		Proof:     proof,
+	}
+	valueHash, ok, err := proof.Verify(trie.Hash(root), trie.KeyHash(cf, kp.Key))
+	if err != nil || !ok {
+		return kp, err
+	}
+
+	value, err := blk.GetStateAt(cf, key, height)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil || trie.ValueHash(value) != valueHash {
+		return nil, ErrStateValueUnavailable
+	}
+	kp.Exists = true
+	kp.Value = value
+	return kp, nil
+}
@@ -0,0 +1,136 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package state
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/ledger/balance"
+	"github.com/zipper-project/zipper/params"
+	pb "github.com/zipper-project/zipper/proto"
+)
+
+// ErrReadOnly is returned when a contract query changes the state, transfers or emits events
+var ErrReadOnly = errors.New("contract query can't change the state, transfer or emit events")
+
+// QuerySet is the read-only handler of a contract query, it reads the committed state only
+type QuerySet struct {
+	block     *BLKRWSet
+	currentTx *pb.Transaction
+	calls     []*pb.Transaction
+	height    uint32
+}
+
+// NewQuerySet returns the handler of the contract query tx against the committed state at height
+func NewQuerySet(blk *BLKRWSet, tx *pb.Transaction, height uint32) *QuerySet {
+	return &QuerySet{
+		block:     blk,
+		currentTx: tx,
+		height:    height,
+	}
+}
+
+// contractTx returns the transaction of the contract executing, the call of the innermost contract called
+func (q *QuerySet) contractTx() *pb.Transaction {
+	if n := len(q.calls); n > 0 {
+		return q.calls[n-1]
+	}
+	return q.currentTx
+}
+
+// EnterContract makes the contract callTx is sent to the queried one until ExitContract
+func (q *QuerySet) EnterContract(callTx *pb.Transaction) error {
+	q.calls = append(q.calls, callTx)
+	return nil
+}
+
+// ExitContract returns to the contract that called the queried one
+func (q *QuerySet) ExitContract() error {
+	if len(q.calls) == 0 {
+		return errors.New("no contract call to exit")
+	}
+	q.calls = q.calls[:len(q.calls)-1]
+	return nil
+}
+
+func (q *QuerySet) GetGlobalState(key string) ([]byte, error) {
+	return q.block.GetChainCodeState(params.GlobalStateKey, key, true)
+}
+
+func (q *QuerySet) PutGlobalState(key string, value []byte) error {
+	return ErrReadOnly
+}
+
+func (q *QuerySet) DelGlobalState(key string) error {
+	return ErrReadOnly
+}
+
+func (q *QuerySet) GetState(key string) ([]byte, error) {
+	return q.block.GetChainCodeState(q.contractTx().Recipient().String(), key, true)
+}
+
+func (q *QuerySet) PutState(key string, value []byte) error {
+	return ErrReadOnly
+}
+
+func (q *QuerySet) DelState(key string) error {
+	return ErrReadOnly
+}
+
+func (q *QuerySet) GetByPrefix(prefix, startKey string, count int) ([]*db.KeyValue, error) {
+	return q.block.GetChainCodeStates(q.contractTx().Recipient().String(), &KeyRange{Prefix: prefix, Start: startKey, Count: count}, true)
+}
+
+func (q *QuerySet) GetByRange(startKey, limitKey string, count int) ([]*db.KeyValue, error) {
+	return q.block.GetChainCodeStates(q.contractTx().Recipient().String(), &KeyRange{Start: startKey, End: limitKey, Count: count}, true)
+}
+
+func (q *QuerySet) Emit(name string, data []byte) error {
+	return ErrReadOnly
+}
+
+func (q *QuerySet) ComplexQuery(key string) ([]byte, error) {
+	return nil, errors.New("vp can't support complex qery")
+}
+
+func (q *QuerySet) GetBalance(addr string, assetID uint32) (*big.Int, error) {
+	return q.block.GetBalanceState(addr, assetID, true)
+}
+
+func (q *QuerySet) GetBalances(addr string) (*balance.Balance, error) {
+	ret, err := q.block.GetBalanceStates(addr, true)
+	return &balance.Balance{Amounts: ret}, err
+}
+
+func (q *QuerySet) GetCurrentBlockHeight() uint32 {
+	return q.height
+}
+
+func (q *QuerySet) AddTransfer(fromAddr, toAddr string, assetID uint32, amount, fee *big.Int) error {
+	return ErrReadOnly
+}
+
+func (q *QuerySet) Transfer(tx *pb.Transaction) error {
+	return ErrReadOnly
+}
+
+// CallBack does nothing, a query has no changes to merge
+func (q *QuerySet) CallBack(response *CallBackResponse) error {
+	return nil
+}
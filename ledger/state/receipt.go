@@ -0,0 +1,181 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package state
+
+import (
+	"encoding/json"
+	"math/big"
+	"sort"
+
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+)
+
+// Receipt records the outcome of a transaction in a block
+type Receipt struct {
+	TxHash      crypto.Hash      `json:"txHash" enc:"1"`
+	BlockHeight uint32           `json:"blockHeight" enc:"2"`
+	TxIndex     uint32           `json:"txIndex" enc:"3"`
+	Success     bool             `json:"success" enc:"4"`
+	Err         string           `json:"error" enc:"5"`
+	Keys        []*StateKey      `json:"keys" enc:"6"`
+	Deltas      []*BalanceDelta  `json:"deltas" enc:"7"`
+	Result      utils.Bytes      `json:"result" enc:"8"`
+	Fee         *FeeCharge       `json:"fee,omitempty" enc:"9"` // fee charged by the fee policy
+	Events      []*ContractEvent `json:"events,omitempty" enc:"10"`
+}
+
+// StateKey is a state key written by a transaction
+type StateKey struct {
+	Namespace string `json:"namespace" enc:"1"`
+	Key       string `json:"key" enc:"2"`
+}
+
+// BalanceDelta is the change of the balance of Addr for AssetID made by a transaction
+type BalanceDelta struct {
+	Addr    string   `json:"addr" enc:"1"`
+	AssetID uint32   `json:"assetID" enc:"2"`
+	Amount  *big.Int `json:"amount" enc:"3"`
+}
+
+// Hash returns the hash of the receipt committed to the receipts root
+func (r *Receipt) Hash() crypto.Hash {
+	return crypto.Sha256(utils.Serialize(r))
+}
+
+// Serialize returns the serialized bytes of the receipt
+func (r *Receipt) Serialize() []byte {
+	return utils.Serialize(r)
+}
+
+// Deserialize deserializes bytes to the receipt
+func (r *Receipt) Deserialize(data []byte) error {
+	return utils.Deserialize(data, r)
+}
+
+// ReceiptsRoot returns the merkle root of the receipts
+func ReceiptsRoot(receipts []*Receipt) crypto.Hash {
+	if len(receipts) == 0 {
+		return crypto.Hash{}
+	}
+	hashs := make([]crypto.Hash, 0, len(receipts))
+	for _, r := range receipts {
+		hashs = append(hashs, r.Hash())
+	}
+	return crypto.GetMerkleHash(hashs)
+}
+
+// GetReceipt returns the receipt of the transaction, nil if there is none
+func (blk *BLKRWSet) GetReceipt(txHash crypto.Hash) (*Receipt, error) {
+	data, err := blk.dbHandler.Get(blk.receiptCF, txHash.Bytes())
+	if err != nil || data == nil {
+		return nil, err
+	}
+	r := &Receipt{}
+	if err := r.Deserialize(data); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// newReceipt builds the receipt of a transaction from its execution result and write sets,
+// balance deltas are taken against the state of the block before the write sets are merged
+func (blk *BLKRWSet) newReceipt(execErr error, result interface{}, chainCodeSet, assetSet, balanceSet *KVRWSet, fee *FeeCharge) *Receipt {
+	r := &Receipt{
+		BlockHeight: blk.BlockIndex,
+		Success:     execErr == nil,
+		Result:      encodeResult(result),
+		Fee:         fee,
+	}
+	if execErr != nil {
+		r.Err = execErr.Error()
+		return r
+	}
+
+	sets := []struct {
+		cf  string
+		set *KVRWSet
+	}{
+		{blk.chainCodeCF, chainCodeSet},
+		{blk.assetCF, assetSet},
+		{blk.balanceCF, balanceSet},
+	}
+	for _, s := range sets {
+		if s.set == nil {
+			continue
+		}
+		for ckey := range s.set.Writes {
+			r.Keys = append(r.Keys, &StateKey{Namespace: s.cf, Key: ckey})
+		}
+	}
+	sort.Slice(r.Keys, func(i, j int) bool {
+		if r.Keys[i].Namespace != r.Keys[j].Namespace {
+			return r.Keys[i].Namespace < r.Keys[j].Namespace
+		}
+		return r.Keys[i].Key < r.Keys[j].Key
+	})
+
+	if balanceSet != nil {
+		for ckey, wset := range balanceSet.Writes {
+			before, after := new(big.Int), new(big.Int)
+			if kvw, ok := blk.balanceSet.Writes[ckey]; ok {
+				before, _ = DecodeAmount(kvw.Value)
+			} else if value, err := blk.dbHandler.Get(blk.balanceCF, []byte(ckey)); err == nil {
+				before, _ = DecodeAmount(value)
+			}
+			if !wset.IsDelete {
+				after, _ = DecodeAmount(wset.Value)
+			}
+			if before == nil || after == nil || after.Cmp(before) == 0 {
+				continue
+			}
+			addr, assetID := decodeBalanceKey(ckey)
+			r.Deltas = append(r.Deltas, &BalanceDelta{Addr: addr, AssetID: assetID, Amount: new(big.Int).Sub(after, before)})
+		}
+	}
+	sort.Slice(r.Deltas, func(i, j int) bool {
+		if r.Deltas[i].Addr != r.Deltas[j].Addr {
+			return r.Deltas[i].Addr < r.Deltas[j].Addr
+		}
+		return r.Deltas[i].AssetID < r.Deltas[j].AssetID
+	})
+	return r
+}
+
+// receiptBatches returns the write batches of the receipts of the block
+func (blk *BLKRWSet) receiptBatches() []*db.WriteBatch {
+	writeBatchs := make([]*db.WriteBatch, 0, len(blk.receipts))
+	for _, r := range blk.receipts {
+		writeBatchs = append(writeBatchs, db.NewWriteBatch(blk.receiptCF, db.OperationPut, r.TxHash.Bytes(), r.Serialize(), blk.receiptCF))
+	}
+	return writeBatchs
+}
+
+func encodeResult(result interface{}) []byte {
+	switch v := result.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	default:
+		data, _ := json.Marshal(v)
+		return data
+	}
+}
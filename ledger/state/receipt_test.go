@@ -0,0 +1,126 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package state
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	pb "github.com/zipper-project/zipper/proto"
+)
+
+func newTestTransfer(from, to string, amount int64, nonce uint32) *pb.Transaction {
+	return pb.NewTransaction(
+		account.NewChainCoordinate([]byte{0}),
+		account.NewChainCoordinate([]byte{0}),
+		pb.TransactionType_Atomic,
+		nonce,
+		account.HexToAddress(from),
+		account.HexToAddress(to),
+		0,
+		big.NewInt(amount),
+		big.NewInt(0),
+		utils.CurrentTimestamp(),
+	)
+}
+
+func TestReceipts(t *testing.T) {
+	testDB := db.NewMemDB(db.DefaultConfig())
+	b := NewBLKRWSet(testDB)
+	recipient := "0xa232277be213f56221b6140998c03d860a60e1f8"
+
+	b.SetBlock(1, 0)
+	b.SetBalacneState(balanceAddr, 0, big.NewInt(1000))
+	writeBatchs, _, _, err := b.ApplyChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDB.AtomicWrite(writeBatchs)
+
+	okTx := newTestTransfer(balanceAddr, recipient, 100, 0)
+	errTx := newTestTransfer(balanceAddr, recipient, 100, 1)
+	b.SetBlock(2, 2)
+	txrw := NewTXRWSet(b, okTx, 0)
+	if err := txrw.Transfer(okTx); err != nil {
+		t.Fatal(err)
+	}
+	if err := txrw.CallBack(&CallBackResponse{Result: "ok"}); err != nil {
+		t.Fatal(err)
+	}
+	txrw = NewTXRWSet(b, errTx, 1)
+	if err := txrw.CallBack(&CallBackResponse{Err: errors.New("contract failed")}); err != nil {
+		t.Fatal(err)
+	}
+
+	writeBatchs, txs, errTxs, err := b.ApplyChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testDB.AtomicWrite(writeBatchs)
+	utils.AssertEquals(t, len(txs), 2)
+	utils.AssertEquals(t, len(errTxs), 1)
+	utils.AssertNotEquals(t, b.ReceiptsRoot(), crypto.Hash{})
+
+	receipt, err := b.GetReceipt(okTx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, receipt.Success, true)
+	utils.AssertEquals(t, receipt.BlockHeight, uint32(2))
+	utils.AssertEquals(t, receipt.TxIndex, uint32(0))
+	utils.AssertEquals(t, receipt.Result, utils.Bytes("ok"))
+	utils.AssertEquals(t, len(receipt.Keys), 3)
+	utils.AssertEquals(t, len(receipt.Deltas), 2)
+	utils.AssertEquals(t, receipt.Deltas[0].Addr, balanceAddr)
+	utils.AssertEquals(t, receipt.Deltas[0].Amount.String(), "-100")
+	utils.AssertEquals(t, receipt.Deltas[1].Addr, recipient)
+	utils.AssertEquals(t, receipt.Deltas[1].Amount.String(), "100")
+
+	receipt, err = b.GetReceipt(errTx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, receipt.Success, false)
+	utils.AssertEquals(t, receipt.TxIndex, uint32(1))
+	utils.AssertEquals(t, receipt.Err, "contract failed")
+
+	receipt, err = b.GetReceipt(crypto.Hash{})
+	if err != nil || receipt != nil {
+		t.Errorf("unexpected receipt %v, %v", receipt, err)
+	}
+}
+
+func TestReceiptBigDelta(t *testing.T) {
+	large, _ := new(big.Int).SetString("100000000000000000000000", 10)
+	r := &Receipt{Deltas: []*BalanceDelta{{Addr: balanceAddr, AssetID: 0, Amount: large}, {Addr: balanceAddr, AssetID: 1, Amount: big.NewInt(-5)}}}
+	decoded := &Receipt{}
+	if err := decoded.Deserialize(r.Serialize()); err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, decoded.Deltas[0].Amount.String(), large.String())
+	utils.AssertEquals(t, decoded.Deltas[1].Amount.String(), "-5")
+
+	utils.AssertEquals(t, decoded.Hash(), r.Hash())
+
+	zero := &Receipt{Deltas: []*BalanceDelta{{Addr: balanceAddr, AssetID: 0, Amount: new(big.Int)}, {Addr: balanceAddr, AssetID: 1, Amount: new(big.Int)}}}
+	utils.AssertNotEquals(t, zero.Hash(), r.Hash())
+}
@@ -18,26 +18,29 @@ package state
 
 // Version encapsulates the version of a Key
 type Version struct {
-	BlockNum uint64
-	TxNum    uint64
+	BlockNum uint64 `enc:"1"`
+	TxNum    uint64 `enc:"2"`
 }
 
 // KVRead captures a read operation performed during transaction simulation
 type KVRead struct {
-	Value   []byte
-	Version *Version
+	Value   []byte   `enc:"1"`
+	Version *Version `enc:"2"`
 }
 
 // KVWrite captures a write (update/delete) operation performed during transaction simulation
 type KVWrite struct {
-	Value    []byte
-	IsDelete bool
+	Value    []byte `enc:"1"`
+	IsDelete bool   `enc:"2"`
+
+	txIndex uint32
 }
 
 // KVRWSet encapsulates the read-write operation performed during transaction simulation
 type KVRWSet struct {
-	Reads  map[string]*KVRead
-	Writes map[string]*KVWrite
+	Reads      map[string]*KVRead  `enc:"1"`
+	Writes     map[string]*KVWrite `enc:"2"`
+	RangeReads []*RangeRead        `enc:"3"`
 }
 
 //NewKVRWSet initialization
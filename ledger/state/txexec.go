@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"strings"
 
 	"github.com/zipper-project/zipper/account"
@@ -34,6 +35,23 @@ import (
 
 var permissionPrefix = "permission."
 
+// decodePermission returns the address holding a permission, stored as json contract state
+// or as the hex string a contract put
+func decodePermission(value []byte) (account.Address, error) {
+	var addr account.Address
+	data, err := DoContractStateData(value)
+	if err != nil {
+		return addr, err
+	}
+	if err := json.Unmarshal(data, &addr); err == nil {
+		return addr, nil
+	}
+	if err := addr.UnmarshalText(data); err != nil {
+		return addr, fmt.Errorf("permission holder %q isn't an address", data)
+	}
+	return addr, nil
+}
+
 func (tx *TXRWSet) verifyPermission(key string) error {
 	var dataAdmin []byte
 	var err error
@@ -63,12 +81,11 @@ func (tx *TXRWSet) verifyPermission(key string) error {
 		}
 	}
 
-	sender := tx.currentTx.Sender().Bytes()
+	sender := tx.contractTx().Sender().Bytes()
 	if len(dataAdmin) > 0 {
-		var dataAdminAddr account.Address
-		err = json.Unmarshal(dataAdmin, &dataAdminAddr)
+		dataAdminAddr, err := decodePermission(dataAdmin)
 		if err != nil {
-			return nil
+			return err
 		}
 
 		if !bytes.Equal(sender, dataAdminAddr[:]) {
@@ -80,6 +97,34 @@ func (tx *TXRWSet) verifyPermission(key string) error {
 	return nil
 }
 
+// contractTx returns the transaction of the contract executing, the call of the innermost contract called
+func (tx *TXRWSet) contractTx() *pb.Transaction {
+	if n := len(tx.calls); n > 0 {
+		return tx.calls[n-1]
+	}
+	return tx.currentTx
+}
+
+// EnterContract makes the contract callTx is sent to the executing one until ExitContract,
+// its sender is the contract calling it
+func (tx *TXRWSet) EnterContract(callTx *pb.Transaction) error {
+	log.Debugf("EnterContract caller=[%s], chaincode=[%s]", callTx.Sender(), callTx.Recipient())
+	if err := tx.checkActive(callTx.Recipient().String()); err != nil {
+		return err
+	}
+	tx.calls = append(tx.calls, callTx)
+	return nil
+}
+
+// ExitContract returns to the contract that called the executing one
+func (tx *TXRWSet) ExitContract() error {
+	if len(tx.calls) == 0 {
+		return errors.New("no contract call to exit")
+	}
+	tx.calls = tx.calls[:len(tx.calls)-1]
+	return nil
+}
+
 func (tx *TXRWSet) GetGlobalState(key string) ([]byte, error) {
 	log.Debugf("GetGlobalState key=[%s]", key)
 	return tx.GetChainCodeState(params.GlobalStateKey, key, false)
@@ -89,6 +134,12 @@ func (tx *TXRWSet) PutGlobalState(key string, value []byte) error {
 	if err := tx.verifyPermission(key); err != nil {
 		return err
 	}
+	// the fee policy is decoded when a block starts, one that doesn't decode would fail every transaction
+	if key == params.FeePolicyKey {
+		if _, err := DecodeFeePolicy(value); err != nil {
+			return err
+		}
+	}
 	log.Debugf("SetGlobalState key=[%s], value=[%#v]", key, value)
 	return tx.SetChainCodeState(params.GlobalStateKey, key, value)
 }
@@ -103,65 +154,52 @@ func (tx *TXRWSet) DelGlobalState(key string) error {
 }
 
 func (tx *TXRWSet) ComplexQuery(key string) ([]byte, error) {
-	chaincodeAddr := tx.currentTx.Recipient().String()
+	chaincodeAddr := tx.contractTx().Recipient().String()
 	log.Debugf("ComplexQuery chaincode=[%s], key=[%s]", chaincodeAddr, key)
 	return nil, errors.New("vp can't support complex qery")
 }
 
 func (tx *TXRWSet) GetState(key string) ([]byte, error) {
-	chaincodeAddr := tx.currentTx.Recipient().String()
+	chaincodeAddr := tx.contractTx().Recipient().String()
 	log.Debugf("GetState chaincode=[%s], key=[%s]", chaincodeAddr, key)
 	return tx.GetChainCodeState(chaincodeAddr, key, false)
 }
 
 func (tx *TXRWSet) PutState(key string, value []byte) error {
-	chaincodeAddr := tx.currentTx.Recipient().String()
+	chaincodeAddr := tx.contractTx().Recipient().String()
 	log.Debugf("SetState chaincode=[%s], key=[%s], value=[%#v]", chaincodeAddr, key, value)
+	if err := tx.checkContractKey(key); err != nil {
+		return err
+	}
 	return tx.SetChainCodeState(chaincodeAddr, key, value)
 }
 
 func (tx *TXRWSet) DelState(key string) error {
-	chaincodeAddr := tx.currentTx.Recipient().String()
+	chaincodeAddr := tx.contractTx().Recipient().String()
 	log.Debugf("DelState chaincode=[%s], key=[%s]", chaincodeAddr, key)
+	if err := tx.checkContractKey(key); err != nil {
+		return err
+	}
 	tx.DelChainCodeState(chaincodeAddr, key)
 	return nil
 }
 
-func (tx *TXRWSet) GetByPrefix(key string) ([]*db.KeyValue, error) {
-	chaincodeAddr := tx.currentTx.Recipient().String()
-	log.Debugf("GetByPrefix chaincode=[%s], key=[%s]", chaincodeAddr, key)
-	ret, err := tx.GetChainCodeStateByRange(chaincodeAddr, key, "", false)
-	if err != nil {
-		return nil, err
-	}
-	kvs := []*db.KeyValue{}
-	for k, v := range ret {
-		kvs = append(kvs, &db.KeyValue{
-			[]byte(k),
-			v,
-		})
-	}
-	return kvs, nil
+// GetByPrefix returns up to count states of the contract with prefix from startKey on in ascending key order, 0 for all
+func (tx *TXRWSet) GetByPrefix(prefix, startKey string, count int) ([]*db.KeyValue, error) {
+	chaincodeAddr := tx.contractTx().Recipient().String()
+	log.Debugf("GetByPrefix chaincode=[%s], prefix=[%s], startKey=[%s], count=[%d]", chaincodeAddr, prefix, startKey, count)
+	return tx.GetChainCodeStates(chaincodeAddr, &KeyRange{Prefix: prefix, Start: startKey, Count: count}, false)
 }
 
-func (tx *TXRWSet) GetByRange(startKey, endKey string) ([]*db.KeyValue, error) {
-	chaincodeAddr := tx.currentTx.Recipient().String()
-	log.Debugf("GetByRange chaincode=[%s], startKey=[%s], endKey=[%s]", chaincodeAddr, startKey, endKey)
-	ret, err := tx.GetChainCodeStateByRange(chaincodeAddr, startKey, endKey, false)
-	if err != nil {
-		return nil, err
-	}
-	kvs := []*db.KeyValue{}
-	for k, v := range ret {
-		kvs = append(kvs, &db.KeyValue{
-			[]byte(k),
-			v,
-		})
-	}
-	return kvs, nil
+// GetByRange returns up to count states of the contract from startKey up to but excluding limitKey in ascending key order,
+// an empty limitKey leaves the range open
+func (tx *TXRWSet) GetByRange(startKey, limitKey string, count int) ([]*db.KeyValue, error) {
+	chaincodeAddr := tx.contractTx().Recipient().String()
+	log.Debugf("GetByRange chaincode=[%s], startKey=[%s], limitKey=[%s], count=[%d]", chaincodeAddr, startKey, limitKey, count)
+	return tx.GetChainCodeStates(chaincodeAddr, &KeyRange{Start: startKey, End: limitKey, Count: count}, false)
 }
 
-func (tx *TXRWSet) GetBalance(addr string, assetID uint32) (int64, error) {
+func (tx *TXRWSet) GetBalance(addr string, assetID uint32) (*big.Int, error) {
 	log.Debugf("GetBalance addr=[%s], assetID=[%d]", addr, assetID)
 	return tx.GetBalanceState(addr, assetID, false)
 }
@@ -177,7 +215,7 @@ func (tx *TXRWSet) GetCurrentBlockHeight() uint32 {
 	return tx.block.BlockIndex
 }
 
-func (tx *TXRWSet) AddTransfer(fromAddr, toAddr string, assetID uint32, amount, fee int64) error {
+func (tx *TXRWSet) AddTransfer(fromAddr, toAddr string, assetID uint32, amount, fee *big.Int) error {
 	log.Debugf("AddTransfer from=[%s], to=[%s], assetID=[%d], amount=[%s], fee=[%s]", fromAddr, toAddr, assetID, amount, fee)
 	ttx := pb.NewTransaction(
 		tx.currentTx.GetHeader().GetFromChain(),
@@ -191,20 +229,47 @@ func (tx *TXRWSet) AddTransfer(fromAddr, toAddr string, assetID uint32, amount,
 		fee,
 		tx.currentTx.GetHeader().GetCreateTime(),
 	)
-	if err := tx.Transfer(ttx); err != nil {
+	if tx.block.feePolicyErr != nil {
+		return tx.block.feePolicyErr
+	}
+	if err := tx.transfer(ttx, tx.block.feePolicy); err != nil {
 		return err
 	}
 	tx.transferTxs = append(tx.transferTxs, ttx)
 	return nil
 }
 
+// Transfer executes the transfer of a transaction, its fee has to meet the minimum of the fee policy.
+// A transaction executed above the height it is valid until fails without using its nonce,
+// contract deployments, upgrades and decommissions update the contract info before the transfer
 func (tx *TXRWSet) Transfer(ttx *pb.Transaction) error {
 	log.Debugf("TXRWSet Transfer")
+	if until := ttx.ValidUntil(); until != 0 && tx.block.BlockIndex > until {
+		return fmt.Errorf("transaction is valid until block %d, it can't be executed in block %d", until, tx.block.BlockIndex)
+	}
+	if NonceEnforced(ttx) {
+		if err := tx.useNonce(ttx); err != nil {
+			return err
+		}
+	}
+	if tx.block.feePolicyErr != nil {
+		return tx.block.feePolicyErr
+	}
+	if policy := tx.block.feePolicy; policy != nil {
+		if err := policy.CheckFee(ttx); err != nil {
+			return err
+		}
+	}
+	if err := tx.updateContract(ttx); err != nil {
+		return err
+	}
+	return tx.transfer(ttx, tx.block.feePolicy)
+}
+
+func (tx *TXRWSet) transfer(ttx *pb.Transaction, policy *FeePolicy) error {
 	sender := ttx.Sender().String()
 	receiver := ttx.Recipient().String()
 	assetID := ttx.GetHeader().GetAssetID()
-	amount := ttx.GetHeader().GetAmount()
-	fee := ttx.GetHeader().GetFee()
 	tp := ttx.GetHeader().GetType()
 	if tp == pb.TransactionType_Issue {
 		if asset, err := tx.GetAssetState(assetID, false); asset != nil || err != nil {
@@ -222,6 +287,10 @@ func (tx *TXRWSet) Transfer(ttx *pb.Transaction) error {
 		if err != nil {
 			return fmt.Errorf("asset id %d failed to update -- %s", assetID, err)
 		}
+		asset.TotalSupply = new(big.Int)
+		if asset, err = asset.Mint(ttx.Amount()); err != nil {
+			return fmt.Errorf("asset id %d failed to issue -- %s", assetID, err)
+		}
 		tx.SetAssetState(assetID, asset)
 	} else if tp == pb.TransactionType_IssueUpdate {
 		asset, err := tx.GetAssetState(assetID, false)
@@ -236,8 +305,21 @@ func (tx *TXRWSet) Transfer(ttx *pb.Transaction) error {
 			return fmt.Errorf("asset id %d failed to update -- %s", assetID, err)
 		}
 		tx.SetAssetState(assetID, asset)
+	} else if tp == pb.TransactionType_Mint || tp == pb.TransactionType_Burn {
+		asset, err := tx.GetAssetState(assetID, false)
+		if asset == nil {
+			if err != nil {
+				return fmt.Errorf("asset id %d failed to get -- %s", assetID, err)
+			}
+			return fmt.Errorf("asset id %d not exist", assetID)
+		}
+		if asset, err = asset.ChangeSupply(ttx); err != nil {
+			return err
+		}
+		tx.SetAssetState(assetID, asset)
 	}
 
+	debit, credit := TransferAmounts(ttx, policy)
 	sbalance, err := tx.GetBalanceState(sender, assetID, false)
 	if err != nil {
 		return err
@@ -246,17 +328,19 @@ func (tx *TXRWSet) Transfer(ttx *pb.Transaction) error {
 	if err != nil {
 		return err
 	}
-	//todo check border
-	tamount := amount + fee
-	sbalance = sbalance - tamount
-	if tp != pb.TransactionType_Issue && tp != pb.TransactionType_IssueUpdate {
-		if sbalance < 0 {
-			return ErrNegativeBalance
-		}
+	sbalance.Sub(sbalance, debit)
+	if sbalance.Sign() < 0 {
+		return ErrNegativeBalance
+	}
+	if sender == receiver {
+		rbalance = sbalance
 	}
-	rbalance = rbalance + tamount
+	rbalance.Add(rbalance, credit)
 	tx.SetBalacneState(sender, assetID, sbalance)
 	tx.SetBalacneState(receiver, assetID, rbalance)
+	if policy != nil {
+		return tx.chargeFee(ttx, policy)
+	}
 	return nil
 }
 
@@ -269,16 +353,35 @@ type CallBackResponse struct {
 func (tx *TXRWSet) CallBack(res *CallBackResponse) error {
 	log.Debugf("TXRWSet CallBack txIndex: %d %v", tx.TxIndex, res)
 	if res.Err != nil {
-		tx.transferTxs = nil
 		if res.IsCanRedo {
-			tx.assetSet = NewKVRWSet()
-			tx.balanceSet = NewKVRWSet()
-			tx.chainCodeSet = NewKVRWSet()
+			tx.reset()
 			return res.Err
 		}
+		tx.transferTxs = nil
+		tx.fee = nil
+		tx.events = nil
 		tx.assetSet = nil
 		tx.balanceSet = nil
 		tx.chainCodeSet = nil
 	}
-	return tx.ApplyChanges()
+	tx.execErr = res.Err
+	tx.result = res.Result
+	err := tx.ApplyChanges()
+	if err != nil && res.IsCanRedo {
+		// the transaction conflicts with one merged before it, it's redone against the merged state
+		tx.reset()
+	}
+	return err
+}
+
+// reset drops the changes of the transaction so it can be redone
+func (tx *TXRWSet) reset() {
+	tx.transferTxs = nil
+	tx.fee = nil
+	tx.nonce = nil
+	tx.events = nil
+	tx.calls = nil
+	tx.assetSet = NewKVRWSet()
+	tx.balanceSet = NewKVRWSet()
+	tx.chainCodeSet = NewKVRWSet()
 }
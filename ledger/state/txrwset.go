@@ -19,10 +19,11 @@ package state
 
 import (
 	"bytes"
-	"strconv"
+	"math/big"
 	"strings"
 	"sync"
 
+	"github.com/zipper-project/zipper/common/db"
 	"github.com/zipper-project/zipper/common/log"
 	"github.com/zipper-project/zipper/common/treap"
 	"github.com/zipper-project/zipper/common/utils"
@@ -52,8 +53,15 @@ type TXRWSet struct {
 
 	block       *BLKRWSet
 	currentTx   *pb.Transaction
+	calls       []*pb.Transaction
 	transferTxs pb.Transactions
 	TxIndex     uint32
+
+	execErr error
+	result  interface{}
+	fee     *FeeCharge
+	nonce   []byte
+	events  []*ContractEvent
 }
 
 // GetChainCodeState get state for chaincode address and key. If committed is false, this first looks in memory
@@ -74,55 +82,47 @@ func (tx *TXRWSet) GetChainCodeState(chaincodeAddr string, key string, committed
 	val, err := tx.block.GetChainCodeState(chaincodeAddr, key, committed)
 	if val != nil {
 		tx.chainCodeSet.Reads[ckey] = &KVRead{
-			Value: val,
+			Value:   val,
+			Version: tx.readVersion(tx.block.chainCodeCF, ckey),
 		}
 	}
 	return val, err
 }
 
-// GetChainCodeStateByRange get state for chaincode address and key. If committed is false, this first looks in memory
-// and if missing, pulls from db.  If committed is true, this pulls from the db only.
-func (tx *TXRWSet) GetChainCodeStateByRange(chaincodeAddr string, startKey string, endKey string, committed bool) (map[string][]byte, error) {
-	tx.chainCodeRW.RLock()
-	defer tx.chainCodeRW.RUnlock()
-	chaincodePrefix := ConstructCompositeKey(chaincodeAddr, "")
-	ckeyStart := ConstructCompositeKey(chaincodeAddr, startKey)
-	ckeyEnd := ConstructCompositeKey(chaincodeAddr, endKey)
-	ret, err := tx.block.GetChainCodeStateByRange(chaincodeAddr, startKey, endKey, committed)
+// readVersion returns the version of the value of ckey the transaction reads, nil if it's unknown
+func (tx *TXRWSet) readVersion(cf string, ckey string) *Version {
+	version, err := tx.block.GetVersion(cf, ckey)
 	if err != nil {
-		return nil, err
+		log.Errorf("read version of %s %q -- %s", cf, ckey, err)
 	}
-	if !committed {
-		cache := treap.NewImmutable()
-		for ckey, kvr := range tx.chainCodeSet.Reads {
-			if strings.HasPrefix(ckey, chaincodePrefix) {
-				cache.Put([]byte(ckey), kvr.Value)
-			}
-		}
-		for ckey, kvw := range tx.chainCodeSet.Writes {
-			if strings.HasPrefix(ckey, chaincodePrefix) {
-				cache.Put([]byte(ckey), kvw.Value)
-			}
-		}
+	return version
+}
 
-		if len(endKey) > 0 {
-			for iter := cache.Iterator([]byte(ckeyStart), []byte(ckeyEnd)); iter.Next(); {
-				if val := iter.Value(); val != nil {
-					ret[string(iter.Key())] = val
-				}
-			}
-		} else {
-			for iter := cache.Iterator([]byte(startKey), nil); iter.Next(); {
-				if !bytes.HasPrefix(iter.Key(), []byte(startKey)) {
-					break
-				}
-				if val := iter.Value(); val != nil {
-					ret[string(iter.Key())] = val
-				}
-			}
-		}
+// GetChainCodeStates returns the states of chaincode address in r in ascending key order, keyed without the address.
+// If committed is false, the transaction writes are merged in and the block states read are tracked as a range read,
+// so a transaction merged before this one that changes the range is a conflict.  If committed is true, this pulls from the db only.
+func (tx *TXRWSet) GetChainCodeStates(chaincodeAddr string, r *KeyRange, committed bool) ([]*db.KeyValue, error) {
+	if committed {
+		return tx.block.GetChainCodeStates(chaincodeAddr, r, committed)
 	}
-	return ret, nil
+
+	tx.chainCodeRW.Lock()
+	defer tx.chainCodeRW.Unlock()
+	writes := rangeWrites(tx.chainCodeSet, chaincodeAddr, r)
+	blkRange := *r
+	if r.Count > 0 {
+		blkRange.Count = r.Count + len(writes)
+	}
+	states, err := tx.block.GetChainCodeStates(chaincodeAddr, &blkRange, false)
+	if err != nil {
+		return nil, err
+	}
+	tx.chainCodeSet.RangeReads = append(tx.chainCodeSet.RangeReads, &RangeRead{
+		ChaincodeAddr: chaincodeAddr,
+		Range:         blkRange,
+		States:        states,
+	})
+	return MergeStates(states, writes, r.Count), nil
 }
 
 // SetChainCodeState set state to given value for chaincode address and key. Does not immideatly writes to DB
@@ -150,39 +150,36 @@ func (tx *TXRWSet) DelChainCodeState(chaincodeAddr string, key string) {
 
 // GetBalanceState get balance for address and assetID. If committed is false, this first looks in memory
 // and if missing, pulls from db.  If committed is true, this pulls from the db only.
-func (tx *TXRWSet) GetBalanceState(addr string, assetID uint32, committed bool) (int64, error) {
+func (tx *TXRWSet) GetBalanceState(addr string, assetID uint32, committed bool) (*big.Int, error) {
 	tx.balanceRW.RLock()
 	defer tx.balanceRW.RUnlock()
-	var amount int64
-	ckey := ConstructCompositeKey(addr, strconv.FormatUint(uint64(assetID), 10)+assetIDKeySuffix)
+	ckey := BalanceKey(addr, assetID)
 	if !committed {
 		if kvw, ok := tx.balanceSet.Writes[ckey]; ok {
 			if kvw.IsDelete {
-				return 0, nil
+				return new(big.Int), nil
 			}
-			utils.Deserialize(kvw.Value, amount)
-			return amount, nil
+			return DecodeAmount(kvw.Value)
 		}
 
 		if kvr, ok := tx.balanceSet.Reads[ckey]; ok {
-			utils.Deserialize(kvr.Value, amount)
-
-			return amount, nil
+			return DecodeAmount(kvr.Value)
 		}
 	}
 	val, err := tx.block.GetBalanceState(addr, assetID, committed)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	tx.chainCodeSet.Reads[ckey] = &KVRead{
-		Value: utils.Serialize(val),
+	tx.balanceSet.Reads[ckey] = &KVRead{
+		Value:   EncodeAmount(val),
+		Version: tx.readVersion(tx.block.balanceCF, ckey),
 	}
 	return val, nil
 }
 
 // GetBalanceStates get balances for address. If committed is false, this first looks in memory
 // and if missing, pulls from db.  If committed is true, this pulls from the db only.
-func (tx *TXRWSet) GetBalanceStates(addr string, committed bool) (map[uint32]int64, error) {
+func (tx *TXRWSet) GetBalanceStates(addr string, committed bool) (map[uint32]*big.Int, error) {
 	tx.balanceRW.RLock()
 	defer tx.balanceRW.RUnlock()
 	balances, err := tx.block.GetBalanceStates(addr, committed)
@@ -196,12 +193,12 @@ func (tx *TXRWSet) GetBalanceStates(addr string, committed bool) (map[uint32]int
 		cache := treap.NewImmutable()
 		for ckey, kvr := range tx.balanceSet.Reads {
 			if strings.HasPrefix(ckey, prefix) {
-				cache.Put([]byte(ckey), kvr.Value)
+				cache = cache.Put([]byte(ckey), kvr.Value)
 			}
 		}
 		for ckey, kvw := range tx.balanceSet.Writes {
 			if strings.HasPrefix(ckey, prefix) {
-				cache.Put([]byte(ckey), kvw.Value)
+				cache = cache.Put([]byte(ckey), kvw.Value)
 			}
 		}
 
@@ -209,31 +206,32 @@ func (tx *TXRWSet) GetBalanceStates(addr string, committed bool) (map[uint32]int
 			if !bytes.HasPrefix(iter.Key(), []byte(prefix)) {
 				break
 			}
-			if val := iter.Value(); val != nil {
-				ret[string(iter.Key())] = val
-			}
+			ret[string(iter.Key())] = iter.Value()
 		}
 	}
 
 	for k, v := range ret {
-		if v != nil {
-			assetID, err := strconv.ParseUint(k, 10, 32)
-			if err != nil {
-				return nil, err
-			}
-			utils.Deserialize(v, balances[uint32(assetID)])
+		_, assetID := decodeBalanceKey(k)
+		if v == nil {
+			delete(balances, assetID)
+			continue
+		}
+		amount, err := DecodeAmount(v)
+		if err != nil {
+			return nil, err
 		}
+		balances[assetID] = amount
 	}
 	return balances, nil
 }
 
 // SetBalacneState set balance to given value for chaincode address and key. Does not immideatly writes to DB
-func (tx *TXRWSet) SetBalacneState(addr string, assetID uint32, amount int64) error {
+func (tx *TXRWSet) SetBalacneState(addr string, assetID uint32, amount *big.Int) error {
 	tx.balanceRW.Lock()
 	defer tx.balanceRW.Unlock()
-	ckey := ConstructCompositeKey(addr, strconv.FormatUint(uint64(assetID), 10)+assetIDKeySuffix)
+	ckey := BalanceKey(addr, assetID)
 	tx.balanceSet.Writes[ckey] = &KVWrite{
-		Value:    utils.Serialize(amount),
+		Value:    EncodeAmount(amount),
 		IsDelete: false,
 	}
 	return nil
@@ -243,7 +241,7 @@ func (tx *TXRWSet) SetBalacneState(addr string, assetID uint32, amount int64) er
 func (tx *TXRWSet) DelBalanceState(addr string, assetID uint32) {
 	tx.balanceRW.Lock()
 	defer tx.balanceRW.Unlock()
-	ckey := ConstructCompositeKey(addr, strconv.FormatUint(uint64(assetID), 10)+assetIDKeySuffix)
+	ckey := BalanceKey(addr, assetID)
 	tx.balanceSet.Writes[ckey] = &KVWrite{
 		Value:    nil,
 		IsDelete: true,
@@ -256,7 +254,7 @@ func (tx *TXRWSet) GetAssetState(assetID uint32, committed bool) (*Asset, error)
 	tx.assetRW.RLock()
 	defer tx.assetRW.RUnlock()
 	assetInfo := &Asset{}
-	ckey := ConstructCompositeKey(assetIDKeyPrefix, strconv.FormatUint(uint64(assetID), 10)+assetIDKeySuffix)
+	ckey := AssetKey(assetID)
 	if !committed {
 		if kvw, ok := tx.assetSet.Writes[ckey]; ok {
 			if kvw.IsDelete {
@@ -278,7 +276,8 @@ func (tx *TXRWSet) GetAssetState(assetID uint32, committed bool) (*Asset, error)
 	val, err := tx.block.GetAssetState(assetID, committed)
 	if val != nil {
 		tx.chainCodeSet.Reads[ckey] = &KVRead{
-			Value: utils.Serialize(val),
+			Value:   utils.Serialize(val),
+			Version: tx.readVersion(tx.block.assetCF, ckey),
 		}
 	}
 	return val, err
@@ -336,7 +335,7 @@ func (tx *TXRWSet) SetAssetState(assetID uint32, assetInfo *Asset) error {
 	tx.assetRW.Lock()
 	defer tx.assetRW.Unlock()
 	value := utils.Serialize(assetInfo)
-	ckey := ConstructCompositeKey(assetIDKeyPrefix, strconv.FormatUint(uint64(assetID), 10)+assetIDKeySuffix)
+	ckey := AssetKey(assetID)
 	tx.assetSet.Writes[ckey] = &KVWrite{
 		Value:    value,
 		IsDelete: false,
@@ -348,7 +347,7 @@ func (tx *TXRWSet) SetAssetState(assetID uint32, assetInfo *Asset) error {
 func (tx *TXRWSet) DelAssetState(assetID uint32) {
 	tx.assetRW.Lock()
 	defer tx.assetRW.Unlock()
-	ckey := ConstructCompositeKey(assetIDKeyPrefix, strconv.FormatUint(uint64(assetID), 10)+assetIDKeySuffix)
+	ckey := AssetKey(assetID)
 	tx.assetSet.Writes[ckey] = &KVWrite{
 		Value:    nil,
 		IsDelete: true,
@@ -364,7 +363,7 @@ func (tx *TXRWSet) ApplyChanges() error {
 	tx.balanceRW.RLock()
 	defer tx.balanceRW.RUnlock()
 	log.Debugf("TXRWSet ApplyChanges txIndex: %d ", tx.TxIndex)
-	err := tx.block.merge(tx.chainCodeSet, tx.assetSet, tx.balanceSet, tx.currentTx, tx.transferTxs, tx.TxIndex)
+	err := tx.block.merge(tx.chainCodeSet, tx.assetSet, tx.balanceSet, tx.currentTx, tx.transferTxs, tx.TxIndex, tx.execErr, tx.result, tx.fee, tx.nonce, tx.events)
 
 	// tx.assetSet = NewKVRWSet()
 	// tx.balanceSet = NewKVRWSet()
@@ -0,0 +1,253 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/params"
+	pb "github.com/zipper-project/zipper/proto"
+)
+
+// ContractInfo is the owner and current code version of a contract, Decommissioned is the height
+// it was decommissioned at, 0 while it can be invoked
+type ContractInfo struct {
+	Owner          account.Address `json:"owner"`
+	Version        uint32          `json:"version"`
+	Decommissioned uint32          `json:"decommissioned,omitempty"`
+}
+
+// ContractVersion is a version of the code of a contract with the height it was deployed at.
+// The current code is stored without height in the format of the vm contract code
+type ContractVersion struct {
+	Code   []byte
+	Type   string
+	Height uint32 `json:",omitempty"`
+}
+
+// ContractVersionKey returns the key of a code version in the state of a contract
+func ContractVersionKey(version uint32) string {
+	return fmt.Sprintf("%s.%d", params.ContractVersionKey, version)
+}
+
+// contractTypes are the contract types of the contract init transactions
+var contractTypes = map[pb.TransactionType]string{
+	pb.TransactionType_LuaContractInit: "luavm",
+	pb.TransactionType_JSContractInit:  "jsvm",
+}
+
+// decodeContractState decodes a json contract state into v, false if the state is absent
+func decodeContractState(value []byte, v interface{}) (bool, error) {
+	if value == nil {
+		return false, nil
+	}
+	data, err := DoContractStateData(value)
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+func (tx *TXRWSet) getContractState(addr, key string, v interface{}) (bool, error) {
+	value, err := tx.GetChainCodeState(addr, key, false)
+	if err != nil {
+		return false, err
+	}
+	return decodeContractState(value, v)
+}
+
+func (tx *TXRWSet) setContractState(addr, key string, v interface{}) error {
+	buf, err := ConcrateStateJson(v)
+	if err != nil {
+		return err
+	}
+	return tx.SetChainCodeState(addr, key, buf.Bytes())
+}
+
+// getContract returns the info and current code of the contract at addr, nil code if no contract is deployed there.
+// The global contract has no recorded owner and its code is version 1
+func (tx *TXRWSet) getContract(addr string) (*ContractInfo, *ContractVersion, error) {
+	code := &ContractVersion{}
+	if ok, err := tx.getContractState(addr, params.ContractCodeKey, code); !ok || err != nil {
+		return nil, nil, err
+	}
+	info := &ContractInfo{Version: 1}
+	if _, err := tx.getContractState(addr, params.ContractInfoKey, info); err != nil {
+		return nil, nil, err
+	}
+	return info, code, nil
+}
+
+// checkActive returns an error if the contract at addr is decommissioned
+func (tx *TXRWSet) checkActive(addr string) error {
+	info := &ContractInfo{}
+	if _, err := tx.getContractState(addr, params.ContractInfoKey, info); err != nil {
+		return err
+	}
+	if info.Decommissioned != 0 {
+		return fmt.Errorf("contract %s is decommissioned since block %d", addr, info.Decommissioned)
+	}
+	return nil
+}
+
+// checkContractKey returns an error if the executing contract may not write key. The info and
+// code versions of a contract are only written by its deployment, upgrades and decommissioning
+func (tx *TXRWSet) checkContractKey(key string) error {
+	_, isInit := contractTypes[tx.contractTx().GetType()]
+	if key == params.ContractInfoKey || strings.HasPrefix(key, params.ContractVersionKey) ||
+		(key == params.ContractCodeKey && !isInit) {
+		return fmt.Errorf("contract state key %s is reserved", key)
+	}
+	return nil
+}
+
+// checkContractOwner returns an error unless the sender of ttx owns the contract or has the contract admin permission
+func (tx *TXRWSet) checkContractOwner(ttx *pb.Transaction, info *ContractInfo) error {
+	if ttx.Sender() == info.Owner {
+		return nil
+	}
+	return tx.verifyPermission(params.ContractAdminKey)
+}
+
+// updateContract records the owner and first code version of a deployed contract, upgrades or decommissions a contract
+// and checks that an invoked contract isn't decommissioned
+func (tx *TXRWSet) updateContract(ttx *pb.Transaction) error {
+	addr := ttx.Recipient().String()
+	switch ttx.GetType() {
+	case pb.TransactionType_LuaContractInit, pb.TransactionType_JSContractInit:
+		// the global contract is replaced through the global state
+		if len(ttx.GetContractSpec().GetAddr()) == 0 {
+			return nil
+		}
+		return tx.deployContract(ttx, addr)
+	case pb.TransactionType_ContractInvoke:
+		return tx.checkActive(addr)
+	case pb.TransactionType_ContractUpgrade:
+		return tx.upgradeContract(ttx, addr)
+	case pb.TransactionType_ContractDecommission:
+		return tx.decommissionContract(ttx, addr)
+	}
+	return nil
+}
+
+func (tx *TXRWSet) deployContract(ttx *pb.Transaction, addr string) error {
+	if _, code, err := tx.getContract(addr); err != nil {
+		return err
+	} else if code != nil {
+		return fmt.Errorf("contract %s is already deployed, it can only be upgraded", addr)
+	}
+
+	version := &ContractVersion{Code: ttx.GetContractSpec().GetCode(), Type: contractTypes[ttx.GetType()], Height: tx.block.BlockIndex}
+	if err := tx.setContractState(addr, ContractVersionKey(1), version); err != nil {
+		return err
+	}
+	return tx.setContractState(addr, params.ContractInfoKey, &ContractInfo{Owner: ttx.Sender(), Version: 1})
+}
+
+func (tx *TXRWSet) upgradeContract(ttx *pb.Transaction, addr string) error {
+	info, code, err := tx.getContract(addr)
+	if err != nil {
+		return err
+	}
+	if code == nil {
+		return fmt.Errorf("contract %s isn't deployed", addr)
+	}
+	if info.Decommissioned != 0 {
+		return fmt.Errorf("contract %s is decommissioned since block %d", addr, info.Decommissioned)
+	}
+	if err := tx.checkContractOwner(ttx, info); err != nil {
+		return err
+	}
+
+	if info.Version == 1 {
+		// the first version of a contract deployed before code versions were recorded
+		if ok, err := tx.getContractState(addr, ContractVersionKey(1), &ContractVersion{}); err != nil {
+			return err
+		} else if !ok {
+			if err := tx.setContractState(addr, ContractVersionKey(1), code); err != nil {
+				return err
+			}
+		}
+	}
+
+	info.Version++
+	version := &ContractVersion{Code: ttx.GetContractSpec().GetCode(), Type: code.Type, Height: tx.block.BlockIndex}
+	if err := tx.setContractState(addr, ContractVersionKey(info.Version), version); err != nil {
+		return err
+	}
+	if err := tx.setContractState(addr, params.ContractCodeKey, &ContractVersion{Code: version.Code, Type: version.Type}); err != nil {
+		return err
+	}
+	return tx.setContractState(addr, params.ContractInfoKey, info)
+}
+
+func (tx *TXRWSet) decommissionContract(ttx *pb.Transaction, addr string) error {
+	info, code, err := tx.getContract(addr)
+	if err != nil {
+		return err
+	}
+	if code == nil {
+		return fmt.Errorf("contract %s isn't deployed", addr)
+	}
+	if info.Decommissioned != 0 {
+		return fmt.Errorf("contract %s is decommissioned since block %d", addr, info.Decommissioned)
+	}
+	if err := tx.checkContractOwner(ttx, info); err != nil {
+		return err
+	}
+
+	info.Decommissioned = tx.block.BlockIndex
+	return tx.setContractState(addr, params.ContractInfoKey, info)
+}
+
+// GetContract returns the committed info and code versions of the contract at addr, nil if no contract is deployed there
+func (blk *BLKRWSet) GetContract(addr string) (*ContractInfo, []*ContractVersion, error) {
+	get := func(key string, v interface{}) (bool, error) {
+		value, err := blk.GetChainCodeState(addr, key, true)
+		if err != nil {
+			return false, err
+		}
+		return decodeContractState(value, v)
+	}
+
+	code := &ContractVersion{}
+	if ok, err := get(params.ContractCodeKey, code); !ok || err != nil {
+		return nil, nil, err
+	}
+	info := &ContractInfo{Version: 1}
+	if _, err := get(params.ContractInfoKey, info); err != nil {
+		return nil, nil, err
+	}
+
+	versions := make([]*ContractVersion, 0, info.Version)
+	for v := uint32(1); v <= info.Version; v++ {
+		version := &ContractVersion{}
+		ok, err := get(ContractVersionKey(v), version)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			// a contract deployed before code versions were recorded
+			version = code
+		}
+		versions = append(versions, version)
+	}
+	return info, versions, nil
+}
@@ -0,0 +1,97 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/zipper-project/zipper/common/log"
+)
+
+// Supply is the supply of an asset, Total is nil for assets issued before supply tracking
+type Supply struct {
+	AssetID     uint32   `json:"assetId"`
+	Total       *big.Int `json:"total"`
+	Max         *big.Int `json:"max"`
+	Circulating *big.Int `json:"circulating"` // the total less the balance of the owner
+}
+
+// SupplyMismatch is an asset whose balances don't sum to its recorded supply
+type SupplyMismatch struct {
+	AssetID  uint32
+	Recorded *big.Int
+	Balances *big.Int
+}
+
+// GetSupply returns the supply of an asset, nil if the asset doesn't exist
+func (ledger *Ledger) GetSupply(assetID uint32) (*Supply, error) {
+	asset, err := ledger.GetAsset(assetID)
+	if err != nil || asset == nil {
+		return nil, err
+	}
+	supply := &Supply{AssetID: assetID, Total: asset.TotalSupply, Max: asset.MaxSupply}
+	if asset.TotalSupply != nil {
+		owned, err := ledger.state.GetBalanceState(asset.Owner.String(), assetID, true)
+		if err != nil {
+			return nil, err
+		}
+		supply.Circulating = new(big.Int).Sub(asset.TotalSupply, owned)
+	}
+	return supply, nil
+}
+
+// CheckSupply checks that the balances of every asset with a recorded supply sum to it,
+// it returns the assets that don't
+func (ledger *Ledger) CheckSupply() ([]*SupplyMismatch, error) {
+	assets, err := ledger.state.GetAssetStates(true)
+	if err != nil {
+		return nil, err
+	}
+	sums, err := ledger.state.SumBalances()
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []*SupplyMismatch
+	for id, asset := range assets {
+		if asset.TotalSupply == nil {
+			continue
+		}
+		sum, ok := sums[id]
+		if !ok {
+			sum = new(big.Int)
+		}
+		if sum.Cmp(asset.TotalSupply) != 0 {
+			mismatches = append(mismatches, &SupplyMismatch{AssetID: id, Recorded: asset.TotalSupply, Balances: sum})
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool { return mismatches[i].AssetID < mismatches[j].AssetID })
+	return mismatches, nil
+}
+
+// logSupplyMismatches logs the assets whose balances don't sum to their supply after the block at height
+func (ledger *Ledger) logSupplyMismatches(height uint32) {
+	mismatches, err := ledger.CheckSupply()
+	if err != nil {
+		log.Errorf("supply check of block [%d] failed -- %s", height, err)
+		return
+	}
+	for _, m := range mismatches {
+		log.Errorf("supply check of block [%d] -- balances of asset %d sum to %s, its supply is %s", height, m.AssetID, m.Balances, m.Recorded)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger/state"
+	pb "github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+func newSupplyTx(keypair *crypto.PrivateKey, tp pb.TransactionType, nonce uint32, recipient account.Address, amount int64, payload map[string]interface{}) *pb.Transaction {
+	tx := pb.NewTransaction(account.NewChainCoordinate([]byte{byte(0)}),
+		account.NewChainCoordinate([]byte{byte(0)}),
+		tp,
+		nonce,
+		account.PublicKeyToAddress(*keypair.Public()),
+		recipient,
+		uint32(9),
+		big.NewInt(amount),
+		big.NewInt(fee),
+		utils.CurrentTimestamp())
+	if payload != nil {
+		tx.Payload, _ = json.Marshal(payload)
+	}
+	signature, _ := keypair.Sign(tx.Hash().Bytes())
+	tx.GetHeader().Signature = signature.Bytes()
+	return tx
+}
+
+func TestSupply(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	testDb := db.NewMemDB(db.DefaultConfig())
+	li := NewLedger(testDb)
+
+	issuer, _ := crypto.GenerateKey()
+	owner, _ := crypto.GenerateKey()
+	ownerAddr := account.PublicKeyToAddress(*owner.Public())
+
+	blocks := [][]*pb.Transaction{
+		{newSupplyTx(issuer, pb.TransactionType_Issue, 0, ownerAddr, issueAmount, map[string]interface{}{"id": 9, "maxSupply": 150})},
+		{newSupplyTx(owner, pb.TransactionType_Mint, 0, issueReciepent, 40, nil)},
+		{newSupplyTx(owner, pb.TransactionType_Burn, 1, ownerAddr, 30, nil)},
+		{
+			newSupplyTx(issuer, pb.TransactionType_Mint, 1, issueReciepent, 1, nil),
+			newSupplyTx(owner, pb.TransactionType_Mint, 2, issueReciepent, 41, nil),
+			newSupplyTx(owner, pb.TransactionType_Burn, 3, ownerAddr, 71, nil),
+		},
+	}
+	for i, txs := range blocks {
+		header := &pb.BlockHeader{Version: pb.BlockVersion, Height: uint32(i + 1)}
+		if err := li.AppendBlock(&pb.Block{Transactions: txs, Header: header}, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tx := range blocks[3] {
+		receipt, err := li.GetReceipt(tx.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.AssertEquals(t, receipt.Success, false)
+	}
+
+	supply, err := li.GetSupply(9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, supply.Total.String(), "110")
+	utils.AssertEquals(t, supply.Max.String(), "150")
+	utils.AssertEquals(t, supply.Circulating.String(), "40")
+
+	mismatches, err := li.CheckSupply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, len(mismatches), 0)
+
+	testDb.Put("balance", []byte(state.BalanceKey(issueReciepent.String(), 9)), state.EncodeAmount(big.NewInt(45)))
+	mismatches, err = li.CheckSupply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, len(mismatches), 1)
+	utils.AssertEquals(t, mismatches[0].Balances.String(), "115")
+}
+
+func TestSelfTransferSupply(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	li := NewLedger(db.NewMemDB(db.DefaultConfig()))
+
+	issuer, _ := crypto.GenerateKey()
+	owner, _ := crypto.GenerateKey()
+	ownerAddr := account.PublicKeyToAddress(*owner.Public())
+	txs := []*pb.Transaction{
+		newSupplyTx(issuer, pb.TransactionType_Issue, 0, ownerAddr, issueAmount, map[string]interface{}{"id": 9}),
+		newSupplyTx(owner, pb.TransactionType_Atomic, 0, ownerAddr, 30, nil),
+	}
+	if err := li.AppendBlock(&pb.Block{Transactions: txs, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: 1}}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := li.GetBalance(ownerAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, b.Get(9).Int64(), issueAmount)
+
+	mismatches, err := li.CheckSupply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, len(mismatches), 0)
+}
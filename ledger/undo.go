@@ -0,0 +1,96 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+)
+
+const undoColumnFamily = "undo"
+
+// undoEntry is the value a key had before a block was appended, Exists is false if the key was absent
+type undoEntry struct {
+	CfName string `enc:"1"`
+	Key    []byte `enc:"2"`
+	Value  []byte `enc:"3"`
+	Exists bool   `enc:"4"`
+}
+
+// undoJournal returns the write batch storing the prior values of every key the block writes
+func (ledger *Ledger) undoJournal(height uint32, writeBatches []*db.WriteBatch) (*db.WriteBatch, error) {
+	seen := make(map[string]bool)
+	entries := make([]*undoEntry, 0, len(writeBatches))
+	for _, wb := range writeBatches {
+		k := wb.CfName + "\x00" + string(wb.Key)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		value, err := ledger.dbHandler.Get(wb.CfName, wb.Key)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &undoEntry{
+			CfName: wb.CfName,
+			Key:    wb.Key,
+			Value:  value,
+			Exists: value != nil,
+		})
+	}
+	return db.NewWriteBatch(undoColumnFamily, db.OperationPut, utils.Uint32ToBytes(height), utils.Serialize(entries), undoColumnFamily), nil
+}
+
+// Rollback reverts the blocks above toHeight using their undo journals, state and chain height are restored in one atomic write
+func (ledger *Ledger) Rollback(toHeight uint32) error {
+	height, err := ledger.Height()
+	if err != nil {
+		return err
+	}
+	if toHeight >= height {
+		return fmt.Errorf("rollback height %d isn't below the current height %d", toHeight, height)
+	}
+
+	var writeBatches []*db.WriteBatch
+	for h := height; h > toHeight; h-- {
+		data, err := ledger.dbHandler.Get(undoColumnFamily, utils.Uint32ToBytes(h))
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			return fmt.Errorf("block %d has no undo journal", h)
+		}
+		var entries []*undoEntry
+		if err := utils.Deserialize(data, &entries); err != nil {
+			return fmt.Errorf("block %d has a corrupt undo journal -- %s", h, err)
+		}
+
+		// journals are applied from the top block down, so the oldest prior value of a key is written last
+		for _, entry := range entries {
+			if entry.Exists {
+				writeBatches = append(writeBatches, db.NewWriteBatch(entry.CfName, db.OperationPut, entry.Key, entry.Value, entry.CfName))
+			} else {
+				writeBatches = append(writeBatches, db.NewWriteBatch(entry.CfName, db.OperationDelete, entry.Key, nil, entry.CfName))
+			}
+		}
+		writeBatches = append(writeBatches, db.NewWriteBatch(undoColumnFamily, db.OperationDelete, utils.Uint32ToBytes(h), nil, undoColumnFamily))
+	}
+	return ledger.dbHandler.AtomicWrite(writeBatches)
+}
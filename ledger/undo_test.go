@@ -0,0 +1,101 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	pb "github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+func TestRollback(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	testDb := db.NewMemDB(db.DefaultConfig())
+	li := NewLedger(testDb)
+
+	height, err := li.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := li.GetBlockByNumber(height)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keypair, _ := crypto.GenerateKey()
+	issueTx := pb.NewTransaction(account.NewChainCoordinate([]byte{byte(0)}),
+		account.NewChainCoordinate([]byte{byte(0)}),
+		pb.TransactionType_Issue,
+		uint32(0),
+		account.PublicKeyToAddress(*keypair.Public()),
+		backfrontReciepent,
+		uint32(7),
+		big.NewInt(issueAmount),
+		big.NewInt(fee),
+		utils.CurrentTimestamp())
+	issueTx.Payload, _ = json.Marshal(map[string]interface{}{"id": 7})
+	signature, _ := keypair.Sign(issueTx.Hash().Bytes())
+	issueTx.GetHeader().Signature = signature.Bytes()
+
+	for i := uint32(1); i <= 2; i++ {
+		txs := []*pb.Transaction{}
+		if i == 1 {
+			txs = append(txs, issueTx)
+		}
+		if err := li.AppendBlock(&pb.Block{Transactions: txs, Header: &pb.BlockHeader{Height: height + i}}, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := li.GetBalance(backfrontReciepent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, b.Get(7).Int64(), issueAmount)
+
+	if err := li.Rollback(height + 2); err == nil {
+		t.Error("rollback to the current height accepted")
+	}
+	if err := li.Rollback(height); err != nil {
+		t.Fatal(err)
+	}
+
+	newHeight, err := li.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, newHeight, height)
+	b, err = li.GetBalance(backfrontReciepent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, b.Get(7).Int64(), int64(0))
+	if _, err := li.GetTxByTxHash(issueTx.Hash().Bytes()); err == nil {
+		t.Error("transaction of a rolled back block found")
+	}
+	root, _, err := li.state.CommittedRootHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, root.String(), header.StateHash)
+}
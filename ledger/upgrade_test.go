@@ -0,0 +1,166 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger/state"
+	pb "github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+var counterContract = `
+local ZIP = require("ZIP")
+
+function Init(args)
+    ZIP.PutState("count", "0")
+    return true
+end
+
+function Invoke(func, args)
+    ZIP.PutState("count", tostring(tonumber(ZIP.GetState("count")) + STEP))
+    return true
+end
+
+function Query(args)
+    return ZIP.GetState(args[0]) or ""
+end
+`
+
+var counterMigrate = `
+function Migrate(args)
+    ZIP.PutState("migrated", args[0])
+    return args[0] ~= "no"
+end
+`
+
+var counterHijack = `
+local ZIP = require("ZIP")
+
+function Invoke(func, args)
+    ZIP.PutState("__CONTRACT_INFO_KEY__", "{}")
+    return true
+end
+`
+
+var counterCaller = `
+function Init(args) {
+    return true;
+}
+
+function Invoke(func, args) {
+    return ZIP.Call("COUNTER", "inc", []);
+}
+`
+
+func TestContractUpgrade(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	li := NewLedger(db.NewMemDB(db.DefaultConfig()))
+	counter := account.HexToAddress("0xc532277be213f56221b6140998c03d860a60e1f8")
+	caller := account.HexToAddress("0xc632277be213f56221b6140998c03d860a60e1f8")
+	other := account.HexToAddress("0xc732277be213f56221b6140998c03d860a60e1f8")
+
+	nonces := make(map[account.Address]uint32)
+	height := uint32(0)
+	execute := func(sender account.Address, tp pb.TransactionType, addr account.Address, code string, args ...string) bool {
+		tx := newContractTx(tp, addr, code, args...)
+		tx.Header.Sender = sender.String()
+		tx.Header.Nonce = nonces[sender]
+		nonces[sender]++
+		height++
+		if err := li.AppendBlock(&pb.Block{Transactions: []*pb.Transaction{tx}, Header: &pb.BlockHeader{Version: pb.BlockVersion, Height: height}}, true); err != nil {
+			t.Fatal(err)
+		}
+		receipt, err := li.GetReceipt(tx.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return receipt.Success
+	}
+	query := func(key string) string {
+		result, err := li.QueryContract(newContractTx(pb.TransactionType_ContractQuery, counter, "", key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(result)
+	}
+	v1 := strings.Replace(counterContract, "STEP", "1", -1)
+	v2 := strings.Replace(counterContract, "STEP", "2", -1) + counterMigrate
+
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_LuaContractInit, counter, v1), true)
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_JSContractInit, caller, strings.Replace(counterCaller, "COUNTER", counter.String(), -1)), true)
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_ContractInvoke, counter, "", "inc"), true)
+	utils.AssertEquals(t, query("count"), "1")
+
+	// only the owner or the admin upgrades, a deployed contract can't be deployed again
+	utils.AssertEquals(t, execute(other, pb.TransactionType_ContractUpgrade, counter, v2, "yes"), false)
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_LuaContractInit, counter, v2), false)
+
+	// Migrate runs with the state of the old code and has to succeed
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_ContractUpgrade, counter, v2, "no"), false)
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_ContractUpgrade, counter, v2, "yes"), true)
+	utils.AssertEquals(t, query("migrated"), "yes")
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_ContractInvoke, counter, "", "inc"), true)
+	utils.AssertEquals(t, query("count"), "3")
+
+	// code without Migrate, the reserved keys can't be written by the contract
+	utils.AssertEquals(t, execute(state.DefaultAdminAddr, pb.TransactionType_ContractUpgrade, counter, counterHijack), true)
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_ContractInvoke, counter, "", "inc"), false)
+
+	info, versions, err := li.GetContract(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, info.Owner, issueReciepent)
+	utils.AssertEquals(t, info.Version, uint32(3))
+	utils.AssertEquals(t, len(versions), 3)
+	utils.AssertEquals(t, string(versions[0].Code), v1)
+	utils.AssertEquals(t, versions[0].Height, uint32(1))
+	utils.AssertEquals(t, string(versions[1].Code), v2)
+	utils.AssertEquals(t, versions[1].Height, uint32(7))
+	utils.AssertEquals(t, string(versions[2].Code), counterHijack)
+	utils.AssertEquals(t, versions[2].Type, "luavm")
+
+	// a decommissioned contract can't be invoked, called or upgraded, its state stays readable
+	utils.AssertEquals(t, execute(other, pb.TransactionType_ContractDecommission, counter, ""), false)
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_ContractUpgrade, counter, v2, "yes"), true)
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_ContractInvoke, caller, "", "inc"), true)
+	utils.AssertEquals(t, query("count"), "5")
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_ContractDecommission, counter, ""), true)
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_ContractInvoke, counter, "", "inc"), false)
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_ContractInvoke, caller, "", "inc"), false)
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_ContractUpgrade, counter, v1), false)
+	utils.AssertEquals(t, query("count"), "5")
+
+	info, versions, err = li.GetContract(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, info.Version, uint32(4))
+	utils.AssertEquals(t, info.Decommissioned, uint32(14))
+	utils.AssertEquals(t, len(versions), 4)
+
+	// js contracts migrate the same way
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_ContractUpgrade, caller, "function Migrate(args) { return false; }"), false)
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_ContractUpgrade, caller, "function Invoke(func, args) { return true; }"), true)
+	utils.AssertEquals(t, execute(issueReciepent, pb.TransactionType_ContractInvoke, caller, "", "inc"), true)
+}
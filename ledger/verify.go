@@ -0,0 +1,164 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/log"
+	pb "github.com/zipper-project/zipper/proto"
+)
+
+// StateDiff is a state key whose stored value differs from the re-executed one, a nil value means the key is absent
+type StateDiff struct {
+	CfName     string
+	Key        []byte
+	Stored     []byte
+	Recomputed []byte
+}
+
+// Divergence describes the first block whose re-execution doesn't match the stored chain
+type Divergence struct {
+	Height     uint32
+	Field      string
+	Stored     string
+	Recomputed string
+	Keys       []*StateDiff
+}
+
+// Reexecute replays every block from the chain genesis into a scratch in-memory ledger and compares the recomputed
+// TxsMerkleHash and StateHash of each block with the stored header, then the replayed state with the stored one.
+// It returns the first divergence, nil if the chain matches
+func (ledger *Ledger) Reexecute() (*Divergence, error) {
+	if base := ledger.block.GetBaseHeight(); base > 0 {
+		return nil, fmt.Errorf("chain imported from a snapshot at height %d can't be re-executed from genesis", base)
+	}
+	height, err := ledger.Height()
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := ledger.Genesis()
+	if err != nil {
+		return nil, err
+	}
+	scratchDB := db.NewMemDB(db.DefaultConfig())
+	if _, err := InitGenesis(scratchDB, g); err != nil {
+		return nil, err
+	}
+	scratch := NewLedger(scratchDB)
+	genesis, err := ledger.GetGenesisBlock()
+	if err != nil {
+		return nil, err
+	}
+	replayedGenesis, err := scratch.GetGenesisBlock()
+	if err != nil {
+		return nil, err
+	}
+	if d, err := ledger.compareHeader(scratch, replayedGenesis, genesis, height); d != nil || err != nil {
+		return d, err
+	}
+
+	for h := uint32(1); h <= height; h++ {
+		header, err := ledger.block.GetBlockByNumber(h)
+		if err != nil {
+			return nil, fmt.Errorf("block [%d] can't be read -- %s", h, err)
+		}
+		txs, err := ledger.block.GetTransactionsByNumber(h, 100)
+		if err != nil {
+			return nil, fmt.Errorf("transactions of block [%d] can't be read -- %s", h, err)
+		}
+
+		replayed := *header
+		if err := scratch.AppendBlock(&pb.Block{Header: &replayed, Transactions: txs}, false); err != nil {
+			return nil, fmt.Errorf("block [%d] can't be re-executed -- %s", h, err)
+		}
+		if d, err := ledger.compareHeader(scratch, &replayed, header, height); d != nil || err != nil {
+			return d, err
+		}
+	}
+
+	// the headers match, the stored state may still have been corrupted after it was committed
+	keys, err := ledger.stateDiff(scratch, height, height)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	return &Divergence{Height: height, Field: "state", Keys: keys}, nil
+}
+
+// compareHeader returns the divergence of the replayed header of a block from the stored one
+func (ledger *Ledger) compareHeader(scratch *Ledger, replayed, stored *pb.BlockHeader, current uint32) (*Divergence, error) {
+	if replayed.TxsMerkleHash != stored.TxsMerkleHash {
+		return &Divergence{Height: stored.Height, Field: "TxsMerkleHash", Stored: stored.TxsMerkleHash, Recomputed: replayed.TxsMerkleHash}, nil
+	}
+	if replayed.StateHash != stored.StateHash {
+		d := &Divergence{Height: stored.Height, Field: "StateHash", Stored: stored.StateHash, Recomputed: replayed.StateHash}
+		keys, err := ledger.stateDiff(scratch, stored.Height, current)
+		if err != nil {
+			log.Warnf("Reexecute -- the stored state of block [%d] can't be rebuilt, %s", stored.Height, err)
+		}
+		d.Keys = keys
+		return d, nil
+	}
+	return nil, nil
+}
+
+// stateDiff returns the state keys whose stored value at height differs from the current value in scratch
+func (ledger *Ledger) stateDiff(scratch *Ledger, height, current uint32) ([]*StateDiff, error) {
+	stored := make(map[string]*StateDiff)
+	err := ledger.forEachStateAt(height, current, func(cf string, key, value []byte) error {
+		stored[cf+"\x00"+string(key)] = &StateDiff{CfName: cf, Key: key, Stored: value}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []*StateDiff
+	err = scratch.forEachStateAt(height, height, func(cf string, key, value []byte) error {
+		k := cf + "\x00" + string(key)
+		d, ok := stored[k]
+		if ok {
+			delete(stored, k)
+			if bytes.Equal(d.Stored, value) {
+				return nil
+			}
+		} else {
+			d = &StateDiff{CfName: cf, Key: key}
+		}
+		d.Recomputed = value
+		diffs = append(diffs, d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range stored {
+		diffs = append(diffs, d)
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].CfName != diffs[j].CfName {
+			return diffs[i].CfName < diffs[j].CfName
+		}
+		return bytes.Compare(diffs[i].Key, diffs[j].Key) < 0
+	})
+	return diffs, nil
+}
@@ -0,0 +1,121 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package ledger
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger/state"
+	pb "github.com/zipper-project/zipper/proto"
+	"github.com/zipper-project/zipper/vm"
+)
+
+func TestReexecute(t *testing.T) {
+	vm.VMConf = vm.DefaultConfig()
+	testDb := db.NewMemDB(db.DefaultConfig())
+	li := NewLedger(testDb)
+
+	height, err := li.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keypair, _ := crypto.GenerateKey()
+	issueTx := pb.NewTransaction(account.NewChainCoordinate([]byte{byte(0)}),
+		account.NewChainCoordinate([]byte{byte(0)}),
+		pb.TransactionType_Issue,
+		uint32(0),
+		account.PublicKeyToAddress(*keypair.Public()),
+		backfrontReciepent,
+		uint32(10),
+		big.NewInt(issueAmount),
+		big.NewInt(fee),
+		utils.CurrentTimestamp())
+	issueTx.Payload, _ = json.Marshal(map[string]interface{}{"id": 10})
+	signature, _ := keypair.Sign(issueTx.Hash().Bytes())
+	issueTx.GetHeader().Signature = signature.Bytes()
+
+	for i := uint32(1); i <= 2; i++ {
+		txs := []*pb.Transaction{}
+		if i == 1 {
+			txs = append(txs, issueTx)
+		}
+		previous, err := li.GetBlockByNumber(height + i - 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := li.AppendBlock(&pb.Block{Transactions: txs, Header: &pb.BlockHeader{Height: height + i, PreviousHash: previous.Hash().String()}}, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := li.VerifyChain(); err != nil {
+		t.Fatal(err)
+	}
+	d, err := li.Reexecute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != nil {
+		t.Fatalf("divergence in an intact chain: %+v", d)
+	}
+
+	// corrupt the committed balance
+	balanceKey := state.BalanceKey(backfrontReciepent.String(), 10)
+	if err := testDb.Put("balance", []byte(balanceKey), utils.Serialize(issueAmount+1)); err != nil {
+		t.Fatal(err)
+	}
+	d, err = li.Reexecute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, d.Height, height+2)
+	utils.AssertEquals(t, d.Field, "state")
+	utils.AssertEquals(t, len(d.Keys), 1)
+	utils.AssertEquals(t, string(d.Keys[0].Key), balanceKey)
+	utils.AssertEquals(t, d.Keys[0].Recomputed, utils.Serialize(issueAmount))
+
+	// corrupt the state hash of a stored header
+	header, err := li.GetBlockByNumber(height + 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := header.StateHash
+	header.StateHash = crypto.Hash{}.String()
+	hash, err := li.block.GetBlockHashByNumber(height + 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testDb.Put("block", hash, header.Serialize()); err != nil {
+		t.Fatal(err)
+	}
+	if err := li.VerifyChain(); err == nil {
+		t.Error("tampered header links to the chain")
+	}
+	d, err = li.Reexecute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, d.Height, height+1)
+	utils.AssertEquals(t, d.Field, "StateHash")
+	utils.AssertEquals(t, d.Recomputed, stored)
+}
@@ -18,6 +18,7 @@
 package node
 
 import (
+	"fmt"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
@@ -43,10 +44,9 @@ type Node struct {
 }
 
 // NewNode returns node daemon instance
-func NewNode(cfgFile string) *Node {
+func NewNode(cfgFile string) (*Node, error) {
 	if err := config.ReadInConfig(cfgFile); err != nil {
-		log.Panicf("loadConfig error %v", err)
-		return nil
+		return nil, fmt.Errorf("loadConfig error %v", err)
 	}
 
 	cfg := config.NodeOption()
@@ -55,15 +55,19 @@ func NewNode(cfgFile string) *Node {
 	//	log.SetOutput(os.Stdout)
 	config.VMConfig(cfg.LogFile, cfg.LogLevel)
 	pm := protoManager.NewProtoManager()
+	bc, err := blockchain.NewBlockchain(pm)
+	if err != nil {
+		return nil, err
+	}
 	node := &Node{
-		bc:  blockchain.NewBlockchain(pm),
+		bc:  bc,
 		cfg: cfg,
 	}
 
 	pm.SetBlockChain(node.bc)
 	pm.RegisterWorker(proto.ProtoID_ConsensusWorker, consensus.GetConsensusWorkers(1, node.bc.GetConsenter()))
 	pm.RegisterWorker(proto.ProtoID_SyncWorker, blocksync.GetSyncWorkers(1, node.bc))
-	return node
+	return node, nil
 }
 
 // Start starts the blockchain service
@@ -30,4 +30,28 @@ const (
 
 	// SecurityContractKey is the key of security plugin name / security contract address.
 	SecurityContractKey = "securityContract"
+
+	// ChainIDKey is the key of the chain coordinate set by the genesis.
+	ChainIDKey = "chainId"
+
+	// ValidatorsKey is the key of the consensus validator list set by the genesis.
+	ValidatorsKey = "validators"
+
+	// FeePolicyKey is the key of the fee policy.
+	FeePolicyKey = "feePolicy"
+
+	// NonceStateKey is the namespace of the account nonces.
+	NonceStateKey = "nonceStateKey"
+
+	// ContractCodeKey is the key of the current code of a contract in its state.
+	ContractCodeKey = "__CONTRACT_CODE_KEY__"
+
+	// ContractInfoKey is the key of the owner, version and decommission height of a contract in its state.
+	ContractInfoKey = "__CONTRACT_INFO_KEY__"
+
+	// ContractVersionKey is the prefix of the keys of the code versions of a contract in its state.
+	ContractVersionKey = "__CONTRACT_VERSION_KEY__"
+
+	// ContractAdminKey is the permission to upgrade and decommission contracts of other owners.
+	ContractAdminKey = "contractAdmin"
 )
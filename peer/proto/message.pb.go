@@ -120,6 +120,7 @@ type HandShake struct {
 	Type      uint32 `protobuf:"varint,5,opt,name=type" json:"type,omitempty"`
 	Signature []byte `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
 	Cert      []byte `protobuf:"bytes,7,opt,name=cert,proto3" json:"cert,omitempty"`
+	Genesis   []byte `protobuf:"bytes,8,opt,name=genesis,proto3" json:"genesis,omitempty"`
 }
 
 func (m *HandShake) Reset()                    { *m = HandShake{} }
@@ -176,6 +177,13 @@ func (m *HandShake) GetCert() []byte {
 	return nil
 }
 
+func (m *HandShake) GetGenesis() []byte {
+	if m != nil {
+		return m.Genesis
+	}
+	return nil
+}
+
 func init() {
 	proto1.RegisterType((*Header)(nil), "proto.Header")
 	proto1.RegisterType((*Message)(nil), "proto.Message")
@@ -185,21 +193,22 @@ func init() {
 func init() { proto1.RegisterFile("message.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 255 bytes of a gzipped FileDescriptorProto
+	// 263 bytes of a gzipped FileDescriptorProto
 	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x44, 0x8f, 0x41, 0x4e, 0xc3, 0x30,
-	0x10, 0x45, 0xd5, 0xd0, 0x24, 0xf2, 0xd0, 0xb0, 0xb0, 0x10, 0xf2, 0x82, 0x05, 0x8a, 0x84, 0xc4,
-	0xaa, 0x0b, 0xb8, 0x42, 0x17, 0x2d, 0x12, 0x2c, 0xcc, 0x09, 0x86, 0x7a, 0x48, 0x2d, 0x48, 0x52,
-	0xec, 0x14, 0xa9, 0x47, 0xe2, 0x96, 0xc8, 0x3f, 0x89, 0x58, 0x79, 0xde, 0x8c, 0xe7, 0xff, 0x3f,
-	0x54, 0xb5, 0x12, 0x23, 0x37, 0xb2, 0x3e, 0x86, 0x7e, 0xe8, 0x75, 0x8e, 0xa7, 0xfe, 0xa0, 0x62,
-	0x2b, 0xec, 0x24, 0xe8, 0x6b, 0xca, 0x5b, 0x6e, 0xfc, 0xde, 0x2c, 0xee, 0x16, 0x0f, 0x95, 0x1d,
-	0x41, 0xdf, 0x50, 0x11, 0xe5, 0xfb, 0xf5, 0xd4, 0x9a, 0x0c, 0xed, 0x89, 0xb4, 0xa1, 0x12, 0x02,
-	0xbb, 0x8d, 0xb9, 0xc0, 0x60, 0x46, 0xe8, 0xc4, 0x66, 0xb7, 0x31, 0xcb, 0x49, 0x27, 0x41, 0xfd,
-	0x4c, 0xe5, 0xcb, 0xe8, 0xaf, 0xef, 0xa9, 0x38, 0xc0, 0x12, 0x4e, 0x97, 0x8f, 0xd5, 0x98, 0x68,
-	0x3d, 0xe6, 0xb0, 0xd3, 0x10, 0x0e, 0x7c, 0xfe, 0xea, 0xd9, 0xc1, 0x7a, 0x65, 0x67, 0xac, 0x7f,
-	0x17, 0xa4, 0xb6, 0xdc, 0xb9, 0xb7, 0x03, 0x7f, 0x8a, 0xd6, 0xb4, 0xec, 0xb8, 0x15, 0x88, 0x29,
-	0x8b, 0x3a, 0xed, 0xfe, 0x48, 0x88, 0xbe, 0xef, 0xb0, 0xab, 0xec, 0x8c, 0xfa, 0x8a, 0x32, 0xef,
-	0x10, 0x79, 0x65, 0x33, 0xef, 0xd2, 0x4f, 0x76, 0x2e, 0x48, 0x8c, 0xc8, 0xab, 0xec, 0x8c, 0x49,
-	0x77, 0x38, 0x1f, 0xc5, 0xe4, 0x38, 0x03, 0xb5, 0xbe, 0x25, 0x15, 0x7d, 0xd3, 0xf1, 0x70, 0x0a,
-	0x62, 0x0a, 0x88, 0xfc, 0x37, 0xd2, 0xc6, 0x5e, 0xc2, 0x60, 0x4a, 0x0c, 0x50, 0xbf, 0x17, 0xb8,
-	0xed, 0xe9, 0x2f, 0x00, 0x00, 0xff, 0xff, 0xcd, 0xc8, 0xaf, 0x3b, 0x7e, 0x01, 0x00, 0x00,
+	0x10, 0x45, 0x95, 0xd0, 0x24, 0x64, 0x68, 0x58, 0x58, 0x08, 0x79, 0xc1, 0x02, 0x45, 0x42, 0x62,
+	0xd5, 0x05, 0x5c, 0xa1, 0x8b, 0x16, 0x09, 0x16, 0xe6, 0x04, 0xa6, 0x1e, 0x52, 0x0b, 0x92, 0x14,
+	0x4f, 0x8a, 0xd4, 0xf3, 0x71, 0x31, 0xe4, 0x9f, 0x44, 0x5d, 0x79, 0xde, 0x8c, 0xe7, 0xff, 0x3f,
+	0x54, 0xb5, 0x2c, 0x62, 0x1b, 0x5e, 0x1d, 0x42, 0x3f, 0xf4, 0x2a, 0xc3, 0x53, 0x7f, 0x52, 0xbe,
+	0x61, 0xeb, 0x38, 0xa8, 0x1b, 0xca, 0x5a, 0xdb, 0xf8, 0x9d, 0x4e, 0xee, 0x93, 0xc7, 0xca, 0x8c,
+	0xa0, 0x6e, 0x29, 0x17, 0xfe, 0x79, 0x3b, 0xb6, 0x3a, 0x45, 0x7b, 0x22, 0xa5, 0xa9, 0x80, 0xc0,
+	0x76, 0xad, 0x2f, 0x30, 0x98, 0x11, 0x3a, 0xd2, 0x6c, 0xd7, 0x7a, 0x31, 0xe9, 0x44, 0xa8, 0x5f,
+	0xa8, 0x78, 0x1d, 0xfd, 0xd5, 0x03, 0xe5, 0x7b, 0x58, 0xc2, 0xe9, 0xea, 0xa9, 0x1a, 0x13, 0xad,
+	0xc6, 0x1c, 0x66, 0x1a, 0xc2, 0xc1, 0x9e, 0xbe, 0x7b, 0xeb, 0x60, 0xbd, 0x34, 0x33, 0xd6, 0x7f,
+	0x09, 0x95, 0x1b, 0xdb, 0xb9, 0xf7, 0xbd, 0xfd, 0x62, 0xa5, 0x68, 0xd1, 0xd9, 0x96, 0x21, 0x56,
+	0x1a, 0xd4, 0x71, 0xf7, 0x97, 0x83, 0xf8, 0xbe, 0xc3, 0x6e, 0x69, 0x66, 0x54, 0xd7, 0x94, 0x7a,
+	0x87, 0xc8, 0x4b, 0x93, 0x7a, 0x17, 0x7f, 0x5a, 0xe7, 0x02, 0x8b, 0x20, 0x6f, 0x69, 0x66, 0x8c,
+	0xba, 0xc3, 0xe9, 0xc0, 0x3a, 0xc3, 0x19, 0xa8, 0xd5, 0x1d, 0x95, 0xe2, 0x9b, 0xce, 0x0e, 0xc7,
+	0xc0, 0x3a, 0x87, 0xc8, 0xb9, 0x11, 0x37, 0x76, 0x1c, 0x06, 0x5d, 0x60, 0x80, 0x3a, 0xea, 0x37,
+	0xdc, 0xb1, 0x78, 0xd1, 0x97, 0xe3, 0x15, 0x13, 0x7e, 0xe4, 0xb8, 0xfa, 0xf9, 0x7f, 0x00, 0x40,
+	0x12, 0xaa, 0xdc, 0x98, 0x01, 0x00, 0x00,
 }
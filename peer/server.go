@@ -45,6 +45,8 @@ type Option struct {
 	ChainID           []byte
 	PeerID            []byte
 	NVP               bool
+	// GenesisHash is the hash of the local genesis block, peers with another genesis are refused
+	GenesisHash []byte
 }
 
 //option defines the default network configuration
@@ -20,6 +20,8 @@ package peer
 import (
 	"testing"
 	"time"
+
+	"github.com/zipper-project/zipper/peer/proto"
 )
 
 func TestServer(t *testing.T) {
@@ -29,3 +31,21 @@ func TestServer(t *testing.T) {
 	time.Sleep(time.Second * 6)
 	srv.Stop()
 }
+
+func TestVerifyHandShakeGenesis(t *testing.T) {
+	defer func(genesis []byte) { option.GenesisHash = genesis }(option.GenesisHash)
+	option.GenesisHash = []byte{1, 2, 3}
+
+	handshake := &proto.HandShake{Name: baseProtocolName, Version: baseProtocolVersion, Id: []byte("remote"), Genesis: []byte{1, 2, 3}}
+	if !verifyHandShake(handshake) {
+		t.Error("handshake with the local genesis refused")
+	}
+	handshake.Genesis = []byte{4, 5, 6}
+	if verifyHandShake(handshake) {
+		t.Error("handshake with another genesis accepted")
+	}
+	handshake.Genesis = nil
+	if verifyHandShake(handshake) {
+		t.Error("handshake without genesis accepted")
+	}
+}
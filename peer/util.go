@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"net"
 
+	"github.com/zipper-project/zipper/common/log"
 	"github.com/zipper-project/zipper/peer/proto"
 )
 
@@ -69,6 +70,7 @@ func GetHandshake() *proto.HandShake {
 		tp = NVP
 	}
 	handshake.Type = tp
+	handshake.Genesis = option.GenesisHash
 
 	// handshake.Cert =
 	// handshake.Signature =
@@ -82,6 +84,10 @@ func verifyHandShake(handshake *proto.HandShake) bool {
 	if bytes.Equal(handshake.Id, option.PeerID) {
 		return false
 	}
+	if len(option.GenesisHash) > 0 && !bytes.Equal(handshake.Genesis, option.GenesisHash) {
+		log.Errorf("handshake --- genesis %x mismatch, local genesis %x", handshake.Genesis, option.GenesisHash)
+		return false
+	}
 	return true
 }
 
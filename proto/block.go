@@ -19,10 +19,22 @@
 package proto
 
 import (
+	"bytes"
+	"fmt"
+	"time"
+
 	"github.com/golang/protobuf/proto"
 	"github.com/zipper-project/zipper/common/crypto"
 )
 
+// BlockVersion is the version of the block headers this node builds and the only version it accepts above the genesis.
+// Blocks of earlier versions predate the chain coordinate, asset supply tracking, account nonces or contract versions,
+// so chains built before them can't be synced or imported
+const BlockVersion uint32 = 4
+
+// MaxBlockTimeDrift is how far a block timestamp may be ahead of the local clock
+var MaxBlockTimeDrift = 2 * time.Minute
+
 // NewBlock returns an new block
 func NewBlock(prvHash, stateHash crypto.Hash,
 	timeStamp, height, nonce uint32,
@@ -37,12 +49,12 @@ func NewBlock(prvHash, stateHash crypto.Hash,
 // NewBlockHeader returns a blockheader
 func NewBlockHeader(prvHash, stateHash crypto.Hash, timeStamp, height, nonce uint32, txsHash crypto.Hash) *BlockHeader {
 	return &BlockHeader{
-		prvHash.String(),
-		stateHash.String(),
-		timeStamp,
-		nonce,
-		txsHash.String(),
-		height,
+		PreviousHash:  prvHash.String(),
+		StateHash:     stateHash.String(),
+		TimeStamp:     timeStamp,
+		Nonce:         nonce,
+		TxsMerkleHash: txsHash.String(),
+		Height:        height,
 	}
 }
 
@@ -63,11 +75,33 @@ func (bh *BlockHeader) Deserialize(data []byte) error {
 	return proto.Unmarshal(data, bh)
 }
 
-// Hash returns the hash of the blockheader
+// Hash returns the hash of the blockheader. Zero fields aren't serialized,
+// so the hash of a version 0 header doesn't depend on the fields added since
 func (bh *BlockHeader) Hash() crypto.Hash {
 	return crypto.DoubleSha256(bh.Serialize())
 }
 
+// Verify checks the header against the header of its parent block, the chain coordinate and the local time.
+// Only the genesis header may have another version than BlockVersion
+func (bh *BlockHeader) Verify(parent *BlockHeader, chainID []byte, now time.Time) error {
+	if bh.Version != BlockVersion {
+		return fmt.Errorf("block %d has unsupported version %d, expected %d", bh.Height, bh.Version, BlockVersion)
+	}
+	if !bytes.Equal(bh.ChainID, chainID) {
+		return fmt.Errorf("block %d is for chain %x, not %x", bh.Height, bh.ChainID, chainID)
+	}
+	if bh.Height != parent.Height+1 {
+		return fmt.Errorf("block height %d doesn't follow the parent height %d", bh.Height, parent.Height)
+	}
+	if bh.TimeStamp <= parent.TimeStamp {
+		return fmt.Errorf("block %d timestamp %d isn't after the parent timestamp %d", bh.Height, bh.TimeStamp, parent.TimeStamp)
+	}
+	if int64(bh.TimeStamp) > now.Add(MaxBlockTimeDrift).Unix() {
+		return fmt.Errorf("block %d timestamp %d is too far in the future", bh.Height, bh.TimeStamp)
+	}
+	return nil
+}
+
 // Serialize block data marshal
 func (b *Block) Serialize() []byte {
 	bytes, _ := proto.Marshal(b)
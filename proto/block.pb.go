@@ -41,12 +41,17 @@ var _ = math.Inf
 const _ = proto1.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type BlockHeader struct {
-	PreviousHash  string `protobuf:"bytes,1,opt,name=previousHash" json:"previousHash,omitempty"`
-	StateHash     string `protobuf:"bytes,2,opt,name=stateHash" json:"stateHash,omitempty"`
-	TimeStamp     uint32 `protobuf:"varint,3,opt,name=timeStamp" json:"timeStamp,omitempty"`
-	Nonce         uint32 `protobuf:"varint,4,opt,name=nonce" json:"nonce,omitempty"`
-	TxsMerkleHash string `protobuf:"bytes,5,opt,name=txsMerkleHash" json:"txsMerkleHash,omitempty"`
-	Height        uint32 `protobuf:"varint,6,opt,name=height" json:"height,omitempty"`
+	PreviousHash      string `protobuf:"bytes,1,opt,name=previousHash" json:"previousHash,omitempty"`
+	StateHash         string `protobuf:"bytes,2,opt,name=stateHash" json:"stateHash,omitempty"`
+	TimeStamp         uint32 `protobuf:"varint,3,opt,name=timeStamp" json:"timeStamp,omitempty"`
+	Nonce             uint32 `protobuf:"varint,4,opt,name=nonce" json:"nonce,omitempty"`
+	TxsMerkleHash     string `protobuf:"bytes,5,opt,name=txsMerkleHash" json:"txsMerkleHash,omitempty"`
+	Height            uint32 `protobuf:"varint,6,opt,name=height" json:"height,omitempty"`
+	ReceiptsRoot      string `protobuf:"bytes,7,opt,name=receiptsRoot" json:"receiptsRoot,omitempty"`
+	Version           uint32 `protobuf:"varint,8,opt,name=version" json:"version,omitempty"`
+	ChainID           []byte `protobuf:"bytes,9,opt,name=chainID,proto3" json:"chainID,omitempty"`
+	Proposer          string `protobuf:"bytes,10,opt,name=proposer" json:"proposer,omitempty"`
+	ConsensusMetaHash string `protobuf:"bytes,11,opt,name=consensusMetaHash" json:"consensusMetaHash,omitempty"`
 }
 
 func (m *BlockHeader) Reset()                    { *m = BlockHeader{} }
@@ -96,6 +101,41 @@ func (m *BlockHeader) GetHeight() uint32 {
 	return 0
 }
 
+func (m *BlockHeader) GetReceiptsRoot() string {
+	if m != nil {
+		return m.ReceiptsRoot
+	}
+	return ""
+}
+
+func (m *BlockHeader) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *BlockHeader) GetChainID() []byte {
+	if m != nil {
+		return m.ChainID
+	}
+	return nil
+}
+
+func (m *BlockHeader) GetProposer() string {
+	if m != nil {
+		return m.Proposer
+	}
+	return ""
+}
+
+func (m *BlockHeader) GetConsensusMetaHash() string {
+	if m != nil {
+		return m.ConsensusMetaHash
+	}
+	return ""
+}
+
 type Block struct {
 	Header       *BlockHeader   `protobuf:"bytes,1,opt,name=header" json:"header,omitempty"`
 	Transactions []*Transaction `protobuf:"bytes,2,rep,name=transactions" json:"transactions,omitempty"`
@@ -128,20 +168,24 @@ func init() {
 func init() { proto1.RegisterFile("block.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 230 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x90, 0xc1, 0x4a, 0xc3, 0x40,
-	0x10, 0x86, 0x49, 0x6b, 0x02, 0x9d, 0xb4, 0x07, 0x07, 0x91, 0x45, 0x3c, 0x84, 0xe0, 0xa1, 0x78,
-	0xe8, 0x41, 0xc1, 0x07, 0xf0, 0xd4, 0x8b, 0x97, 0xe8, 0x0b, 0x6c, 0xe3, 0x60, 0x96, 0xb4, 0xbb,
-	0x61, 0x77, 0x14, 0x5f, 0xcf, 0x37, 0x93, 0x9d, 0x0d, 0x24, 0x39, 0x2d, 0xf3, 0xff, 0xdf, 0xfc,
-	0xec, 0x3f, 0x50, 0x9e, 0xce, 0xae, 0xed, 0x0f, 0x83, 0x77, 0xec, 0x30, 0x97, 0xe7, 0xee, 0x9a,
-	0xbd, 0xb6, 0x41, 0xb7, 0x6c, 0x9c, 0x4d, 0x4e, 0xfd, 0x97, 0x41, 0xf9, 0x1a, 0xc9, 0x23, 0xe9,
-	0x4f, 0xf2, 0x58, 0xc3, 0x76, 0xf0, 0xf4, 0x63, 0xdc, 0x77, 0x38, 0xea, 0xd0, 0xa9, 0xac, 0xca,
-	0xf6, 0x9b, 0x66, 0xa1, 0xe1, 0x3d, 0x6c, 0x02, 0x6b, 0x26, 0x01, 0x56, 0x02, 0x4c, 0x42, 0x74,
-	0xd9, 0x5c, 0xe8, 0x9d, 0xf5, 0x65, 0x50, 0xeb, 0x2a, 0xdb, 0xef, 0x9a, 0x49, 0xc0, 0x1b, 0xc8,
-	0xad, 0xb3, 0x2d, 0xa9, 0x2b, 0x71, 0xd2, 0x80, 0x0f, 0xb0, 0xe3, 0xdf, 0xf0, 0x46, 0xbe, 0x3f,
-	0xa7, 0xd4, 0x5c, 0x52, 0x97, 0x22, 0xde, 0x42, 0xd1, 0x91, 0xf9, 0xea, 0x58, 0x15, 0xb2, 0x3c,
-	0x4e, 0x75, 0x0f, 0xb9, 0x54, 0xc0, 0xc7, 0x08, 0xc4, 0x1a, 0xf2, 0xed, 0xf2, 0x09, 0x53, 0xc9,
-	0xc3, 0xac, 0x60, 0x33, 0x12, 0xf8, 0x02, 0xdb, 0xd9, 0x35, 0x82, 0x5a, 0x55, 0xeb, 0xd9, 0xc6,
-	0xc7, 0x64, 0x35, 0x0b, 0xee, 0x54, 0x08, 0xf0, 0xfc, 0x1f, 0x00, 0x00, 0xff, 0xff, 0x9a, 0xeb,
-	0x81, 0xec, 0x60, 0x01, 0x00, 0x00,
+	// 302 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x91, 0xcf, 0x4e, 0xc2, 0x40,
+	0x10, 0xc6, 0x53, 0xb0, 0xfc, 0x99, 0xc2, 0x81, 0x8d, 0x31, 0x1b, 0xe2, 0xa1, 0x21, 0x1e, 0x88,
+	0x31, 0x1c, 0x34, 0xf1, 0x01, 0x8c, 0x07, 0x3c, 0x70, 0xa9, 0xbe, 0xc0, 0x52, 0x27, 0x76, 0x03,
+	0xec, 0x36, 0x3b, 0x03, 0xf1, 0x7d, 0x7d, 0x11, 0xb3, 0x53, 0x90, 0x12, 0x4f, 0x9b, 0xef, 0xfb,
+	0x7e, 0x33, 0x99, 0x9d, 0x81, 0x6c, 0xbd, 0xf5, 0xe5, 0x66, 0x51, 0x07, 0xcf, 0x5e, 0xa5, 0xf2,
+	0x4c, 0x27, 0x1c, 0x8c, 0x23, 0x53, 0xb2, 0xf5, 0xae, 0x49, 0x66, 0x3f, 0x1d, 0xc8, 0x5e, 0x22,
+	0xb9, 0x44, 0xf3, 0x89, 0x41, 0xcd, 0x60, 0x54, 0x07, 0x3c, 0x58, 0xbf, 0xa7, 0xa5, 0xa1, 0x4a,
+	0x27, 0x79, 0x32, 0x1f, 0x16, 0x17, 0x9e, 0xba, 0x85, 0x21, 0xb1, 0x61, 0x14, 0xa0, 0x23, 0xc0,
+	0xd9, 0x88, 0x29, 0xdb, 0x1d, 0xbe, 0xb3, 0xd9, 0xd5, 0xba, 0x9b, 0x27, 0xf3, 0x71, 0x71, 0x36,
+	0xd4, 0x35, 0xa4, 0xce, 0xbb, 0x12, 0xf5, 0x95, 0x24, 0x8d, 0x50, 0x77, 0x30, 0xe6, 0x6f, 0x5a,
+	0x61, 0xd8, 0x6c, 0x9b, 0xae, 0xa9, 0x74, 0xbd, 0x34, 0xd5, 0x0d, 0xf4, 0x2a, 0xb4, 0x5f, 0x15,
+	0xeb, 0x9e, 0x14, 0x1f, 0x55, 0x9c, 0x39, 0x60, 0x89, 0xb6, 0x66, 0x2a, 0xbc, 0x67, 0xdd, 0x6f,
+	0x66, 0x6e, 0x7b, 0x4a, 0x43, 0xff, 0x80, 0x81, 0xac, 0x77, 0x7a, 0x20, 0xc5, 0x27, 0x19, 0x93,
+	0xb2, 0x32, 0xd6, 0xbd, 0xbd, 0xea, 0x61, 0x9e, 0xcc, 0x47, 0xc5, 0x49, 0xaa, 0x29, 0x0c, 0xea,
+	0xe0, 0x6b, 0x4f, 0x18, 0x34, 0x48, 0xcf, 0x3f, 0xad, 0x1e, 0x60, 0x52, 0x7a, 0x47, 0xe8, 0x68,
+	0x4f, 0x2b, 0x64, 0x23, 0x53, 0x67, 0x02, 0xfd, 0x0f, 0x66, 0x1b, 0x48, 0x65, 0xc9, 0xea, 0x3e,
+	0x7e, 0x21, 0x2e, 0x5a, 0x16, 0x9b, 0x3d, 0xaa, 0xe6, 0x0c, 0x8b, 0xd6, 0x09, 0x8a, 0x23, 0xa1,
+	0x9e, 0x61, 0xd4, 0xba, 0x17, 0xe9, 0x4e, 0xde, 0x6d, 0x55, 0x7c, 0x9c, 0xa3, 0xe2, 0x82, 0x5b,
+	0xf7, 0x04, 0x78, 0xfa, 0x1d, 0x00, 0x3c, 0x21, 0x3a, 0xfd, 0x02, 0x02, 0x00, 0x00,
 }
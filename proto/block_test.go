@@ -94,3 +94,58 @@ func TestBlockDeserialize(t *testing.T) {
 		t.Errorf("Block.Serialize error, %0x != %0x ", data, blkData)
 	}
 }
+
+func TestBlockHeaderVersion(t *testing.T) {
+	header := &BlockHeader{PreviousHash: crypto.DoubleSha256([]byte("xxxx")).String(), TimeStamp: 10, Nonce: 100, Height: 1}
+	hash := header.Hash()
+
+	// a version 0 header hashes the same as before the version 1 fields existed
+	legacy := &BlockHeader{}
+	if err := legacy.Deserialize(header.Serialize()); err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, legacy.Hash(), hash)
+
+	header.Version = BlockVersion
+	header.ChainID = []byte{0}
+	header.Proposer = "0001_abc"
+	header.ConsensusMetaHash = crypto.Sha256([]byte("meta")).String()
+	utils.AssertNotEquals(t, header.Hash(), hash)
+	for _, modify := range []func(*BlockHeader){
+		func(h *BlockHeader) { h.ChainID = []byte{1} },
+		func(h *BlockHeader) { h.Proposer = "0002_abc" },
+		func(h *BlockHeader) { h.ConsensusMetaHash = "" },
+	} {
+		modified := *header
+		modify(&modified)
+		utils.AssertNotEquals(t, modified.Hash(), header.Hash())
+	}
+}
+
+func TestBlockHeaderVerify(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	parent := &BlockHeader{TimeStamp: uint32(now.Unix()) - 10, Height: 4}
+	valid := func() *BlockHeader {
+		return &BlockHeader{Version: BlockVersion, ChainID: []byte{0}, TimeStamp: uint32(now.Unix()), Height: 5}
+	}
+	if err := valid().Verify(parent, []byte{0}, now); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, modify := range map[string]func(*BlockHeader){
+		"future version": func(h *BlockHeader) { h.Version = BlockVersion + 1 },
+		"version 0":      func(h *BlockHeader) { h.Version = 0 },
+		"older version":  func(h *BlockHeader) { h.Version = BlockVersion - 1 },
+		"other chain":    func(h *BlockHeader) { h.ChainID = []byte{1} },
+		"height gap":     func(h *BlockHeader) { h.Height = 6 },
+		"parent time":    func(h *BlockHeader) { h.TimeStamp = parent.TimeStamp },
+		"before parent":  func(h *BlockHeader) { h.TimeStamp = parent.TimeStamp - 1 },
+		"future time":    func(h *BlockHeader) { h.TimeStamp = uint32(now.Add(MaxBlockTimeDrift).Unix()) + 1 },
+	} {
+		header := valid()
+		modify(header)
+		if err := header.Verify(parent, []byte{0}, now); err == nil {
+			t.Errorf("%s header accepted", name)
+		}
+	}
+}
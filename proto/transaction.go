@@ -21,6 +21,8 @@ package proto
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"math/big"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/zipper-project/zipper/account"
@@ -39,7 +41,7 @@ func NewTransaction(from, to account.ChainCoordinate,
 	nonce uint32,
 	sender, recipient account.Address,
 	assetID uint32,
-	amount, fee int64,
+	amount, fee *big.Int,
 	createTime uint32) *Transaction {
 	tx := &Transaction{
 		Header: &TxHeader{
@@ -50,14 +52,57 @@ func NewTransaction(from, to account.ChainCoordinate,
 			Sender:     sender.String(),
 			Recipient:  recipient.String(),
 			AssetID:    assetID,
-			Amount:     amount,
-			Fee:        fee,
 			CreateTime: createTime,
 		},
 	}
+	tx.Header.SetAmount(amount)
+	tx.Header.SetFee(fee)
 	return tx
 }
 
+// SetAmount sets the transfer amount, amounts in the int64 range are kept in the amount field
+// so their transactions hash the same as before arbitrary precision amounts
+func (m *TxHeader) SetAmount(amount *big.Int) {
+	m.Amount, m.BigAmount = splitAmount(amount)
+}
+
+// SetFee sets the fee, fees in the int64 range are kept in the fee field
+func (m *TxHeader) SetFee(fee *big.Int) {
+	m.Fee, m.BigFee = splitAmount(fee)
+}
+
+func splitAmount(amount *big.Int) (int64, string) {
+	if amount == nil {
+		return 0, ""
+	}
+	if amount.IsInt64() {
+		return amount.Int64(), ""
+	}
+	return 0, amount.String()
+}
+
+// joinAmount is the inverse of splitAmount, it only accepts the split splitAmount produces: a
+// non-negative int64 field, or a zero int64 field with the canonical decimal of an amount beyond int64
+func joinAmount(amount int64, bigAmount string) (*big.Int, error) {
+	if bigAmount == "" {
+		if amount < 0 {
+			return nil, fmt.Errorf("negative amount %d", amount)
+		}
+		return big.NewInt(amount), nil
+	}
+	if amount != 0 {
+		return nil, fmt.Errorf("amount %d set together with big amount %q", amount, bigAmount)
+	}
+	v, ok := new(big.Int).SetString(bigAmount, 10)
+	if !ok || v.Sign() < 0 || v.String() != bigAmount {
+		return nil, fmt.Errorf("big amount %q isn't a canonical non-negative decimal", bigAmount)
+	}
+	if v.IsInt64() {
+		return nil, fmt.Errorf("big amount %q must be set in the int64 field", bigAmount)
+	}
+	return v, nil
+}
+
 // Hash returns the hash of a transaction
 func (tx *Transaction) Hash() crypto.Hash {
 	return crypto.DoubleSha256(tx.Serialize())
@@ -77,6 +122,9 @@ func (tx *Transaction) SignHash() crypto.Hash {
 			Amount:     tx.Header.Amount,
 			Fee:        tx.Header.Fee,
 			CreateTime: tx.Header.CreateTime,
+			BigAmount:  tx.Header.BigAmount,
+			BigFee:     tx.Header.BigFee,
+			ValidUntil: tx.Header.ValidUntil,
 		},
 		Payload:      tx.Payload,
 		Meta:         tx.Meta,
@@ -114,7 +162,8 @@ func (tx *Transaction) Verfiy() (account.Address, error) {
 	)
 	switch tx.Header.GetType() {
 	case TransactionType_Atomic, TransactionType_AcrossChain, TransactionType_Backfront, TransactionType_Distribut, TransactionType_IssueUpdate,
-		TransactionType_JSContractInit, TransactionType_LuaContractInit, TransactionType_ContractInvoke, TransactionType_ContractQuery, TransactionType_Security:
+		TransactionType_JSContractInit, TransactionType_LuaContractInit, TransactionType_ContractInvoke, TransactionType_ContractQuery, TransactionType_Security,
+		TransactionType_Mint, TransactionType_Burn, TransactionType_ContractUpgrade, TransactionType_ContractDecommission:
 		fallthrough
 	case TransactionType_Issue:
 		if tx.Header.Signature != nil {
@@ -169,11 +218,34 @@ func (tx *Transaction) AssetID() uint32 {
 	return tx.Header.AssetID
 }
 
-// Amount returns the transfer amount of the transaction
-func (tx *Transaction) Amount() int64 { return tx.Header.Amount }
+// Amount returns the transfer amount of the transaction, zero if CheckAmounts fails
+func (tx *Transaction) Amount() *big.Int {
+	amount, err := joinAmount(tx.Header.Amount, tx.Header.BigAmount)
+	if err != nil {
+		return new(big.Int)
+	}
+	return amount
+}
 
-// Fee returns the nonce of the transaction
-func (tx *Transaction) Fee() int64 { return tx.Header.Fee }
+// Fee returns the fee of the transaction, zero if CheckAmounts fails
+func (tx *Transaction) Fee() *big.Int {
+	fee, err := joinAmount(tx.Header.Fee, tx.Header.BigFee)
+	if err != nil {
+		return new(big.Int)
+	}
+	return fee
+}
+
+// CheckAmounts checks the amount and fee are encoded the way SetAmount and SetFee encode them
+func (tx *Transaction) CheckAmounts() error {
+	if _, err := joinAmount(tx.Header.Amount, tx.Header.BigAmount); err != nil {
+		return fmt.Errorf("illegal amount: %v", err)
+	}
+	if _, err := joinAmount(tx.Header.Fee, tx.Header.BigFee); err != nil {
+		return fmt.Errorf("illegal fee: %v", err)
+	}
+	return nil
+}
 
 // Nonce returns the nonce of the transaction
 func (tx *Transaction) Nonce() uint32 { return tx.Header.Nonce }
@@ -184,6 +256,9 @@ func (tx *Transaction) GetType() TransactionType { return tx.Header.Type }
 // CreateTime returns the create time of the transaction
 func (tx *Transaction) CreateTime() uint32 { return tx.Header.CreateTime }
 
+// ValidUntil returns the last block height the transaction may be executed at, 0 if it doesn't expire
+func (tx *Transaction) ValidUntil() uint32 { return tx.Header.ValidUntil }
+
 // Compare implements interface consensus need
 func (tx *Transaction) Compare(v interface{}) int {
 	if tx.CreateTime() >= v.(*Transaction).CreateTime() {
@@ -16,18 +16,22 @@ var _ = math.Inf
 type TransactionType int32
 
 const (
-	TransactionType_Atomic          TransactionType = 0
-	TransactionType_AcrossChain     TransactionType = 1
-	TransactionType_Merged          TransactionType = 2
-	TransactionType_Backfront       TransactionType = 3
-	TransactionType_Distribut       TransactionType = 4
-	TransactionType_Issue           TransactionType = 5
-	TransactionType_IssueUpdate     TransactionType = 6
-	TransactionType_JSContractInit  TransactionType = 7
-	TransactionType_LuaContractInit TransactionType = 8
-	TransactionType_ContractInvoke  TransactionType = 9
-	TransactionType_ContractQuery   TransactionType = 10
-	TransactionType_Security        TransactionType = 11
+	TransactionType_Atomic               TransactionType = 0
+	TransactionType_AcrossChain          TransactionType = 1
+	TransactionType_Merged               TransactionType = 2
+	TransactionType_Backfront            TransactionType = 3
+	TransactionType_Distribut            TransactionType = 4
+	TransactionType_Issue                TransactionType = 5
+	TransactionType_IssueUpdate          TransactionType = 6
+	TransactionType_JSContractInit       TransactionType = 7
+	TransactionType_LuaContractInit      TransactionType = 8
+	TransactionType_ContractInvoke       TransactionType = 9
+	TransactionType_ContractQuery        TransactionType = 10
+	TransactionType_Security             TransactionType = 11
+	TransactionType_Mint                 TransactionType = 12
+	TransactionType_Burn                 TransactionType = 13
+	TransactionType_ContractUpgrade      TransactionType = 14
+	TransactionType_ContractDecommission TransactionType = 15
 )
 
 var TransactionType_name = map[int32]string{
@@ -43,20 +47,28 @@ var TransactionType_name = map[int32]string{
 	9:  "ContractInvoke",
 	10: "ContractQuery",
 	11: "Security",
+	12: "Mint",
+	13: "Burn",
+	14: "ContractUpgrade",
+	15: "ContractDecommission",
 }
 var TransactionType_value = map[string]int32{
-	"Atomic":          0,
-	"AcrossChain":     1,
-	"Merged":          2,
-	"Backfront":       3,
-	"Distribut":       4,
-	"Issue":           5,
-	"IssueUpdate":     6,
-	"JSContractInit":  7,
-	"LuaContractInit": 8,
-	"ContractInvoke":  9,
-	"ContractQuery":   10,
-	"Security":        11,
+	"Atomic":               0,
+	"AcrossChain":          1,
+	"Merged":               2,
+	"Backfront":            3,
+	"Distribut":            4,
+	"Issue":                5,
+	"IssueUpdate":          6,
+	"JSContractInit":       7,
+	"LuaContractInit":      8,
+	"ContractInvoke":       9,
+	"ContractQuery":        10,
+	"Security":             11,
+	"Mint":                 12,
+	"Burn":                 13,
+	"ContractUpgrade":      14,
+	"ContractDecommission": 15,
 }
 
 func (x TransactionType) String() string {
@@ -108,6 +120,9 @@ type TxHeader struct {
 	Fee        int64           `protobuf:"varint,9,opt,name=fee" json:"fee,omitempty"`
 	Signature  []byte          `protobuf:"bytes,10,opt,name=signature,proto3" json:"signature,omitempty"`
 	CreateTime uint32          `protobuf:"varint,11,opt,name=createTime" json:"createTime,omitempty"`
+	BigAmount  string          `protobuf:"bytes,12,opt,name=bigAmount" json:"bigAmount,omitempty"`
+	BigFee     string          `protobuf:"bytes,13,opt,name=bigFee" json:"bigFee,omitempty"`
+	ValidUntil uint32          `protobuf:"varint,14,opt,name=validUntil" json:"validUntil,omitempty"`
 }
 
 func (m *TxHeader) Reset()                    { *m = TxHeader{} }
@@ -192,6 +207,27 @@ func (m *TxHeader) GetCreateTime() uint32 {
 	return 0
 }
 
+func (m *TxHeader) GetBigAmount() string {
+	if m != nil {
+		return m.BigAmount
+	}
+	return ""
+}
+
+func (m *TxHeader) GetBigFee() string {
+	if m != nil {
+		return m.BigFee
+	}
+	return ""
+}
+
+func (m *TxHeader) GetValidUntil() uint32 {
+	if m != nil {
+		return m.ValidUntil
+	}
+	return 0
+}
+
 type Transaction struct {
 	Header       *TxHeader     `protobuf:"bytes,1,opt,name=Header" json:"Header,omitempty"`
 	Payload      []byte        `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"`
@@ -242,35 +278,40 @@ func init() {
 func init() { proto1.RegisterFile("transaction.proto", fileDescriptor2) }
 
 var fileDescriptor2 = []byte{
-	// 480 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x52, 0xc1, 0x72, 0xd3, 0x30,
-	0x10, 0xc5, 0x71, 0xe2, 0xc4, 0xeb, 0xa4, 0x51, 0x55, 0xa6, 0xa3, 0x03, 0xc3, 0x78, 0x72, 0x21,
-	0xd3, 0x43, 0x0f, 0xe1, 0xc0, 0xb9, 0xb4, 0x07, 0xc2, 0x00, 0x33, 0x38, 0xe1, 0x03, 0x54, 0x79,
-	0x53, 0x34, 0xc5, 0x92, 0x47, 0x92, 0x19, 0xfc, 0x31, 0xfc, 0x09, 0x5f, 0xc1, 0x17, 0x31, 0x92,
-	0x6d, 0x92, 0xf6, 0xe4, 0x7d, 0x6f, 0x77, 0x9e, 0xde, 0xbe, 0x35, 0x9c, 0x3b, 0xc3, 0x95, 0xe5,
-	0xc2, 0x49, 0xad, 0xae, 0x6b, 0xa3, 0x9d, 0xa6, 0x93, 0xf0, 0x59, 0x7d, 0x81, 0xf9, 0xad, 0x56,
-	0xce, 0x70, 0xe1, 0x76, 0x35, 0x0a, 0x4a, 0x61, 0xcc, 0xcb, 0xd2, 0xb0, 0x28, 0x8f, 0xd6, 0xf3,
-	0x22, 0xd4, 0x9e, 0x13, 0xba, 0x44, 0x36, 0xea, 0x38, 0x5f, 0xd3, 0x4b, 0x48, 0x6a, 0x6e, 0x78,
-	0x65, 0x59, 0x9c, 0xc7, 0xeb, 0xb4, 0xe8, 0xd1, 0xea, 0xcf, 0x08, 0x66, 0xfb, 0x5f, 0x1f, 0x90,
-	0x97, 0x68, 0xe8, 0x2b, 0x48, 0x0f, 0x46, 0x57, 0xb7, 0xdf, 0xb9, 0x54, 0xbd, 0xe2, 0x91, 0xa0,
-	0x0c, 0xa6, 0x4e, 0x77, 0xbd, 0x4e, 0x79, 0x80, 0xf4, 0x0a, 0xc6, 0xae, 0xad, 0x91, 0xc5, 0x79,
-	0xb4, 0x3e, 0xdb, 0x5c, 0x76, 0x8e, 0xaf, 0xf7, 0xc7, 0x1d, 0xf6, 0x6d, 0x8d, 0x45, 0x98, 0xa1,
-	0x2f, 0x61, 0xa2, 0xb4, 0x12, 0xc8, 0xc6, 0x79, 0xb4, 0x5e, 0x14, 0x1d, 0xf0, 0xf6, 0x2c, 0xaa,
-	0x12, 0x0d, 0x9b, 0xe4, 0x91, 0xb7, 0xd7, 0x21, 0xef, 0xc8, 0xa0, 0x90, 0xb5, 0x44, 0xe5, 0x58,
-	0x12, 0x5a, 0x47, 0xc2, 0x3b, 0xe2, 0xd6, 0xa2, 0xdb, 0xde, 0xb1, 0x69, 0x50, 0x1b, 0xa0, 0xd7,
-	0xe3, 0x95, 0x6e, 0x94, 0x63, 0xb3, 0x3c, 0x5a, 0xc7, 0x45, 0x8f, 0x28, 0x81, 0xf8, 0x80, 0xc8,
-	0xd2, 0x40, 0xfa, 0xd2, 0xbf, 0x60, 0xe5, 0x83, 0xe2, 0xae, 0x31, 0xc8, 0xa0, 0xdb, 0xf9, 0x3f,
-	0x41, 0x5f, 0x03, 0x08, 0x83, 0xdc, 0xe1, 0x5e, 0x56, 0xc8, 0xb2, 0xf0, 0xc8, 0x09, 0xb3, 0xfa,
-	0x1d, 0x41, 0x76, 0xb2, 0x27, 0x7d, 0x03, 0x49, 0x97, 0x65, 0x88, 0x2f, 0xdb, 0x2c, 0x87, 0x2c,
-	0xfa, 0x88, 0x8b, 0xbe, 0xed, 0xad, 0xd7, 0xbc, 0xfd, 0xa1, 0x79, 0x39, 0x84, 0xd9, 0x43, 0x7f,
-	0xbd, 0x0a, 0x1d, 0x0f, 0x61, 0xce, 0x8b, 0x50, 0xd3, 0x77, 0x30, 0x17, 0x27, 0x57, 0x0f, 0xd9,
-	0x65, 0x9b, 0x8b, 0x5e, 0xfc, 0xf4, 0x87, 0x28, 0x9e, 0x0c, 0x5e, 0xfd, 0x8d, 0x60, 0xf9, 0xec,
-	0x0e, 0x14, 0x20, 0xb9, 0x71, 0xba, 0x92, 0x82, 0xbc, 0xa0, 0x4b, 0xc8, 0x6e, 0x84, 0xd1, 0xd6,
-	0x86, 0x43, 0x92, 0xc8, 0x37, 0x3f, 0xa3, 0x79, 0xc0, 0x92, 0x8c, 0xe8, 0x02, 0xd2, 0xf7, 0x5c,
-	0x3c, 0x1e, 0x8c, 0x56, 0x8e, 0xc4, 0x1e, 0xde, 0x49, 0xeb, 0x8c, 0xbc, 0x6f, 0x1c, 0x19, 0xd3,
-	0x14, 0x26, 0x5b, 0x6b, 0x1b, 0x24, 0x13, 0xaf, 0x12, 0xca, 0x6f, 0x75, 0xc9, 0x1d, 0x92, 0x84,
-	0x52, 0x38, 0xfb, 0xb8, 0x1b, 0x6c, 0x6d, 0x95, 0x74, 0x64, 0x4a, 0x2f, 0x60, 0xf9, 0xa9, 0xe1,
-	0x4f, 0xc8, 0x99, 0x1f, 0x3c, 0x32, 0x3f, 0xf5, 0x23, 0x92, 0x94, 0x9e, 0xc3, 0x62, 0xe0, 0xbe,
-	0x36, 0x68, 0x5a, 0x02, 0x74, 0x0e, 0xb3, 0x1d, 0x8a, 0xc6, 0x48, 0xd7, 0x92, 0xec, 0x3e, 0x09,
-	0x6b, 0xbf, 0xfd, 0x17, 0x00, 0x00, 0xff, 0xff, 0xab, 0x38, 0x9b, 0x84, 0x26, 0x03, 0x00, 0x00,
+	// 554 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x52, 0xcd, 0x6e, 0xdb, 0x3c,
+	0x10, 0xfc, 0x14, 0xd9, 0x8e, 0xbd, 0x92, 0x6d, 0x86, 0x09, 0x02, 0x1e, 0x3e, 0x14, 0x42, 0x2e,
+	0x35, 0x72, 0xc8, 0x21, 0x3d, 0xf4, 0x9c, 0x1f, 0x14, 0x4d, 0xd1, 0x14, 0xa8, 0xe2, 0x3c, 0x00,
+	0x4d, 0x6d, 0x5c, 0x22, 0x16, 0x29, 0x90, 0x54, 0x50, 0xbf, 0x46, 0x81, 0x1e, 0xfb, 0xae, 0x05,
+	0x49, 0x29, 0x76, 0x7a, 0xd2, 0xce, 0x2c, 0x35, 0x1c, 0xee, 0x2c, 0x1c, 0x39, 0xc3, 0x95, 0xe5,
+	0xc2, 0x49, 0xad, 0x2e, 0x1a, 0xa3, 0x9d, 0xa6, 0xc3, 0xf0, 0x39, 0xfb, 0x06, 0xf9, 0x8d, 0x56,
+	0xce, 0x70, 0xe1, 0x1e, 0x1a, 0x14, 0x94, 0xc2, 0x80, 0x57, 0x95, 0x61, 0x49, 0x91, 0x2c, 0xf2,
+	0x32, 0xd4, 0x9e, 0x13, 0xba, 0x42, 0x76, 0x10, 0x39, 0x5f, 0xd3, 0x53, 0x18, 0x35, 0xdc, 0xf0,
+	0xda, 0xb2, 0xb4, 0x48, 0x17, 0x93, 0xb2, 0x43, 0x67, 0xbf, 0x52, 0x18, 0x2f, 0x7f, 0x7e, 0x46,
+	0x5e, 0xa1, 0xa1, 0xff, 0xc3, 0xe4, 0xc9, 0xe8, 0xfa, 0xe6, 0x07, 0x97, 0xaa, 0x53, 0xdc, 0x11,
+	0x94, 0xc1, 0xa1, 0xd3, 0xb1, 0x17, 0x95, 0x7b, 0x48, 0xcf, 0x61, 0xe0, 0xb6, 0x0d, 0xb2, 0xb4,
+	0x48, 0x16, 0xb3, 0xcb, 0xd3, 0xe8, 0xf8, 0x62, 0xb9, 0x7b, 0xc3, 0x72, 0xdb, 0x60, 0x19, 0xce,
+	0xd0, 0x13, 0x18, 0x2a, 0xad, 0x04, 0xb2, 0x41, 0x91, 0x2c, 0xa6, 0x65, 0x04, 0xde, 0x9e, 0x45,
+	0x55, 0xa1, 0x61, 0xc3, 0x22, 0xf1, 0xf6, 0x22, 0xf2, 0x8e, 0x0c, 0x0a, 0xd9, 0x48, 0x54, 0x8e,
+	0x8d, 0x42, 0x6b, 0x47, 0x78, 0x47, 0xdc, 0x5a, 0x74, 0x77, 0xb7, 0xec, 0x30, 0xa8, 0xf5, 0xd0,
+	0xeb, 0xf1, 0x5a, 0xb7, 0xca, 0xb1, 0x71, 0x91, 0x2c, 0xd2, 0xb2, 0x43, 0x94, 0x40, 0xfa, 0x84,
+	0xc8, 0x26, 0x81, 0xf4, 0xa5, 0xbf, 0xc1, 0xca, 0xb5, 0xe2, 0xae, 0x35, 0xc8, 0x20, 0xbe, 0xf9,
+	0x95, 0xa0, 0xef, 0x00, 0x84, 0x41, 0xee, 0x70, 0x29, 0x6b, 0x64, 0x59, 0xb8, 0x64, 0x8f, 0xf1,
+	0x7f, 0xaf, 0xe4, 0xfa, 0x2a, 0x5e, 0x95, 0x47, 0x7f, 0xaf, 0x84, 0x77, 0xb1, 0x92, 0xeb, 0x4f,
+	0x88, 0x6c, 0x1a, 0x5f, 0x15, 0x91, 0x57, 0x7d, 0xe1, 0x1b, 0x59, 0x3d, 0x2a, 0x27, 0x37, 0x6c,
+	0x16, 0x55, 0x77, 0xcc, 0xd9, 0x9f, 0x04, 0xb2, 0xbd, 0xe9, 0xd1, 0xf7, 0x30, 0x8a, 0x09, 0x85,
+	0x50, 0xb2, 0xcb, 0x79, 0x3f, 0xe1, 0x2e, 0xb8, 0xb2, 0x6b, 0xfb, 0x81, 0x34, 0x7c, 0xbb, 0xd1,
+	0xbc, 0xea, 0x23, 0xea, 0xa0, 0xdf, 0x89, 0x1a, 0x1d, 0x0f, 0x11, 0xe5, 0x65, 0xa8, 0xe9, 0x47,
+	0xc8, 0xc5, 0xde, 0x2e, 0x85, 0x44, 0xb2, 0xcb, 0xe3, 0x4e, 0x7c, 0x7f, 0xcd, 0xca, 0x37, 0x07,
+	0xcf, 0x7f, 0x1f, 0xc0, 0xfc, 0x9f, 0x74, 0x29, 0xc0, 0xe8, 0xca, 0xe9, 0x5a, 0x0a, 0xf2, 0x1f,
+	0x9d, 0x43, 0x76, 0x25, 0x8c, 0xb6, 0x36, 0xac, 0x07, 0x49, 0x7c, 0xf3, 0x1e, 0xcd, 0x1a, 0x2b,
+	0x72, 0x40, 0xa7, 0x30, 0xb9, 0xe6, 0xe2, 0xf9, 0xc9, 0x68, 0xe5, 0x48, 0xea, 0xe1, 0xad, 0xb4,
+	0xce, 0xc8, 0x55, 0xeb, 0xc8, 0x80, 0x4e, 0x60, 0x78, 0x67, 0x6d, 0x8b, 0x64, 0xe8, 0x55, 0x42,
+	0xf9, 0xd8, 0x54, 0xdc, 0x21, 0x19, 0x51, 0x0a, 0xb3, 0x2f, 0x0f, 0xbd, 0xad, 0x3b, 0x25, 0x1d,
+	0x39, 0xa4, 0xc7, 0x30, 0xff, 0xda, 0xf2, 0x37, 0xe4, 0xd8, 0x1f, 0xdc, 0x31, 0x2f, 0xfa, 0x19,
+	0xc9, 0x84, 0x1e, 0xc1, 0xb4, 0xe7, 0xbe, 0xb7, 0x68, 0xb6, 0x04, 0x68, 0x0e, 0xe3, 0x07, 0x14,
+	0xad, 0x91, 0x6e, 0x4b, 0x32, 0x3a, 0x86, 0xc1, 0xbd, 0x54, 0x8e, 0xe4, 0xbe, 0xba, 0x6e, 0x8d,
+	0x22, 0x53, 0xaf, 0xde, 0xff, 0xf4, 0xd8, 0xac, 0x0d, 0xaf, 0x90, 0xcc, 0x28, 0x83, 0x93, 0x9e,
+	0xbc, 0x45, 0xa1, 0xeb, 0x5a, 0x5a, 0x2b, 0xb5, 0x22, 0xf3, 0xd5, 0x28, 0x4c, 0xee, 0xc3, 0xdf,
+	0x01, 0x00, 0x31, 0x53, 0x08, 0xd7, 0xbf, 0x03, 0x00, 0x00,
 }
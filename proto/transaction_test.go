@@ -91,3 +91,59 @@ func TestTxSender(t *testing.T) {
 	utils.AssertEquals(t, tx.Serialize(), tx2.Serialize())
 
 }
+
+func TestTxBigAmount(t *testing.T) {
+	addr := account.HexToAddress("0xc9bc867a613381f35b4430a6cb712eff8bb50311")
+	small := NewTransaction(nil, nil, TransactionType_Atomic, 1, addr, addr, 1, big.NewInt(1100), big.NewInt(110), 0)
+	utils.AssertEquals(t, small.Header.Amount, int64(1100))
+	utils.AssertEquals(t, small.Header.BigAmount, "")
+	utils.AssertEquals(t, small.Amount().String(), "1100")
+	utils.AssertEquals(t, small.Fee().String(), "110")
+
+	// 10^18 precision amounts overflow int64
+	amount, _ := new(big.Int).SetString("123000000000000000000000", 10)
+	tx := NewTransaction(nil, nil, TransactionType_Atomic, 1, addr, addr, 1, amount, big.NewInt(110), 0)
+	utils.AssertEquals(t, tx.Header.Amount, int64(0))
+	utils.AssertEquals(t, tx.Header.BigAmount, "123000000000000000000000")
+
+	tx2 := new(Transaction)
+	if err := tx2.Deserialize(tx.Serialize()); err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, tx2.Amount().String(), amount.String())
+	utils.AssertEquals(t, tx2.Fee().String(), "110")
+	utils.AssertNotEquals(t, tx2.SignHash(), small.SignHash())
+}
+
+func TestTxCheckAmounts(t *testing.T) {
+	addr := account.HexToAddress("0xc9bc867a613381f35b4430a6cb712eff8bb50311")
+	amount, _ := new(big.Int).SetString("123000000000000000000000", 10)
+	tx := NewTransaction(nil, nil, TransactionType_Atomic, 1, addr, addr, 1, amount, big.NewInt(110), 0)
+	utils.AssertEquals(t, tx.CheckAmounts(), nil)
+
+	for _, c := range []struct {
+		amount    int64
+		bigAmount string
+	}{
+		{-1, ""},
+		{0, "abc"},
+		{0, "-123000000000000000000000"},
+		{0, "+123000000000000000000000"},
+		{0, "0123000000000000000000000"},
+		{0, "0x1a0c6a95fe1b09e3c0000"},
+		{0, "1100"},
+		{1, "123000000000000000000000"},
+	} {
+		tx.Header.Amount, tx.Header.BigAmount = c.amount, c.bigAmount
+		if tx.CheckAmounts() == nil {
+			t.Errorf("amount %d, big amount %q accepted", c.amount, c.bigAmount)
+		}
+		utils.AssertEquals(t, tx.Amount().Sign(), 0)
+	}
+
+	tx.Header.SetAmount(amount)
+	tx.Header.BigFee = "110"
+	if tx.CheckAmounts() == nil {
+		t.Error("fee of the int64 range accepted in the big fee")
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2017, Zipper Team.  All rights reserved.
+//
+// This file is part of zipper
+//
+// The zipper is free software: you can use, copy, modify,
+// and distribute this software for any purpose with or
+// without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// The zipper is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// ISC License for more details.
+//
+// You should have received a copy of the ISC License
+// along with this program.  If not, see <https://opensource.org/licenses/isc>.
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/blockchain"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger/state"
+	"github.com/zipper-project/zipper/params"
+	"github.com/zipper-project/zipper/proto"
+)
+
+// RPCContract runs read-only contract queries and filters contract events, it is registered as the Contract service
+type RPCContract struct {
+	bc *blockchain.Blockchain
+}
+
+func NewRPCContract(bc *blockchain.Blockchain) *RPCContract {
+	return &RPCContract{
+		bc: bc,
+	}
+}
+
+// ContractQueryArgs selects the contract to query and the args of its Query function, an empty Addr queries the global contract
+type ContractQueryArgs struct {
+	Addr string   `json:"addr"`
+	Args []string `json:"args"`
+}
+
+// Query runs the Query function of a contract against the committed state without a transaction.
+// A result that is JSON is returned decoded, any other result as a string
+func (rc *RPCContract) Query(args *ContractQueryArgs, reply *interface{}) error {
+	var addr []byte
+	if args.Addr != "" {
+		addr = account.HexToAddress(args.Addr).Bytes()
+	}
+	chain := account.NewChainCoordinate(params.ChainID)
+	tx := proto.NewTransaction(chain, chain, proto.TransactionType_ContractQuery, 0,
+		account.Address{}, account.NewAddress(addr), 0, big.NewInt(0), big.NewInt(0), utils.CurrentTimestamp())
+	tx.ContractSpec = &proto.ContractSpec{Addr: addr, Params: args.Args}
+
+	result, err := rc.bc.GetLedger().QueryContract(tx)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	if err := json.Unmarshal(result, &value); err != nil {
+		value = string(result)
+	}
+	*reply = value
+	return nil
+}
+
+// GetEvents returns the events of a contract, of one name if Name is set, in a height range in chain order.
+// The Next of a page continues the query as the Cursor of the following one
+func (rc *RPCContract) GetEvents(args *state.EventFilter, reply *state.EventPage) error {
+	filter := *args
+	if filter.Contract != "" {
+		filter.Contract = account.HexToAddress(filter.Contract).String()
+	}
+	page, err := rc.bc.GetLedger().GetEvents(&filter)
+	if err != nil {
+		return err
+	}
+	*reply = *page
+	return nil
+}
+
+// ContractInfoReply is the owner, current version and decommission height of a contract with its code versions
+type ContractInfoReply struct {
+	*state.ContractInfo
+	Versions []*state.ContractVersion `json:"versions"`
+}
+
+// GetInfo returns the owner and the code versions of the contract at addr
+func (rc *RPCContract) GetInfo(addr string, reply *ContractInfoReply) error {
+	info, versions, err := rc.bc.GetLedger().GetContract(account.HexToAddress(addr))
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		return fmt.Errorf("no contract is deployed at %s", addr)
+	}
+	*reply = ContractInfoReply{ContractInfo: info, Versions: versions}
+	return nil
+}
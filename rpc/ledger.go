@@ -18,7 +18,17 @@
 package rpc
 
 import (
+	"errors"
+	"fmt"
+
+	"github.com/zipper-project/zipper/account"
 	"github.com/zipper-project/zipper/blockchain"
+	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/utils"
+	"github.com/zipper-project/zipper/ledger"
+	"github.com/zipper-project/zipper/ledger/balance"
+	"github.com/zipper-project/zipper/ledger/blockstorage"
+	"github.com/zipper-project/zipper/ledger/state"
 )
 
 type RPCLedger struct {
@@ -35,4 +45,222 @@ func (rl *RPCLedger) GetChainHeight(ignore string, reply *uint32) error {
 	height := rl.bc.CurrentHeight()
 	*reply = height
 	return nil
-}
\ No newline at end of file
+}
+
+// BalanceArgs selects the balances of an address, AtHeight defaults to the current state
+type BalanceArgs struct {
+	Addr     string  `json:"addr"`
+	AtHeight *uint32 `json:"atHeight"`
+}
+
+// AssetArgs selects an asset, AtHeight defaults to the current state
+type AssetArgs struct {
+	ID       uint32  `json:"id"`
+	AtHeight *uint32 `json:"atHeight"`
+}
+
+// ContractStateArgs selects a contract state key, AtHeight defaults to the current state
+type ContractStateArgs struct {
+	ContractAddr string  `json:"contractAddr"`
+	Key          string  `json:"key"`
+	AtHeight     *uint32 `json:"atHeight"`
+}
+
+// GetBalance returns the balances of an address
+func (rl *RPCLedger) GetBalance(args *BalanceArgs, reply *balance.Balance) error {
+	var (
+		b   *balance.Balance
+		err error
+	)
+	if args.AtHeight == nil {
+		b, err = rl.bc.GetLedger().GetBalance(account.HexToAddress(args.Addr))
+	} else {
+		b, err = rl.bc.GetLedger().GetBalanceAt(account.HexToAddress(args.Addr), *args.AtHeight)
+	}
+	if err != nil {
+		return err
+	}
+	*reply = *b
+	return nil
+}
+
+// GetNonce returns the nonce the next transaction of an address has to carry, counting the transactions waiting in the pool
+func (rl *RPCLedger) GetNonce(addr string, reply *uint32) error {
+	nonce, err := rl.bc.GetNonce(account.HexToAddress(addr))
+	if err != nil {
+		return err
+	}
+	*reply = nonce
+	return nil
+}
+
+// GetAsset returns an asset
+func (rl *RPCLedger) GetAsset(args *AssetArgs, reply *state.Asset) error {
+	var (
+		asset *state.Asset
+		err   error
+	)
+	if args.AtHeight == nil {
+		asset, err = rl.bc.GetLedger().GetAsset(args.ID)
+	} else {
+		asset, err = rl.bc.GetLedger().GetAssetAt(args.ID, *args.AtHeight)
+	}
+	if err != nil {
+		return err
+	}
+	if asset == nil {
+		return fmt.Errorf("not found asset %d", args.ID)
+	}
+	*reply = *asset
+	return nil
+}
+
+// GetSupply returns the total, max and circulating supply of an asset
+func (rl *RPCLedger) GetSupply(assetID uint32, reply *ledger.Supply) error {
+	supply, err := rl.bc.GetLedger().GetSupply(assetID)
+	if err != nil {
+		return err
+	}
+	if supply == nil {
+		return fmt.Errorf("not found asset %d", assetID)
+	}
+	*reply = *supply
+	return nil
+}
+
+// GetFeePolicy returns the fee policy of the chain
+func (rl *RPCLedger) GetFeePolicy(ignore string, reply *state.FeePolicy) error {
+	policy, err := rl.bc.GetLedger().GetFeePolicy()
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		return errors.New("fees are paid to the recipient, the chain has no fee policy")
+	}
+	*reply = *policy
+	return nil
+}
+
+// GetContractState returns the value of a contract state key
+func (rl *RPCLedger) GetContractState(args *ContractStateArgs, reply *utils.Bytes) error {
+	var (
+		value []byte
+		err   error
+	)
+	if args.AtHeight == nil {
+		value, err = rl.bc.GetLedger().GetContractState(args.ContractAddr, args.Key)
+	} else {
+		value, err = rl.bc.GetLedger().GetContractStateAt(args.ContractAddr, args.Key, *args.AtHeight)
+	}
+	if err != nil {
+		return err
+	}
+	*reply = value
+	return nil
+}
+
+// BalanceProofArgs selects the balance to prove, Height defaults to the current height
+type BalanceProofArgs struct {
+	Addr    string  `json:"addr"`
+	AssetID uint32  `json:"assetID"`
+	Height  *uint32 `json:"height"`
+}
+
+// AssetProofArgs selects the asset to prove, Height defaults to the current height
+type AssetProofArgs struct {
+	AssetID uint32  `json:"assetID"`
+	Height  *uint32 `json:"height"`
+}
+
+// ContractStateProofArgs selects the contract state key to prove, Height defaults to the current height
+type ContractStateProofArgs struct {
+	ContractAddr string  `json:"contractAddr"`
+	Key          string  `json:"key"`
+	Height       *uint32 `json:"height"`
+}
+
+func (rl *RPCLedger) proofHeight(height *uint32) uint32 {
+	if height == nil {
+		return rl.bc.CurrentHeight()
+	}
+	return *height
+}
+
+// GetBalanceProof returns the merkle proof of a balance against the StateHash of a block
+func (rl *RPCLedger) GetBalanceProof(args *BalanceProofArgs, reply *state.StateProof) error {
+	proof, err := rl.bc.GetLedger().GetBalanceProof(account.HexToAddress(args.Addr), args.AssetID, rl.proofHeight(args.Height))
+	if err != nil {
+		return err
+	}
+	*reply = *proof
+	return nil
+}
+
+// GetAssetProof returns the merkle proof of an asset against the StateHash of a block
+func (rl *RPCLedger) GetAssetProof(args *AssetProofArgs, reply *state.StateProof) error {
+	proof, err := rl.bc.GetLedger().GetAssetProof(args.AssetID, rl.proofHeight(args.Height))
+	if err != nil {
+		return err
+	}
+	*reply = *proof
+	return nil
+}
+
+// GetContractStateProof returns the merkle proof of a contract state key against the StateHash of a block
+func (rl *RPCLedger) GetContractStateProof(args *ContractStateProofArgs, reply *state.StateProof) error {
+	proof, err := rl.bc.GetLedger().GetContractStateProof(args.ContractAddr, args.Key, rl.proofHeight(args.Height))
+	if err != nil {
+		return err
+	}
+	*reply = *proof
+	return nil
+}
+
+// GetTxProof returns the merkle proof of a transaction against the TxsMerkleHash of its block
+func (rl *RPCLedger) GetTxProof(txHash string, reply *blockstorage.TxProof) error {
+	proof, err := rl.bc.GetLedger().GetTxProof(crypto.HexToHash(txHash))
+	if err != nil {
+		return err
+	}
+	*reply = *proof
+	return nil
+}
+
+// GetReceipt returns the receipt of a transaction
+func (rl *RPCLedger) GetReceipt(txHash string, reply *state.Receipt) error {
+	receipt, err := rl.bc.GetLedger().GetReceipt(crypto.HexToHash(txHash))
+	if err != nil {
+		return err
+	}
+	*reply = *receipt
+	return nil
+}
+
+// TxsByAddressArgs selects a page of the transaction history of an address,
+// Cursor is the Next of the previous page and overrides FromHeight
+type TxsByAddressArgs struct {
+	Addr       string `json:"addr"`
+	AssetID    uint32 `json:"assetID"`
+	FromHeight uint32 `json:"fromHeight"`
+	Cursor     string `json:"cursor"`
+	Limit      int    `json:"limit"`
+}
+
+// GetTxsByAddress returns the transactions sent or received by an address in chain order
+func (rl *RPCLedger) GetTxsByAddress(args *TxsByAddressArgs, reply *blockstorage.TxHistory) error {
+	var (
+		history *blockstorage.TxHistory
+		err     error
+	)
+	addr := account.HexToAddress(args.Addr)
+	if args.Cursor != "" {
+		history, err = rl.bc.GetLedger().GetTxsByAddressCursor(addr, args.AssetID, args.Cursor, args.Limit)
+	} else {
+		history, err = rl.bc.GetLedger().GetTxsByAddress(addr, args.AssetID, args.FromHeight, args.Limit)
+	}
+	if err != nil {
+		return err
+	}
+	*reply = *history
+	return nil
+}
@@ -48,6 +48,7 @@ func NewServer(bc *blockchain.Blockchain) *rpc.Server {
 
 	server.Register(NewRPCTransaction(bc))
 	server.Register(NewRPCLedger(bc))
+	server.RegisterName("Contract", NewRPCContract(bc))
 	return server
 }
 
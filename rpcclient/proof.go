@@ -0,0 +1,174 @@
+package rpcclient
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/zipper-project/zipper/common/trie"
+	"github.com/zipper-project/zipper/common/utils"
+)
+
+// state column families and key layout of the ledger
+const (
+	balanceNamespace  = "balance"
+	assetNamespace    = "asset"
+	contractNamespace = "scontract"
+
+	stateKeyDelimiter = "\x00"
+	assetKeyPrefix    = "asset"
+	assetKeySuffix    = "$"
+)
+
+const (
+	methodGetBalanceProof       = "RPCLedger.GetBalanceProof"
+	methodGetAssetProof         = "RPCLedger.GetAssetProof"
+	methodGetContractStateProof = "RPCLedger.GetContractStateProof"
+)
+
+var (
+	// ErrProofKeyMismatch is returned when a proof is for another key than expected
+	ErrProofKeyMismatch = errors.New("proof is for another state key")
+)
+
+// StateProof proves a state key against the StateHash of the block at Height
+type StateProof struct {
+	Height    uint32 `json:"height"`
+	StateHash string `json:"stateHash"`
+	*trie.KeyProof
+}
+
+// BalanceKey returns the state key of the balance of addr for assetID
+func BalanceKey(addr string, assetID uint32) []byte {
+	return []byte(normalizeAddr(addr) + stateKeyDelimiter + strconv.FormatUint(uint64(assetID), 10) + assetKeySuffix)
+}
+
+// AssetKey returns the state key of assetID
+func AssetKey(assetID uint32) []byte {
+	return []byte(assetKeyPrefix + stateKeyDelimiter + strconv.FormatUint(uint64(assetID), 10) + assetKeySuffix)
+}
+
+// ContractStateKey returns the state key of key in the contract at contractAddr
+func ContractStateKey(contractAddr string, key string) []byte {
+	return []byte(contractAddr + stateKeyDelimiter + key)
+}
+
+func normalizeAddr(addr string) string {
+	addr = strings.ToLower(addr)
+	if !strings.HasPrefix(addr, "0x") {
+		addr = "0x" + addr
+	}
+	return addr
+}
+
+// VerifyStateProof checks proof against stateHash, the StateHash of a block header the caller trusts.
+// It returns the proven value, or false if the proof shows the key is absent.
+func VerifyStateProof(proof *StateProof, stateHash string) ([]byte, bool, error) {
+	if proof == nil || proof.KeyProof == nil {
+		return nil, false, trie.ErrInvalidProof
+	}
+	buf, err := hex.DecodeString(stateHash)
+	if err != nil || len(buf) != trie.HashSize {
+		return nil, false, fmt.Errorf("invalid state hash %q", stateHash)
+	}
+	if err := proof.Verify(trie.BytesToHash(buf)); err != nil {
+		return nil, false, err
+	}
+	return proof.Value, proof.Exists, nil
+}
+
+func verifyKey(proof *StateProof, stateHash string, namespace string, key []byte) ([]byte, bool, error) {
+	if proof == nil || proof.KeyProof == nil {
+		return nil, false, trie.ErrInvalidProof
+	}
+	if proof.Namespace != namespace || string(proof.Key) != string(key) {
+		return nil, false, ErrProofKeyMismatch
+	}
+	return VerifyStateProof(proof, stateHash)
+}
+
+// VerifyBalanceProof checks the proof of the balance of addr for assetID and returns the proven amount,
+// an absent balance is zero
+func VerifyBalanceProof(proof *StateProof, stateHash string, addr string, assetID uint32) (*big.Int, error) {
+	value, ok, err := verifyKey(proof, stateHash, balanceNamespace, BalanceKey(addr, assetID))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return new(big.Int), nil
+	}
+	return decodeAmount(value)
+}
+
+// decodeAmount decodes a balance the way the ledger stores it, amounts in the int64 range
+// keep the int64 encoding and larger ones are encoded as a big.Int
+func decodeAmount(value []byte) (*big.Int, error) {
+	var amount int64
+	if err := utils.Deserialize(value, &amount); err == nil {
+		return big.NewInt(amount), nil
+	}
+	v := new(big.Int)
+	if err := utils.Deserialize(value, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// VerifyAssetProof checks the proof of assetID and returns the serialized asset
+func VerifyAssetProof(proof *StateProof, stateHash string, assetID uint32) ([]byte, bool, error) {
+	return verifyKey(proof, stateHash, assetNamespace, AssetKey(assetID))
+}
+
+// VerifyContractStateProof checks the proof of key in the contract at contractAddr and returns the proven value
+func VerifyContractStateProof(proof *StateProof, stateHash string, contractAddr string, key string) ([]byte, bool, error) {
+	return verifyKey(proof, stateHash, contractNamespace, ContractStateKey(contractAddr, key))
+}
+
+// GetBalanceProof requests the proof of the balance of addr for assetID at height
+func GetBalanceProof(addr string, assetID uint32, height uint32) (*StateProof, error) {
+	return getStateProof(methodGetBalanceProof, map[string]interface{}{
+		"addr":    addr,
+		"assetID": assetID,
+		"height":  height,
+	})
+}
+
+// GetAssetProof requests the proof of assetID at height
+func GetAssetProof(assetID uint32, height uint32) (*StateProof, error) {
+	return getStateProof(methodGetAssetProof, map[string]interface{}{
+		"assetID": assetID,
+		"height":  height,
+	})
+}
+
+// GetContractStateProof requests the proof of key in the contract at contractAddr at height
+func GetContractStateProof(contractAddr string, key string, height uint32) (*StateProof, error) {
+	return getStateProof(methodGetContractStateProof, map[string]interface{}{
+		"contractAddr": contractAddr,
+		"key":          key,
+		"height":       height,
+	})
+}
+
+func getStateProof(method string, args map[string]interface{}) (*StateProof, error) {
+	request := NewRPCRequest("2.0", method, args)
+	jsonParsed, err := SendRPCRequst(rpchost, request)
+	if err != nil {
+		return nil, fmt.Errorf("%s SendRPCRequst error --- %s", method, err)
+	}
+
+	if _, ok := jsonParsed.Path("error.code").Data().(float64); ok {
+		msg, _ := jsonParsed.Path("error.message").Data().(string)
+		return nil, fmt.Errorf("%s error --- %s", method, msg)
+	}
+
+	proof := &StateProof{}
+	if err := json.Unmarshal(jsonParsed.Path("result").Bytes(), proof); err != nil {
+		return nil, fmt.Errorf("%s Path('result') error --- %s", method, err)
+	}
+	return proof, nil
+}
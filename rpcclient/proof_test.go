@@ -0,0 +1,93 @@
+package rpcclient
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/zipper-project/zipper/common/trie"
+	"github.com/zipper-project/zipper/common/utils"
+)
+
+type memStore map[trie.Hash][]byte
+
+func (s memStore) GetNode(h trie.Hash) ([]byte, error) {
+	return s[h], nil
+}
+
+func TestVerifyBalanceProof(t *testing.T) {
+	addr := "0xa132277be213f56221b6140998c03d860a60e1f8"
+	value := utils.Serialize(int64(10000))
+
+	tr := trie.New(trie.Hash{}, memStore{})
+	tr.Update(trie.KeyHash(balanceNamespace, BalanceKey(addr, 0)), trie.ValueHash(value))
+	tr.Update(trie.KeyHash(assetNamespace, AssetKey(0)), trie.ValueHash([]byte("asset")))
+	root := tr.Root()
+
+	proof, err := tr.Prove(trie.KeyHash(balanceNamespace, BalanceKey(addr, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(&StateProof{
+		Height:    1,
+		StateHash: root.String(),
+		KeyProof: &trie.KeyProof{
+			Namespace: balanceNamespace,
+			Key:       BalanceKey(addr, 0),
+			Exists:    true,
+			Value:     value,
+			Proof:     proof,
+		},
+	})
+
+	sp := &StateProof{}
+	if err := json.Unmarshal(data, sp); err != nil {
+		t.Fatal(err)
+	}
+	amount, err := VerifyBalanceProof(sp, root.String(), addr, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, amount, big.NewInt(10000))
+
+	if _, err := VerifyBalanceProof(sp, root.String(), addr, 1); err != ErrProofKeyMismatch {
+		t.Errorf("proof verified for another asset, err %v", err)
+	}
+
+	sp.Value = utils.Serialize(int64(20000))
+	if _, err := VerifyBalanceProof(sp, root.String(), addr, 0); err != trie.ErrValueMismatch {
+		t.Errorf("forged balance verified, err %v", err)
+	}
+}
+
+func TestVerifyBigBalanceProof(t *testing.T) {
+	addr := "0xa132277be213f56221b6140998c03d860a60e1f8"
+	balance := new(big.Int).Add(big.NewInt(math.MaxInt64), big.NewInt(math.MaxInt64))
+	value := utils.Serialize(balance)
+
+	tr := trie.New(trie.Hash{}, memStore{})
+	tr.Update(trie.KeyHash(balanceNamespace, BalanceKey(addr, 0)), trie.ValueHash(value))
+	root := tr.Root()
+	proof, err := tr.Prove(trie.KeyHash(balanceNamespace, BalanceKey(addr, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sp := &StateProof{
+		Height:    1,
+		StateHash: root.String(),
+		KeyProof: &trie.KeyProof{
+			Namespace: balanceNamespace,
+			Key:       BalanceKey(addr, 0),
+			Exists:    true,
+			Value:     value,
+			Proof:     proof,
+		},
+	}
+	amount, err := VerifyBalanceProof(sp, root.String(), addr, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.AssertEquals(t, amount.String(), balance.String())
+}
@@ -0,0 +1,118 @@
+package rpcclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const methodGetTxProof = "RPCLedger.GetTxProof"
+
+var (
+	// ErrTxProofMismatch is returned when a transaction proof doesn't lead to the expected merkle hash
+	ErrTxProofMismatch = errors.New("transaction proof doesn't match merkle hash")
+)
+
+// TxProof proves the transaction TxHash is included in the block at Height, the Index of TxCount transactions
+type TxProof struct {
+	Height        uint32   `json:"height"`
+	Index         uint32   `json:"index"`
+	TxCount       uint32   `json:"txCount"`
+	TxHash        string   `json:"txHash"`
+	TxsMerkleHash string   `json:"txsMerkleHash"`
+	Siblings      []string `json:"siblings"`
+}
+
+// VerifyTxProof checks proof against txsMerkleHash, the TxsMerkleHash of a block header the caller trusts
+func VerifyTxProof(proof *TxProof, txHash string, txsMerkleHash string) error {
+	if proof == nil {
+		return ErrTxProofMismatch
+	}
+	h, err := decodeHash(proof.TxHash)
+	if err != nil {
+		return err
+	}
+	expected, err := decodeHash(txHash)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(h, expected) {
+		return ErrTxProofMismatch
+	}
+	root, err := decodeHash(txsMerkleHash)
+	if err != nil {
+		return err
+	}
+
+	index, n := proof.Index, proof.TxCount
+	if index >= n {
+		return ErrTxProofMismatch
+	}
+	for _, s := range proof.Siblings {
+		sibling, err := decodeHash(s)
+		if err != nil {
+			return err
+		}
+		// the last node of an odd level is paired with itself, any other sibling differs from the node
+		if n <= 1 || (index^1 >= n) != bytes.Equal(sibling, h) {
+			return ErrTxProofMismatch
+		}
+		if index%2 == 0 {
+			h = merkleParent(h, sibling)
+		} else {
+			h = merkleParent(sibling, h)
+		}
+		index /= 2
+		n = (n + 1) / 2
+	}
+	if n != 1 || !bytes.Equal(h, root) {
+		return ErrTxProofMismatch
+	}
+	return nil
+}
+
+// merkleParent hashes two merkle nodes the way crypto.ComputeMerkleHash does
+func merkleParent(a, b []byte) []byte {
+	buf := append(reverseBytes(a), reverseBytes(b)...)
+	h := sha256.Sum256(buf)
+	return reverseBytes(h[:])
+}
+
+func reverseBytes(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
+
+func decodeHash(s string) ([]byte, error) {
+	buf, err := hex.DecodeString(s)
+	if err != nil || len(buf) != sha256.Size {
+		return nil, fmt.Errorf("invalid hash %q", s)
+	}
+	return buf, nil
+}
+
+// GetTxProof requests the proof of the transaction txHash
+func GetTxProof(txHash string) (*TxProof, error) {
+	request := NewRPCRequest("2.0", methodGetTxProof, txHash)
+	jsonParsed, err := SendRPCRequst(rpchost, request)
+	if err != nil {
+		return nil, fmt.Errorf("GetTxProof SendRPCRequst error --- %s", err)
+	}
+
+	if _, ok := jsonParsed.Path("error.code").Data().(float64); ok {
+		msg, _ := jsonParsed.Path("error.message").Data().(string)
+		return nil, fmt.Errorf("GetTxProof error --- %s", msg)
+	}
+
+	proof := &TxProof{}
+	if err := json.Unmarshal(jsonParsed.Path("result").Bytes(), proof); err != nil {
+		return nil, fmt.Errorf("GetTxProof Path('result') error --- %s", err)
+	}
+	return proof, nil
+}
@@ -0,0 +1,55 @@
+package rpcclient
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// the fifth of five transactions, built with crypto.ComputeMerkleBranch
+const (
+	testTxsMerkleHash = "d661d1847d9448fe6de58f8162edb26f94eaa122ba131fbfa7305e5ec96b71e7"
+	testTxProof       = `{
+		"height": 3,
+		"index": 4,
+		"txCount": 5,
+		"txHash": "e52d9c508c502347344d8c07ad91cbd6068afc75ff6292f062a09ca381c89e71",
+		"txsMerkleHash": "d661d1847d9448fe6de58f8162edb26f94eaa122ba131fbfa7305e5ec96b71e7",
+		"siblings": [
+			"e52d9c508c502347344d8c07ad91cbd6068afc75ff6292f062a09ca381c89e71",
+			"a835a74c2da5876b7542507e3ed786e1c9d6a307f4e639d568046011523cf149",
+			"12b4ef17f841336cfc83985a184c66dd4edd8cbcd4cd21d2dfd30cb3cc907ac8"
+		]
+	}`
+)
+
+func TestVerifyTxProof(t *testing.T) {
+	proof := &TxProof{}
+	if err := json.Unmarshal([]byte(testTxProof), proof); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyTxProof(proof, proof.TxHash, testTxsMerkleHash); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyTxProof(proof, proof.Siblings[1], testTxsMerkleHash); err != ErrTxProofMismatch {
+		t.Errorf("proof of another transaction accepted: %v", err)
+	}
+
+	proof.Index = 0
+	if err := VerifyTxProof(proof, proof.TxHash, testTxsMerkleHash); err != ErrTxProofMismatch {
+		t.Errorf("proof at a wrong index accepted: %v", err)
+	}
+
+	proof.Index = 4 + 8
+	if err := VerifyTxProof(proof, proof.TxHash, testTxsMerkleHash); err != ErrTxProofMismatch {
+		t.Errorf("proof at an out of range index accepted: %v", err)
+	}
+
+	// the fifth transaction is its own sibling, the branch mustn't prove a sixth one
+	for _, count := range []uint32{5, 6} {
+		proof.Index, proof.TxCount = 5, count
+		if err := VerifyTxProof(proof, proof.TxHash, testTxsMerkleHash); err != ErrTxProofMismatch {
+			t.Errorf("proof past the last transaction of %d accepted: %v", count, err)
+		}
+	}
+}
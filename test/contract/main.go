@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"os"
 	"strings"
@@ -30,7 +31,6 @@ import (
 	"github.com/zipper-project/zipper/common/crypto"
 	"github.com/zipper-project/zipper/account"
 	"github.com/zipper-project/zipper/proto"
-	"math/rand"
 )
 
 var (
@@ -57,6 +57,8 @@ var (
 		Timeout: time.Second * 500,
 	}
 	url = "http://localhost:8881"
+
+	nonces = make(map[string]uint32)
 )
 
 // contract lang
@@ -154,6 +156,22 @@ func main() {
 	<-ch
 }
 
+// nextNonce returns the nonce to sign the next transaction of addr with, the first one is read from the node
+func nextNonce(addr account.Address) uint32 {
+	key := addr.String()
+	if _, ok := nonces[key]; !ok {
+		postForm := fmt.Sprintf(`{"id":1,"method":"RPCLedger.GetNonce","params":["%s"]}`, key)
+		httpPost(postForm, func(result map[string]interface{}) {
+			if nonce, ok := result["result"].(float64); ok {
+				nonces[key] = uint32(nonce)
+			}
+		})
+	}
+	nonce := nonces[key]
+	nonces[key] = nonce + 1
+	return nonce
+}
+
 func httpPost(postForm string, resultHandler func(result map[string]interface{})) {
 	req, _ := http.NewRequest("POST", url, strings.NewReader(postForm))
 	req.Header.Set("Content-Type", "application/json")
@@ -191,19 +209,18 @@ func sendTransaction() {
 
 func issueTX() {
 	issueKey, _ := crypto.HexToECDSA(issuePriKeyHex)
-	nonce := rand.Uint32()
 	issueSender := account.PublicKeyToAddress(*issueKey.Public())
 
 	tx := proto.NewTransaction(
 		account.NewChainCoordinate(fromChain),
 		account.NewChainCoordinate(toChain),
 		proto.TransactionType_Issue,
-		nonce,
+		nextNonce(issueSender),
 		issueSender,
 		sender,
 		1,
-		1000000000,
-		1,
+		big.NewInt(1000000000),
+		big.NewInt(1),
 		uint32(time.Now().Unix()),
 	)
 
@@ -222,19 +239,18 @@ func issueTX() {
 }
 
 func transferTx() {
-	nonce := rand.Uint32()
 	privateKey, _ := crypto.GenerateKey()
 	receiver := account.PublicKeyToAddress(*privateKey.Public())
 	tx := proto.NewTransaction(
 		account.NewChainCoordinate(fromChain),
 		account.NewChainCoordinate(toChain),
 		proto.TransactionType_Atomic,
-		nonce,
+		nextNonce(sender),
 		sender,
 		receiver,
 		1,
-		10,
-		1,
+		big.NewInt(10),
+		big.NewInt(1),
 		uint32(time.Now().Unix()),
 	)
 
@@ -248,7 +264,6 @@ func transferTx() {
 func deploySmartContractTX(conf *contractConf) []byte {
 	contractSpec := new(proto.ContractSpec)
 	contractSpec.Params = conf.initArgs
-	nonce := rand.Uint32()
 	f, _ := os.Open(conf.path)
 	buf, _ := ioutil.ReadAll(f)
 	contractSpec.Code = buf
@@ -264,12 +279,12 @@ func deploySmartContractTX(conf *contractConf) []byte {
 		account.NewChainCoordinate(fromChain),
 		account.NewChainCoordinate(toChain),
 		conf.lang.ConvertInitTxType(),
-		nonce,
+		nextNonce(sender),
 		sender,
 		account.NewAddress(contractSpec.Addr),
 		1,
-		10,
-		0,
+		big.NewInt(10),
+		big.NewInt(0),
 		uint32(time.Now().Unix()),
 	)
 
@@ -299,17 +314,16 @@ func execSmartContractTX(conf *contractConf) {
 		contractSpec.Addr = a.Bytes()
 	}
 
-	nonce := 2
 	tx := proto.NewTransaction(
 		account.NewChainCoordinate(fromChain),
 		account.NewChainCoordinate(toChain),
 		proto.TransactionType_ContractInvoke,
-		uint32(nonce),
+		nextNonce(sender),
 		sender,
 		account.NewAddress(contractSpec.Addr),
 		0,
-		0,
-		0,
+		big.NewInt(0),
+		big.NewInt(0),
 		uint32(time.Now().Unix()),
 	)
 
@@ -322,7 +336,7 @@ func execSmartContractTX(conf *contractConf) {
 }
 
 func queryGlobalContract(key string) {
-	form := `{"id": 2, "method": "Transaction.Query", "params":[{"ContractAddr":"","ContractParams":["` + key + `"]}]}`
+	form := `{"id": 2, "method": "Contract.Query", "params":[{"addr":"","args":["` + key + `"]}]}`
 	httpPost(form, func(result map[string]interface{}) {
 		if result != nil {
 			fmt.Printf("> query result: %s\n", result["result"])
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"time"
 
@@ -34,8 +35,16 @@ var (
 	toChain        = []byte{0}
 	txChan         = make(chan *proto.Transaction, 1)
 	issuePriKeyHex = "496c663b994c3f6a8e99373c3308ee43031d7ea5120baf044168c95c45fbcf83"
+	nonces         = make(map[string]uint32)
 )
 
+// nextNonce returns the nonce to sign the next transaction of addr with, counting from 0 on a new chain
+func nextNonce(addr account.Address) uint32 {
+	nonce := nonces[addr.String()]
+	nonces[addr.String()] = nonce + 1
+	return nonce
+}
+
 func main() {
 	srv.Start()
 	time.Sleep(time.Second)
@@ -111,12 +120,12 @@ func issueTx(owner account.Address, assetID uint32, amount int64) {
 		account.NewChainCoordinate(fromChain),
 		account.NewChainCoordinate(toChain),
 		proto.TransactionType_Issue,
-		uint32(time.Now().UnixNano()),
+		nextNonce(issueSender),
 		issueSender,
 		owner,
 		assetID,
-		amount,
-		0,
+		big.NewInt(amount),
+		big.NewInt(0),
 		uint32(time.Now().Unix()),
 	)
 	issueCoin := make(map[string]interface{})
@@ -135,12 +144,12 @@ func atomicTx(privkey *crypto.PrivateKey, owner account.Address, assetID uint32,
 		account.NewChainCoordinate(fromChain),
 		account.NewChainCoordinate(toChain),
 		proto.TransactionType_Atomic,
-		uint32(time.Now().UnixNano()),
+		nextNonce(sender),
 		sender,
 		owner,
 		assetID,
-		amount,
-		0,
+		big.NewInt(amount),
+		big.NewInt(0),
 		uint32(time.Now().Unix()),
 	)
 	sig, _ := privkey.Sign(tx.SignHash().Bytes())
@@ -169,12 +178,12 @@ func deployTx(privkey *crypto.PrivateKey, assetID uint32, amount int64, path str
 		account.NewChainCoordinate(fromChain),
 		account.NewChainCoordinate(toChain),
 		proto.TransactionType_LuaContractInit,
-		uint32(time.Now().UnixNano()),
+		nextNonce(sender),
 		sender,
 		account.NewAddress(contractSpec.Addr),
 		assetID,
-		amount,
-		0,
+		big.NewInt(amount),
+		big.NewInt(0),
 		uint32(time.Now().Unix()),
 	)
 	tx.ContractSpec = contractSpec
@@ -197,12 +206,12 @@ func invokeTx(privkey *crypto.PrivateKey, assetID uint32, amount int64, contract
 		account.NewChainCoordinate(fromChain),
 		account.NewChainCoordinate(toChain),
 		proto.TransactionType_ContractInvoke,
-		uint32(time.Now().UnixNano()),
+		nextNonce(sender),
 		sender,
 		account.NewAddress(contractSpec.Addr),
 		assetID,
-		amount,
-		int64(0),
+		big.NewInt(amount),
+		big.NewInt(0),
 		uint32(time.Now().Unix()),
 	)
 
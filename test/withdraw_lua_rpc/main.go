@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"os"
 	"strings"
@@ -46,6 +47,8 @@ var (
 	}
 
 	url = "http://localhost:8881"
+
+	nonces = make(map[string]uint32)
 )
 
 func main() {
@@ -108,12 +111,12 @@ func issueTx(owner account.Address, assetID uint32, amount int64) {
 		account.NewChainCoordinate(fromChain),
 		account.NewChainCoordinate(toChain),
 		proto.TransactionType_Issue,
-		uint32(time.Now().UnixNano()),
+		nextNonce(issueSender),
 		issueSender,
 		owner,
 		assetID,
-		amount,
-		0,
+		big.NewInt(amount),
+		big.NewInt(0),
 		uint32(time.Now().Unix()),
 	)
 	issueCoin := make(map[string]interface{})
@@ -132,12 +135,12 @@ func atomicTx(privkey *crypto.PrivateKey, owner account.Address, assetID uint32,
 		account.NewChainCoordinate(fromChain),
 		account.NewChainCoordinate(toChain),
 		proto.TransactionType_Atomic,
-		uint32(time.Now().UnixNano()),
+		nextNonce(sender),
 		sender,
 		owner,
 		assetID,
-		amount,
-		0,
+		big.NewInt(amount),
+		big.NewInt(0),
 		uint32(time.Now().Unix()),
 	)
 	sig, _ := privkey.Sign(tx.SignHash().Bytes())
@@ -166,12 +169,12 @@ func deployTx(privkey *crypto.PrivateKey, assetID uint32, amount int64, path str
 		account.NewChainCoordinate(fromChain),
 		account.NewChainCoordinate(toChain),
 		proto.TransactionType_LuaContractInit,
-		uint32(time.Now().UnixNano()),
+		nextNonce(sender),
 		sender,
 		account.NewAddress(contractSpec.Addr),
 		assetID,
-		amount,
-		0,
+		big.NewInt(amount),
+		big.NewInt(0),
 		uint32(time.Now().Unix()),
 	)
 	tx.ContractSpec = contractSpec
@@ -195,12 +198,12 @@ func invokeTx(privkey *crypto.PrivateKey, assetID uint32, amount int64, contract
 		account.NewChainCoordinate(fromChain),
 		account.NewChainCoordinate(toChain),
 		proto.TransactionType_ContractInvoke,
-		uint32(time.Now().UnixNano()),
+		nextNonce(sender),
 		sender,
 		account.NewAddress(contractSpec.Addr),
 		assetID,
-		amount,
-		int64(0),
+		big.NewInt(amount),
+		big.NewInt(0),
 		uint32(time.Now().Unix()),
 	)
 
@@ -211,6 +214,22 @@ func invokeTx(privkey *crypto.PrivateKey, assetID uint32, amount int64, contract
 	sendTransaction(tx)
 }
 
+// nextNonce returns the nonce to sign the next transaction of addr with, the first one is read from the node
+func nextNonce(addr account.Address) uint32 {
+	key := addr.String()
+	if _, ok := nonces[key]; !ok {
+		postForm := fmt.Sprintf(`{"id":1,"method":"RPCLedger.GetNonce","params":["%s"]}`, key)
+		httpPost(postForm, func(result map[string]interface{}) {
+			if nonce, ok := result["result"].(float64); ok {
+				nonces[key] = uint32(nonce)
+			}
+		})
+	}
+	nonce := nonces[key]
+	nonces[key] = nonce + 1
+	return nonce
+}
+
 func httpPost(postForm string, resultHandler func(result map[string]interface{})) {
 	req, _ := http.NewRequest("POST", url, strings.NewReader(postForm))
 	req.Header.Set("Content-Type", "application/json")
@@ -66,7 +66,9 @@ func (worker *BsWorker) FetchContractType(workerProcWithCallback *vm.WorkerProcW
 	var err error
 	txType := "unknown"
 
-	if workerProcWithCallback.WorkProc.ContractData.Transaction.GetHeader().GetType() == proto.TransactionType_ContractInvoke {
+	// an upgrade keeps the contract type of the stored code
+	tp := workerProcWithCallback.WorkProc.ContractData.Transaction.GetHeader().GetType()
+	if tp == proto.TransactionType_ContractInvoke || tp == proto.TransactionType_ContractUpgrade {
 		txType, err = worker.GetInvokeType(workerProcWithCallback)
 		if err != nil {
 			log.Errorf("ThreadId: %+v, can't execute contract, tx_hash: %s, tx_idx: %+v, err_msg: %+v, can Redo: %+v", worker.workerID,
@@ -84,6 +86,7 @@ func (worker *BsWorker) ExecJob(workerProcWithCallback *vm.WorkerProcWithCallbac
 	var err error
 
 	execTime := time.Now()
+	workerProcWithCallback.WorkProc.Invoker = worker.CallContract
 	if worker.isCommonTransaction(workerProcWithCallback) {
 		err = worker.ExecCommonTransaction(workerProcWithCallback)
 	} else {
@@ -109,11 +112,10 @@ func (worker *BsWorker) ExecJob(workerProcWithCallback *vm.WorkerProcWithCallbac
 	}
 
 	mergeTime := time.Now()
-	res = res
 	cerr := workerProcWithCallback.WorkProc.SCHandler.CallBack(&state.CallBackResponse{
 		IsCanRedo: !worker.isCanRedo,
 		Err:       err,
-		//Result: res.(string),
+		Result:    res,
 	})
 
 	nowTime := time.Now()
@@ -138,6 +140,36 @@ func (worker *BsWorker) ExecJob(workerProcWithCallback *vm.WorkerProcWithCallbac
 	return cerr
 }
 
+// QueryContract runs the Query function of the contract of a query transaction with the worker of its contract type
+func (worker *BsWorker) QueryContract(wp *vm.WorkerProc) ([]byte, error) {
+	wp.Invoker = worker.CallContract
+	txType, err := worker.GetInvokeType(&vm.WorkerProcWithCallback{WorkProc: wp})
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(txType, "lua") {
+		return worker.luaWorker.QueryContract(wp)
+	} else if strings.Contains(txType, "js") {
+		return worker.jsWorker.QueryContract(wp)
+	}
+	return nil, fmt.Errorf("can't query contract of type %s", txType)
+}
+
+// CallContract runs a contract called by another contract in a new worker of its contract type,
+// the worker of the calling contract is still executing it
+func (worker *BsWorker) CallContract(wp *vm.WorkerProc) (interface{}, error) {
+	txType, err := worker.GetInvokeType(&vm.WorkerProcWithCallback{WorkProc: wp})
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(txType, "lua") {
+		return luavm.NewLuaWorker(vm.VMConf).CallContract(wp)
+	} else if strings.Contains(txType, "js") {
+		return jsvm.NewJsWorker(vm.VMConf).CallContract(wp)
+	}
+	return nil, fmt.Errorf("can't call contract of type %s", txType)
+}
+
 func (worker *BsWorker) VmJob(data interface{}) (interface{}, error) {
 	workerProcWithCallback := data.(*vm.WorkerProcWithCallback)
 	log.Debugf("worker thread id: %+v, start tx: %+v, tx_idx: %+v", worker.workerID, workerProcWithCallback.WorkProc.ContractData.Transaction.Hash().String(), workerProcWithCallback.Idx)
@@ -212,7 +244,8 @@ func (worker *BsWorker) GetInitType(wpwc *vm.WorkerProcWithCallback) string {
 func (worker *BsWorker) isCommonTransaction(wpwc *vm.WorkerProcWithCallback) bool {
 	txType := wpwc.WorkProc.ContractData.Transaction.GetHeader().GetType()
 	if txType == proto.TransactionType_LuaContractInit || txType == proto.TransactionType_ContractInvoke ||
-		txType == proto.TransactionType_JSContractInit || txType == proto.TransactionType_ContractQuery {
+		txType == proto.TransactionType_JSContractInit || txType == proto.TransactionType_ContractQuery ||
+		txType == proto.TransactionType_ContractUpgrade {
 		return false
 	}
 
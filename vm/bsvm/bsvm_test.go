@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"sync"
 	"testing"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/zipper-project/zipper/account"
 	"github.com/zipper-project/zipper/common/crypto"
+	"github.com/zipper-project/zipper/common/db"
 	ltyes "github.com/zipper-project/zipper/ledger/balance"
 	"github.com/zipper-project/zipper/proto"
 	"github.com/zipper-project/zipper/vm"
@@ -100,16 +102,28 @@ func (hd *MockerHandler) ComplexQuery(key string) ([]byte, error) {
 	return []byte{}, errors.New("Not found")
 }
 
-//func (hd *MockerHandler) GetByPrefix(prefix string) ([]*db.KeyValue, error) {
-//	return []*db.KeyValue{}, nil
-//}
-//
-//func (hd *MockerHandler) GetByRange(startKey, limitKey string) ([]*db.KeyValue, error) {
-//	return []*db.KeyValue{}, nil
-//}
+func (hd *MockerHandler) GetByPrefix(prefix, startKey string, count int) ([]*db.KeyValue, error) {
+	return []*db.KeyValue{}, nil
+}
+
+func (hd *MockerHandler) GetByRange(startKey, limitKey string, count int) ([]*db.KeyValue, error) {
+	return []*db.KeyValue{}, nil
+}
+
+func (hd *MockerHandler) Emit(name string, data []byte) error {
+	return nil
+}
+
+func (hd *MockerHandler) EnterContract(tx *proto.Transaction) error {
+	return nil
+}
+
+func (hd *MockerHandler) ExitContract() error {
+	return nil
+}
 
-func (hd *MockerHandler) GetBalance(addr string, assetID uint32) (int64, error) {
-	return int64(100), nil
+func (hd *MockerHandler) GetBalance(addr string, assetID uint32) (*big.Int, error) {
+	return big.NewInt(100), nil
 }
 
 func (hd *MockerHandler) GetBalances(addr string) (*ltyes.Balance, error) {
@@ -117,8 +131,8 @@ func (hd *MockerHandler) GetBalances(addr string) (*ltyes.Balance, error) {
 	defer hd.Unlock()
 
 	balance := ltyes.NewBalance()
-	balance.Amounts[0] = int64(100)
-	balance.Amounts[1] = int64(50)
+	balance.Set(0, big.NewInt(100))
+	balance.Set(1, big.NewInt(50))
 	return balance, nil
 }
 
@@ -126,7 +140,7 @@ func (hd *MockerHandler) GetCurrentBlockHeight() uint32 {
 	return 100
 }
 
-func (hd *MockerHandler) AddTransfer(fromAddr, toAddr string, assetID uint32, amount, fee int64) error {
+func (hd *MockerHandler) AddTransfer(fromAddr, toAddr string, assetID uint32, amount, fee *big.Int) error {
 	hd.Lock()
 	defer hd.Unlock()
 	return nil
@@ -19,6 +19,7 @@ package vm
 
 import (
 	"container/list"
+	"math/big"
 
 	ltyes "github.com/zipper-project/zipper/ledger/balance"
 )
@@ -59,11 +60,11 @@ func (ss *stateQueue) poll() *stateOpfunc {
 }
 
 type transferOpfunc struct {
-	fee    int64
+	fee    *big.Int
 	from   string
 	to     string
 	id     uint32
-	amount int64
+	amount *big.Int
 }
 
 type transferQueue struct {
@@ -89,3 +90,29 @@ func (tq *transferQueue) poll() *transferOpfunc {
 	}
 	return nil
 }
+
+type eventOpfunc struct {
+	name string
+	data []byte
+}
+
+type eventQueue struct {
+	lst *list.List
+}
+
+func NewEventQueue() *eventQueue {
+	return &eventQueue{list.New()}
+}
+
+func (eq *eventQueue) offer(opfunc *eventOpfunc) {
+	eq.lst.PushFront(opfunc)
+}
+
+func (eq *eventQueue) poll() *eventOpfunc {
+	e := eq.lst.Back()
+	if e != nil {
+		eq.lst.Remove(e)
+		return e.Value.(*eventOpfunc)
+	}
+	return nil
+}
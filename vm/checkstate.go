@@ -21,6 +21,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/zipper-project/zipper/account"
 )
@@ -52,6 +53,24 @@ func CheckStateValue(value []byte) error {
 	return nil
 }
 
+// CheckEvent checks the name and data of an event a contract emits
+func CheckEvent(name string, data []byte) error {
+	if name == "" || strings.IndexByte(name, 0) >= 0 {
+		return errors.New("event name illegal " + strconv.Quote(name))
+	}
+	if len(name) > VMConf.ExecLimitMaxStateKeyLength {
+		return errors.New("event name size illegal " +
+			strconv.Itoa(len(name)) +
+			" , max length is:" + strconv.Itoa(VMConf.ExecLimitMaxStateKeyLength))
+	}
+	if len(data) > VMConf.ExecLimitMaxStateValueSize {
+		return errors.New("event data size illegal " +
+			strconv.Itoa(len(data)) +
+			"  max size is:" + strconv.Itoa(VMConf.ExecLimitMaxStateValueSize))
+	}
+	return nil
+}
+
 func CheckStateKeyValue(key string, value []byte) error {
 	if err := CheckStateKey(key); err != nil {
 		return err
@@ -65,9 +84,7 @@ func CheckStateKeyValue(key string, value []byte) error {
 }
 
 func CheckAddr(addr string) error {
-	if addr[0:2] == "0x" {
-		addr = addr[2:]
-	}
+	addr = strings.TrimPrefix(addr, "0x")
 
 	addrByte, err := hex.DecodeString(addr)
 	if err != nil {
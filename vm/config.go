@@ -17,10 +17,14 @@
 
 package vm
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/zipper-project/zipper/params"
+)
 
 const (
-	ContractCodeKey = "__CONTRACT_CODE_KEY__"
+	ContractCodeKey = params.ContractCodeKey
 )
 
 var VMConf *Config
@@ -39,6 +43,7 @@ type Config struct {
 	ExecLimitMaxStateValueSize int // the max state value size (byte)
 	ExecLimitMaxStateItemCount int // the max state count in one contract
 	ExecLimitMaxStateKeyLength int // max state key length
+	ExecLimitMaxEventCount     int // the max events one transaction emits
 	LuaVMExeFilePath           string
 	JSVMExeFilePath            string
 	BsWorkerCnt                int
@@ -59,6 +64,7 @@ func DefaultConfig() *Config {
 		ExecLimitMaxStateValueSize: 10240, //5K
 		ExecLimitMaxStateItemCount: 10000000000,
 		ExecLimitMaxStateKeyLength: 256,
+		ExecLimitMaxEventCount:     64,
 		LuaVMExeFilePath:           "bin/luavm",
 		JSVMExeFilePath:            "bin/jsvm",
 		BsWorkerCnt:                1,
@@ -18,17 +18,26 @@
 package vm
 
 import (
+	"bytes"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
 
+	"github.com/zipper-project/zipper/account"
+	"github.com/zipper-project/zipper/common/db"
 	"github.com/zipper-project/zipper/common/log"
 	ltyes "github.com/zipper-project/zipper/ledger/balance"
+	"github.com/zipper-project/zipper/ledger/state"
 	"github.com/zipper-project/zipper/proto"
 )
 
 var (
 	ErrNoValidParamsCnt = errors.New("invalid param count")
+	// ErrResultFalse fails a transaction whose contract, or a contract it called, returned false
+	ErrResultFalse = errors.New("contract returned false")
 )
 
 type ContractCode struct {
@@ -47,10 +56,15 @@ func NewContractData(tx *proto.Transaction) *ContractData {
 	cd := new(ContractData)
 	if tx.GetType() == proto.TransactionType_ContractInvoke ||
 		tx.GetType() == proto.TransactionType_JSContractInit ||
-		tx.GetType() == proto.TransactionType_LuaContractInit {
+		tx.GetType() == proto.TransactionType_LuaContractInit ||
+		tx.GetType() == proto.TransactionType_ContractUpgrade {
 		cd.ContractCode = string(tx.GetContractSpec().Code)
 		cd.ContractAddr = hex.EncodeToString(tx.GetContractSpec().Addr)
 		cd.ContractParams = tx.GetContractSpec().Params
+	} else if tx.GetType() == proto.TransactionType_ContractQuery {
+		// a query runs the stored code of the contract
+		cd.ContractAddr = hex.EncodeToString(tx.GetContractSpec().GetAddr())
+		cd.ContractParams = tx.GetContractSpec().GetParams()
 	}
 
 	cd.Transaction = tx
@@ -62,6 +76,12 @@ type WorkerProc struct {
 	SCHandler        ISmartConstract
 	StateChangeQueue *stateQueue
 	TransferQueue    *transferQueue
+	EventQueue       *eventQueue
+
+	// Invoker runs a contract called by the contract in a worker of its contract type
+	Invoker   func(wp *WorkerProc) (interface{}, error)
+	CallDepth int
+	callErr   error
 }
 
 type WorkerProcWithCallback struct {
@@ -142,28 +162,142 @@ func (p *WorkerProc) CCallDelState(key string) error {
 	return nil
 }
 
-//
-//func (p *WorkerProc) CCallGetByPrefix(key string) ([]*db.KeyValue, error) {
-//	if err := CheckStateKey(key); err != nil {
-//		return nil, err
-//	}
-//
-//	result, err := p.ccall("GetByPrefix", key)
-//	return result.([]*db.KeyValue), err
-//}
-//
-//func (p *WorkerProc) CCallGetByRange(startKey string, limitKey string) ([]*db.KeyValue, error) {
-//	if err := CheckStateKey(startKey); err != nil {
-//		return nil, err
-//	}
-//
-//	if err := CheckStateKey(limitKey); err != nil {
-//		return nil, err
-//	}
-//
-//	result, err := p.ccall("GetByRange", startKey, limitKey)
-//	return result.([]*db.KeyValue), err
-//}
+// CCallGetByPrefix returns up to count contract states with prefix from startKey on in ascending key order, 0 for all,
+// and the key the next page starts at, "" if there is none
+func (p *WorkerProc) CCallGetByPrefix(prefix, startKey string, count int) ([]*db.KeyValue, string, error) {
+	if err := CheckStateKey(prefix); err != nil {
+		return nil, "", err
+	}
+	if err := CheckStateKey(startKey); err != nil {
+		return nil, "", err
+	}
+
+	return p.getStates(&state.KeyRange{Prefix: prefix, Start: startKey, Count: count}, "GetByPrefix", prefix, startKey)
+}
+
+// CCallGetByRange returns up to count contract states from startKey up to but excluding limitKey in ascending key order,
+// an empty limitKey leaves the range open, and the key the next page starts at, "" if there is none
+func (p *WorkerProc) CCallGetByRange(startKey, limitKey string, count int) ([]*db.KeyValue, string, error) {
+	if err := CheckStateKey(startKey); err != nil {
+		return nil, "", err
+	}
+	if err := CheckStateKey(limitKey); err != nil {
+		return nil, "", err
+	}
+
+	return p.getStates(&state.KeyRange{Start: startKey, End: limitKey, Count: count}, "GetByRange", startKey, limitKey)
+}
+
+// getStates merges the uncommitted changes of the contract in r into the states funcName returns
+func (p *WorkerProc) getStates(r *state.KeyRange, funcName, key1, key2 string) ([]*db.KeyValue, string, error) {
+	if r.Count < 0 {
+		return nil, "", errors.New("count can't be negative")
+	}
+
+	var changes []*db.KeyValue
+	for key, value := range p.StateChangeQueue.stateMap {
+		if r.Contains(key) && !state.ReservedContractKey(key) {
+			changes = append(changes, &db.KeyValue{Key: []byte(key), Value: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return bytes.Compare(changes[i].Key, changes[j].Key) < 0
+	})
+
+	// one more state than the page tells the key the next page starts at, a change hides at most one state
+	count := 0
+	if r.Count > 0 {
+		count = r.Count + 1
+	}
+	fetch := 0
+	if count > 0 {
+		fetch = count + len(changes)
+	}
+	result, err := p.ccall(funcName, key1, key2, fetch)
+	if err != nil {
+		return nil, "", err
+	}
+
+	states := state.MergeStates(result.([]*db.KeyValue), changes, count)
+	if r.Count > 0 && len(states) > r.Count {
+		return states[:r.Count], string(states[r.Count].Key), nil
+	}
+	return states, "", nil
+}
+
+// CCallEmit buffers an event of the contract, data is its JSON encoding. The events reach the handler on commit,
+// so the events of a failed transaction are discarded
+func (p *WorkerProc) CCallEmit(name string, data []byte) error {
+	if err := CheckEvent(name, data); err != nil {
+		return err
+	}
+	if p.EventQueue.lst.Len() >= VMConf.ExecLimitMaxEventCount {
+		return errors.New("event count illegal, max count is:" + strconv.Itoa(VMConf.ExecLimitMaxEventCount))
+	}
+
+	p.EventQueue.offer(&eventOpfunc{name, data})
+	return nil
+}
+
+// CCallCall invokes funcName of the contract at contractAddr with args on behalf of the contract and returns the result
+// of its Invoke. The changes of the contract are committed first so the called contract sees them. A call failing or
+// returning false fails the transaction, the changes of every contract it committed are discarded with it
+func (p *WorkerProc) CCallCall(contractAddr, funcName string, args []string) (interface{}, error) {
+	if p.callErr != nil {
+		return nil, p.callErr
+	}
+	if err := CheckAddr(contractAddr); err != nil {
+		return nil, err
+	}
+	if p.CallDepth >= VMConf.ExecLimitStackDepth {
+		return nil, errors.New("call depth illegal, max depth is:" + strconv.Itoa(VMConf.ExecLimitStackDepth))
+	}
+	if p.Invoker == nil {
+		return nil, errors.New("contract can't call other contracts here")
+	}
+
+	result, err := p.call(account.HexToAddress(contractAddr), funcName, args)
+	if err != nil {
+		p.callErr = fmt.Errorf("call of contract %s failed -- %s", contractAddr, err)
+		return nil, p.callErr
+	}
+	return result, nil
+}
+
+// call runs the Invoke of the contract at addr as a transaction sent by the contract
+func (p *WorkerProc) call(addr account.Address, funcName string, args []string) (interface{}, error) {
+	if err := p.CCallCommit(); err != nil {
+		return nil, err
+	}
+	// the called contract may change the states and balances cached by the queues
+	p.StateChangeQueue = NewStateQueue()
+	p.TransferQueue = NewTransferQueue()
+
+	tx := p.ContractData.Transaction
+	callTx := proto.NewTransaction(tx.GetHeader().GetFromChain(),
+		tx.GetHeader().GetToChain(),
+		proto.TransactionType_ContractInvoke,
+		tx.GetHeader().GetNonce(),
+		tx.Recipient(),
+		addr,
+		0,
+		big.NewInt(0),
+		big.NewInt(0),
+		tx.GetHeader().GetCreateTime())
+	callTx.ContractSpec = &proto.ContractSpec{Addr: addr.Bytes(), Params: append([]string{funcName}, args...)}
+
+	if _, err := p.ccall("EnterContract", callTx); err != nil {
+		return nil, err
+	}
+	defer p.ccall("ExitContract")
+
+	return p.Invoker(&WorkerProc{
+		ContractData: NewContractData(callTx),
+		SCHandler:    p.SCHandler,
+		Invoker:      p.Invoker,
+		CallDepth:    p.CallDepth + 1,
+	})
+}
 
 func (p *WorkerProc) CCallComplexQuery(key string) ([]byte, error) {
 	if err := CheckStateKey(key); err != nil {
@@ -174,16 +308,19 @@ func (p *WorkerProc) CCallComplexQuery(key string) ([]byte, error) {
 	return result.([]byte), err
 }
 
-func (p *WorkerProc) CCallGetBalance(addr string, assetID uint32) (int64, error) {
+func (p *WorkerProc) CCallGetBalance(addr string, assetID uint32) (*big.Int, error) {
 	if err := CheckAddr(addr); err != nil {
-		return 0, err
+		return nil, err
 	}
 	if v, ok := p.TransferQueue.balancesMap[addr]; ok {
-		return v.Amounts[assetID], nil
+		return v.Get(assetID), nil
 	}
 
 	result, err := p.ccall("GetBalance", addr, assetID)
-	return result.(int64), err
+	if err != nil {
+		return nil, err
+	}
+	return result.(*big.Int), nil
 }
 
 func (p *WorkerProc) CCallGetBalances(addr string) (*ltyes.Balance, error) {
@@ -203,12 +340,12 @@ func (p *WorkerProc) CCallCurrentBlockHeight() (uint32, error) {
 	return result.(uint32), err
 }
 
-func (p *WorkerProc) CCallTransfer(recipientAddr string, id, amount int64, fee int64) error {
-	log.Debugf("CCallTransfer recipientAddr:%s, id:%d, amount:%d, fee:%d\n", recipientAddr, id, amount, fee)
+func (p *WorkerProc) CCallTransfer(recipientAddr string, id int64, amount, fee *big.Int) error {
+	log.Debugf("CCallTransfer recipientAddr:%s, id:%d, amount:%s, fee:%s\n", recipientAddr, id, amount, fee)
 	if err := CheckAddr(recipientAddr); err != nil {
 		return err
 	}
-	if amount <= 0 {
+	if amount.Sign() <= 0 {
 		return errors.New("amount must above 0")
 	}
 
@@ -221,10 +358,10 @@ func (p *WorkerProc) CCallTransfer(recipientAddr string, id, amount int64, fee i
 		if err != nil || result == nil {
 			return fmt.Errorf("get balance error -- %s", err)
 		}
-		contractBalances.Amounts[uint32(id)] = result.(int64)
+		contractBalances.Set(uint32(id), result.(*big.Int))
 	}
 
-	if b, ok := contractBalances.Amounts[uint32(id)]; !ok || b < amount {
+	if b, ok := contractBalances.Amounts[uint32(id)]; !ok || b.Cmp(amount) < 0 {
 		return errors.New("balances not enough")
 	}
 
@@ -236,16 +373,13 @@ func (p *WorkerProc) CCallTransfer(recipientAddr string, id, amount int64, fee i
 		if err != nil || result == nil {
 			return errors.New("get balance error")
 		}
-		recipientBalances.Amounts[uint32(id)] = result.(int64)
+		recipientBalances.Set(uint32(id), result.(*big.Int))
 	}
 
-	contractBalances.Amounts[uint32(id)] = contractBalances.Amounts[uint32(id)] - amount
+	contractBalances.Add(uint32(id), new(big.Int).Neg(amount))
 	p.TransferQueue.balancesMap[contractAddr] = contractBalances
 
-	if _, ok := recipientBalances.Amounts[uint32(id)]; !ok {
-		recipientBalances.Amounts[uint32(id)] = 0
-	}
-	recipientBalances.Amounts[uint32(id)] = recipientBalances.Amounts[uint32(id)] + amount
+	recipientBalances.Add(uint32(id), amount)
 	p.TransferQueue.balancesMap[recipientAddr] = recipientBalances
 	p.TransferQueue.offer(&transferOpfunc{fee, contractAddr, recipientAddr, uint32(id), amount})
 
@@ -263,6 +397,10 @@ func (p *WorkerProc) CCallSmartContractCommitted() error {
 }
 
 func (p *WorkerProc) CCallCommit() error {
+	if p.callErr != nil {
+		return p.callErr
+	}
+
 	for {
 		txOP := p.TransferQueue.poll()
 		if txOP == nil {
@@ -286,12 +424,34 @@ func (p *WorkerProc) CCallCommit() error {
 				return err
 			}
 		} else if stateOP.optype == stateOpTypeDelete {
-			if _, err := p.ccall("DelState", nil, stateOP.key); err != nil {
+			if _, err := p.ccall("DelState", stateOP.key); err != nil {
 				return err
 			}
 		}
 	}
 
+	for {
+		eventOP := p.EventQueue.poll()
+		if eventOP == nil {
+			break
+		}
+		if _, err := p.ccall("Emit", eventOP.name, eventOP.data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckReadOnly returns an error if the contract changed the state, transferred, emitted events or failed a call,
+// a query can't commit changes
+func (p *WorkerProc) CheckReadOnly() error {
+	if p.callErr != nil {
+		return p.callErr
+	}
+	if p.StateChangeQueue.lst.Len() > 0 || p.TransferQueue.lst.Len() > 0 || p.EventQueue.lst.Len() > 0 {
+		return state.ErrReadOnly
+	}
 	return nil
 }
 
@@ -332,32 +492,36 @@ func (p *WorkerProc) ccall(funcName string, params ...interface{}) (interface{},
 		}
 		key := params[0].(string)
 		value := params[1].([]byte)
-		p.SCHandler.PutState(key, value)
-		return true, nil
+		return true, p.SCHandler.PutState(key, value)
 
 	case "DelState":
 		if !p.checkParamsCnt(1, params...) {
 			return nil, ErrNoValidParamsCnt
 		}
-		p.SCHandler.DelState(params[0].(string))
-		return true, nil
-	//case "GetByPrefix":
-	//	if !p.checkParamsCnt(1, params...) {
-	//		return nil, ErrNoValidParamsCnt
-	//	}
-	//
-	//	prefix := params[0].(string)
-	//	return p.SCHandler.GetByPrefix(prefix)
-	//
-	//case "GetByRange":
-	//	if !p.checkParamsCnt(2, params...) {
-	//		return nil, ErrNoValidParamsCnt
-	//	}
-	//
-	//	startKey := params[0].(string)
-	//	limitKey := params[1].(string)
-	//
-	//	return p.SCHandler.GetByRange(startKey, limitKey)
+		return true, p.SCHandler.DelState(params[0].(string))
+	case "GetByPrefix":
+		if !p.checkParamsCnt(3, params...) {
+			return nil, ErrNoValidParamsCnt
+		}
+		return p.SCHandler.GetByPrefix(params[0].(string), params[1].(string), params[2].(int))
+
+	case "GetByRange":
+		if !p.checkParamsCnt(3, params...) {
+			return nil, ErrNoValidParamsCnt
+		}
+		return p.SCHandler.GetByRange(params[0].(string), params[1].(string), params[2].(int))
+	case "Emit":
+		if !p.checkParamsCnt(2, params...) {
+			return nil, ErrNoValidParamsCnt
+		}
+		return true, p.SCHandler.Emit(params[0].(string), params[1].([]byte))
+	case "EnterContract":
+		if !p.checkParamsCnt(1, params...) {
+			return nil, ErrNoValidParamsCnt
+		}
+		return true, p.SCHandler.EnterContract(params[0].(*proto.Transaction))
+	case "ExitContract":
+		return true, p.SCHandler.ExitContract()
 	case "GetBalance":
 		if !p.checkParamsCnt(2, params...) {
 			return nil, ErrNoValidParamsCnt
@@ -387,11 +551,10 @@ func (p *WorkerProc) ccall(funcName string, params ...interface{}) (interface{},
 		fromAddr := params[0].(string)
 		toAddr := params[1].(string)
 		assetID := params[2].(uint32)
-		amount := params[3].(int64)
-		fee := params[4].(int64)
+		amount := params[3].(*big.Int)
+		fee := params[4].(*big.Int)
 
-		p.SCHandler.AddTransfer(fromAddr, toAddr, assetID, amount, fee)
-		return true, nil
+		return true, p.SCHandler.AddTransfer(fromAddr, toAddr, assetID, amount, fee)
 	}
 
 	return false, errors.New("no method match:" + funcName)
@@ -19,6 +19,8 @@ package jsvm
 
 import (
 	"bytes"
+	"encoding/json"
+	"math/big"
 	"time"
 
 	"github.com/robertkrimen/otto"
@@ -44,14 +46,16 @@ func exporter(ottoVM *otto.Otto, workerProc *vm.WorkerProc) (*otto.Object, error
 	exporterFuncs.Set("PutState", putStateFunc(workerProc))
 	exporterFuncs.Set("DelState", delStateFunc(workerProc))
 
-	//exporterFuncs.Set("GetByPrefix", getByPrefixFunc(workerProc))
-	//exporterFuncs.Set("GetByRange", getByRangeFunc(workerProc))
+	exporterFuncs.Set("GetByPrefix", getByPrefixFunc(workerProc))
+	exporterFuncs.Set("GetByRange", getByRangeFunc(workerProc))
 	exporterFuncs.Set("ComplexQuery", complexQueryFunc(workerProc))
 	exporterFuncs.Set("GetBalance", getBalanceFunc(workerProc))
 	exporterFuncs.Set("GetBalances", getBalancesFunc(workerProc))
 	exporterFuncs.Set("Account", accountFunc(workerProc))
 	exporterFuncs.Set("TxInfo", txInfoFunc(workerProc))
 	exporterFuncs.Set("Transfer", transferFunc(workerProc))
+	exporterFuncs.Set("Emit", emitFunc(workerProc))
+	exporterFuncs.Set("Call", callFunc(workerProc))
 	exporterFuncs.Set("CurrentBlockHeight", currentBlockHeightFunc(workerProc))
 	exporterFuncs.Set("Sleep", sleepFunc(workerProc))
 
@@ -213,60 +217,96 @@ func delStateFunc(workerProc *vm.WorkerProc) interface{} {
 	}
 }
 
-//
-//func getByPrefixFunc(workerProc *vm.WorkerProc) interface{} {
-//	return func(fc otto.FunctionCall) otto.Value {
-//		if len(fc.ArgumentList) != 1 {
-//			log.Error("param illegality when invoke GetByPrefix")
-//			return fc.Otto.MakeCustomError("getByPrefixFunc", "param illegality when invoke GetByPrefix")
-//		}
-//
-//		perfix, err := fc.Argument(0).ToString()
-//		values, err := workerProc.CCallGetByPrefix(perfix)
-//		if err != nil {
-//			log.Errorf("getByPrefix error key:%s  err:%s", perfix, err)
-//			return fc.Otto.MakeCustomError("getByPrefixFunc", "getByPrefix error:"+err.Error())
-//		}
-//		if values == nil {
-//			return otto.NullValue()
-//		}
-//
-//		val, err := kvsToJSValue(values, fc.Otto)
-//		if err != nil {
-//			log.Error("byteToJSvalue error", err)
-//			return fc.Otto.MakeCustomError("getByPrefixFunc", "byteToJSvalue error:"+err.Error())
-//		}
-//		return val
-//	}
-//}
-//
-//func getByRangeFunc(workerProc *vm.WorkerProc) interface{} {
-//	return func(fc otto.FunctionCall) otto.Value {
-//		if len(fc.ArgumentList) != 2 {
-//			log.Error("param illegality when invoke GetByRange")
-//			return fc.Otto.MakeCustomError("getByRangeFunc", "param illegality when invoke GetByRange")
-//		}
-//
-//		startKey, err := fc.Argument(0).ToString()
-//		limitKey, err := fc.Argument(1).ToString()
-//
-//		values, err := workerProc.CCallGetByRange(startKey, limitKey)
-//		if err != nil {
-//			log.Errorf("getByRange error startKey:%s  limitKey:%s  err:%s", startKey, limitKey, err)
-//			return fc.Otto.MakeCustomError("getByRangeFunc", "getByRange error:"+err.Error())
-//		}
-//		if values == nil {
-//			return otto.NullValue()
-//		}
-//
-//		val, err := kvsToJSValue(values, fc.Otto)
-//		if err != nil {
-//			log.Error("byteToJSvalue error", err)
-//			return fc.Otto.MakeCustomError("getByRangeFunc", "byteToJSvalue error:"+err.Error())
-//		}
-//		return val
-//	}
-//}
+// getByPrefixFunc is GetByPrefix(prefix [, count [, startKey]]), it returns the states with prefix as
+// {records: [{key: ..., value: ...}, ...], next: ...} in key order, next is the key the next page starts at or null
+func getByPrefixFunc(workerProc *vm.WorkerProc) interface{} {
+	return func(fc otto.FunctionCall) otto.Value {
+		if len(fc.ArgumentList) < 1 || len(fc.ArgumentList) > 3 {
+			log.Error("param illegality when invoke GetByPrefix")
+			return fc.Otto.MakeCustomError("getByPrefixFunc", "param illegality when invoke GetByPrefix")
+		}
+
+		prefix, err := fc.Argument(0).ToString()
+		if err != nil {
+			log.Error("get string prefix error", err)
+			return fc.Otto.MakeCustomError("getByPrefixFunc", "get string prefix error"+err.Error())
+		}
+		count, err := optInteger(fc.Argument(1))
+		if err != nil {
+			log.Error("get integer count error", err)
+			return fc.Otto.MakeCustomError("getByPrefixFunc", "get integer count error"+err.Error())
+		}
+		startKey := ""
+		if fc.Argument(2).IsDefined() {
+			if startKey, err = fc.Argument(2).ToString(); err != nil {
+				log.Error("get string startKey error", err)
+				return fc.Otto.MakeCustomError("getByPrefixFunc", "get string startKey error"+err.Error())
+			}
+		}
+
+		values, next, err := workerProc.CCallGetByPrefix(prefix, startKey, count)
+		if err != nil {
+			log.Errorf("getByPrefix error prefix:%s  err:%s", prefix, err)
+			return fc.Otto.MakeCustomError("getByPrefixFunc", "getByPrefix error:"+err.Error())
+		}
+
+		val, err := kvsToJSValue(values, next, fc.Otto)
+		if err != nil {
+			log.Error("byteToJSvalue error", err)
+			return fc.Otto.MakeCustomError("getByPrefixFunc", "byteToJSvalue error:"+err.Error())
+		}
+		return val
+	}
+}
+
+// getByRangeFunc is GetByRange(startKey, limitKey [, count]), it returns the states from startKey up to but excluding limitKey
+// as {records: [{key: ..., value: ...}, ...], next: ...} in key order, next is the key the next page starts at or null
+func getByRangeFunc(workerProc *vm.WorkerProc) interface{} {
+	return func(fc otto.FunctionCall) otto.Value {
+		if len(fc.ArgumentList) < 2 || len(fc.ArgumentList) > 3 {
+			log.Error("param illegality when invoke GetByRange")
+			return fc.Otto.MakeCustomError("getByRangeFunc", "param illegality when invoke GetByRange")
+		}
+
+		startKey, err := fc.Argument(0).ToString()
+		if err != nil {
+			log.Error("get string startKey error", err)
+			return fc.Otto.MakeCustomError("getByRangeFunc", "get string startKey error"+err.Error())
+		}
+		limitKey, err := fc.Argument(1).ToString()
+		if err != nil {
+			log.Error("get string limitKey error", err)
+			return fc.Otto.MakeCustomError("getByRangeFunc", "get string limitKey error"+err.Error())
+		}
+		count, err := optInteger(fc.Argument(2))
+		if err != nil {
+			log.Error("get integer count error", err)
+			return fc.Otto.MakeCustomError("getByRangeFunc", "get integer count error"+err.Error())
+		}
+
+		values, next, err := workerProc.CCallGetByRange(startKey, limitKey, count)
+		if err != nil {
+			log.Errorf("getByRange error startKey:%s  limitKey:%s  err:%s", startKey, limitKey, err)
+			return fc.Otto.MakeCustomError("getByRangeFunc", "getByRange error:"+err.Error())
+		}
+
+		val, err := kvsToJSValue(values, next, fc.Otto)
+		if err != nil {
+			log.Error("byteToJSvalue error", err)
+			return fc.Otto.MakeCustomError("getByRangeFunc", "byteToJSvalue error:"+err.Error())
+		}
+		return val
+	}
+}
+
+// optInteger returns the integer of an optional argument, 0 if it's undefined
+func optInteger(value otto.Value) (int, error) {
+	if !value.IsDefined() {
+		return 0, nil
+	}
+	n, err := value.ToInteger()
+	return int(n), err
+}
 
 func complexQueryFunc(workerProc *vm.WorkerProc) interface{} {
 	return func(fc otto.FunctionCall) otto.Value {
@@ -313,7 +353,7 @@ func getBalanceFunc(workerProc *vm.WorkerProc) interface{} {
 			return fc.Otto.MakeCustomError("getBalance", "getBalance error:"+err.Error())
 		}
 
-		val, err := otto.ToValue(res)
+		val, err := amountToValue(res, fc.Otto)
 		if err != nil {
 			log.Errorf("CCallGetBalance Error, addr: %s, assetID: %d, err: %s", addr, assetID, err)
 			return fc.Otto.MakeCustomError("getBalance", "getBalance error:"+err.Error())
@@ -355,7 +395,7 @@ func getBalancesFunc(workerProc *vm.WorkerProc) interface{} {
 func txInfoFunc(workerProc *vm.WorkerProc) interface{} {
 	return func(fc otto.FunctionCall) otto.Value {
 		var addr, sender, recipient string
-		var amount, fee int64
+		var amount, fee *big.Int
 		var err error
 		if len(fc.ArgumentList) == 1 {
 			addr, err = fc.Argument(0).ToString()
@@ -366,21 +406,21 @@ func txInfoFunc(workerProc *vm.WorkerProc) interface{} {
 		sender = workerProc.ContractData.Transaction.Sender().String()
 		recipient = workerProc.ContractData.Transaction.Recipient().String()
 
-		amount = workerProc.ContractData.Transaction.GetHeader().GetAmount()
-		amountValue, err := fc.Otto.ToValue(amount)
+		amount = workerProc.ContractData.Transaction.Amount()
+		amountValue, err := amountToValue(amount, fc.Otto)
 		if err != nil {
 			log.Error("accountFunc -> call amount ToLValue error", err)
 			return fc.Otto.MakeCustomError("accountFunc", "call call amount ToLValue error:"+err.Error())
 		}
 
-		fee = workerProc.ContractData.Transaction.GetHeader().GetFee()
-		feeValue, err := fc.Otto.ToValue(fee)
+		fee = workerProc.ContractData.Transaction.Fee()
+		feeValue, err := amountToValue(fee, fc.Otto)
 		if err != nil {
 			log.Error("accountFunc -> call amount ToLValue error", err)
 			return fc.Otto.MakeCustomError("accountFunc", "call call amount ToLValue error:"+err.Error())
 		}
 
-		assetID := workerProc.ContractData.Transaction.GetHeader().GetFee()
+		assetID := workerProc.ContractData.Transaction.GetHeader().GetAssetID()
 		assetIDValue, err := fc.Otto.ToValue(assetID)
 		if err != nil {
 			log.Error("accountFunc -> call amount ToLValue error", err)
@@ -407,7 +447,7 @@ func txInfoFunc(workerProc *vm.WorkerProc) interface{} {
 func accountFunc(workerProc *vm.WorkerProc) interface{} {
 	return func(fc otto.FunctionCall) otto.Value {
 		var addr, sender, recipient string
-		var amount int64
+		var amount *big.Int
 		var err error
 		if len(fc.ArgumentList) == 1 {
 			addr, err = fc.Argument(0).ToString()
@@ -430,8 +470,8 @@ func accountFunc(workerProc *vm.WorkerProc) interface{} {
 		sender = workerProc.ContractData.Transaction.Sender().String()
 		recipient = workerProc.ContractData.Transaction.Recipient().String()
 
-		amount = workerProc.ContractData.Transaction.GetHeader().GetAmount()
-		amountValue, err := fc.Otto.ToValue(amount)
+		amount = workerProc.ContractData.Transaction.Amount()
+		amountValue, err := amountToValue(amount, fc.Otto)
 		if err != nil {
 			log.Error("accountFunc -> call amount ToLValue error", err)
 			return fc.Otto.MakeCustomError("accountFunc", "call call amount ToLValue error:"+err.Error())
@@ -472,13 +512,13 @@ func transferFunc(workerProc *vm.WorkerProc) interface{} {
 			return fc.Otto.MakeCustomError("transferFunc", err.Error())
 		}
 
-		amout, err := fc.Argument(2).ToInteger()
+		amout, err := valueToAmount(fc.Argument(2))
 		if err != nil {
 			log.Errorf("transferFunc -> get amout arg error")
 			return fc.Otto.MakeCustomError("transferFunc", err.Error())
 		}
 
-		fee, err := fc.Argument(3).ToInteger()
+		fee, err := valueToAmount(fc.Argument(3))
 		if err != nil {
 			log.Errorf("transferFunc -> get fee arg error")
 			return fc.Otto.MakeCustomError("transferFunc", err.Error())
@@ -486,7 +526,7 @@ func transferFunc(workerProc *vm.WorkerProc) interface{} {
 
 		err = workerProc.CCallTransfer(recipientAddr, id, amout, fee)
 		if err != nil {
-			log.Errorf("transferFunc -> contract do transfer error recipientAddr:%s, amout:%d, fee:%d  err:%s", recipientAddr, amout, fee, err)
+			log.Errorf("transferFunc -> contract do transfer error recipientAddr:%s, amout:%s, fee:%s  err:%s", recipientAddr, amout, fee, err)
 			return fc.Otto.MakeCustomError("transferFunc", err.Error())
 		}
 
@@ -528,3 +568,73 @@ func currentBlockHeightFunc(workerProc *vm.WorkerProc) interface{} {
 		return val
 	}
 }
+
+// emitFunc is Emit(name [, data]), it emits an event of the contract with data encoded as JSON
+func emitFunc(workerProc *vm.WorkerProc) interface{} {
+	return func(fc otto.FunctionCall) otto.Value {
+		if len(fc.ArgumentList) < 1 || len(fc.ArgumentList) > 2 {
+			log.Error("param illegality when invoke Emit")
+			return fc.Otto.MakeCustomError("emitFunc", "param illegality when invoke Emit")
+		}
+
+		name, err := fc.Argument(0).ToString()
+		if err != nil {
+			log.Error("get string name error", err)
+			return fc.Otto.MakeCustomError("emitFunc", "get string name error"+err.Error())
+		}
+		value, err := fc.Argument(1).Export()
+		if err != nil {
+			log.Errorf("emit error name:%s  err:%s", name, err)
+			return fc.Otto.MakeCustomError("emitFunc", "emit error:"+err.Error())
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			log.Errorf("emit error name:%s  err:%s", name, err)
+			return fc.Otto.MakeCustomError("emitFunc", "emit error:"+err.Error())
+		}
+
+		if err := workerProc.CCallEmit(name, data); err != nil {
+			log.Errorf("emit error name:%s  err:%s", name, err)
+			return fc.Otto.MakeCustomError("emitFunc", "emit error:"+err.Error())
+		}
+
+		val, _ := otto.ToValue(true)
+		return val
+	}
+}
+
+// callFunc is Call(contractAddr, funcName [, args]), it invokes funcName of another contract with the array of string
+// args and returns the result of its Invoke
+func callFunc(workerProc *vm.WorkerProc) interface{} {
+	return func(fc otto.FunctionCall) otto.Value {
+		if len(fc.ArgumentList) < 2 || len(fc.ArgumentList) > 3 {
+			log.Error("param illegality when invoke Call")
+			return fc.Otto.MakeCustomError("callFunc", "param illegality when invoke Call")
+		}
+
+		contractAddr, err := fc.Argument(0).ToString()
+		if err != nil {
+			log.Error("get string contractAddr error", err)
+			return fc.Otto.MakeCustomError("callFunc", "get string contractAddr error"+err.Error())
+		}
+		funcName, err := fc.Argument(1).ToString()
+		if err != nil {
+			log.Error("get string funcName error", err)
+			return fc.Otto.MakeCustomError("callFunc", "get string funcName error"+err.Error())
+		}
+		args, err := valueToArgs(fc.Argument(2))
+		if err != nil {
+			log.Errorf("call error contractAddr:%s  err:%s", contractAddr, err)
+			return fc.Otto.MakeCustomError("callFunc", "call error:"+err.Error())
+		}
+
+		result, err := workerProc.CCallCall(contractAddr, funcName, args)
+		if err != nil {
+			log.Errorf("call error contractAddr:%s  err:%s", contractAddr, err)
+			return fc.Otto.MakeCustomError("callFunc", "call error:"+err.Error())
+		}
+
+		val, _ := otto.ToValue(result)
+		return val
+	}
+}
@@ -86,6 +86,8 @@ func (worker *JsWorker) requestHandle(wp *vm.WorkerProc) (interface{}, error) {
 		return worker.InvokeExecute(wp)
 	} else if txType == proto.TransactionType_ContractQuery {
 		return worker.QueryContract(wp)
+	} else if txType == proto.TransactionType_ContractUpgrade {
+		return worker.UpgradeContract(wp)
 	}
 
 	return nil, errors.New(fmt.Sprintf("luavm no method match transaction type: %d", txType))
@@ -106,8 +108,13 @@ func (worker *JsWorker) InitContract(wp *vm.WorkerProc) (interface{}, error) {
 	}
 
 	ok, err := worker.execContract(wp.ContractData, "Init")
-	if !ok.(bool) || err != nil {
-		return ok, err
+	if err != nil {
+		return false, err
+	}
+	if initialized, isBool := ok.(bool); !isBool {
+		return false, errors.New("InitContract execContract result type is not bool")
+	} else if !initialized {
+		return false, vm.ErrResultFalse
 	}
 
 	err = worker.workerProc.CCallCommit()
@@ -121,12 +128,12 @@ func (worker *JsWorker) InitContract(wp *vm.WorkerProc) (interface{}, error) {
 
 // RealExecute real call Invoke and commit all change
 func (worker *JsWorker) InvokeExecute(wp *vm.WorkerProc) (interface{}, error) {
+	worker.resetProc(wp)
 	err := worker.txTransfer()
 	if err != nil {
 		return nil, err
 	}
 
-	worker.resetProc(wp)
 	if len(wp.ContractData.ContractCode) == 0 {
 		code, err := worker.GetContractCode()
 		if err != nil {
@@ -136,8 +143,71 @@ func (worker *JsWorker) InvokeExecute(wp *vm.WorkerProc) (interface{}, error) {
 	}
 
 	ok, err := worker.execContract(wp.ContractData, "Invoke")
-	if !ok.(bool) || err != nil {
-		return ok, err
+	if err != nil {
+		return false, err
+	}
+	if invoked, isBool := ok.(bool); !isBool {
+		return false, errors.New("RealExecute execContract result type is not bool")
+	} else if !invoked {
+		return false, vm.ErrResultFalse
+	}
+
+	err = worker.workerProc.CCallCommit()
+	if err != nil {
+		log.Errorf("commit all change error contractAddr:%s, errmsg:%s\n", worker.workerProc.ContractData.ContractAddr, err.Error())
+		return false, err
+	}
+
+	return ok, err
+}
+
+// CallContract call Invoke of a contract called by another contract and commit all change,
+// the result is the true or string Invoke returns, false fails the call
+func (worker *JsWorker) CallContract(wp *vm.WorkerProc) (interface{}, error) {
+	worker.resetProc(wp)
+	if len(wp.ContractData.ContractCode) == 0 {
+		code, err := worker.GetContractCode()
+		if err != nil {
+			return nil, errors.New("can't get contract code")
+		}
+		wp.ContractData.ContractCode = string(code)
+	}
+
+	result, err := worker.execContract(wp.ContractData, "Invoke")
+	if err != nil {
+		return nil, err
+	}
+	if ok, isBool := result.(bool); isBool && !ok {
+		return nil, vm.ErrResultFalse
+	}
+
+	err = worker.workerProc.CCallCommit()
+	if err != nil {
+		log.Errorf("commit all change error contractAddr:%s, errmsg:%s\n", worker.workerProc.ContractData.ContractAddr, err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpgradeContract replace the contract code with the code of the upgrade transaction,
+// call Migrate of the new code if it has one and commit all change
+func (worker *JsWorker) UpgradeContract(wp *vm.WorkerProc) (interface{}, error) {
+	// a new js vm, Migrate of code run before mustn't be called
+	worker.workerInit(true, worker.VMConf)
+	worker.resetProc(wp)
+	err := worker.txTransfer()
+	if err != nil {
+		return nil, err
+	}
+
+	wp.ContractData.ContractCode = string(wp.ContractData.Transaction.GetContractSpec().GetCode())
+	ok, err := worker.execContract(wp.ContractData, "Migrate")
+	if err != nil {
+		return false, err
+	}
+	if migrated, isBool := ok.(bool); !isBool || !migrated {
+		return false, errors.New("UpgradeContract Migrate result is not true")
 	}
 
 	err = worker.workerProc.CCallCommit()
@@ -152,11 +222,27 @@ func (worker *JsWorker) InvokeExecute(wp *vm.WorkerProc) (interface{}, error) {
 // QueryContract call Query not commit change
 func (worker *JsWorker) QueryContract(wp *vm.WorkerProc) ([]byte, error) {
 	worker.resetProc(wp)
+	if len(wp.ContractData.ContractCode) == 0 {
+		code, err := worker.GetContractCode()
+		if err != nil {
+			return nil, errors.New("can't get contract code")
+		}
+		wp.ContractData.ContractCode = string(code)
+	}
+
 	result, err := worker.execContract(wp.ContractData, "Query")
 	if err != nil {
 		return nil, err
 	}
-	return []byte(result.(string)), nil
+	if err := worker.workerProc.CheckReadOnly(); err != nil {
+		return nil, err
+	}
+
+	value, ok := result.(string)
+	if !ok {
+		return nil, errors.New("QueryContract execContract result type is not string")
+	}
+	return []byte(value), nil
 }
 
 func (worker *JsWorker) resetProc(wp *vm.WorkerProc) {
@@ -164,6 +250,7 @@ func (worker *JsWorker) resetProc(wp *vm.WorkerProc) {
 	exporter(worker.ottoVM, worker.workerProc)
 	wp.StateChangeQueue = vm.NewStateQueue()
 	wp.TransferQueue = vm.NewTransferQueue()
+	wp.EventQueue = vm.NewEventQueue()
 }
 
 func (worker *JsWorker) txTransfer() error {
@@ -284,6 +371,12 @@ func (worker *JsWorker) CheckContractCode(code string) error {
 
 func callJSFunc(ottoVM *otto.Otto, cd *vm.ContractData, funcName string) (val otto.Value, err error) {
 	count := len(cd.ContractParams)
+	if "Migrate" == funcName {
+		// code without Migrate needs no migration
+		if fn, err := ottoVM.Get(funcName); err != nil || fn.IsUndefined() {
+			return otto.TrueValue(), err
+		}
+	}
 	if "Invoke" == funcName {
 		if count == 0 {
 			val, err = ottoVM.Call(funcName, nil, otto.NullValue(), otto.NullValue())
@@ -20,9 +20,12 @@ package jsvm
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"math/big"
 	"strconv"
 
 	"github.com/robertkrimen/otto"
+	"github.com/zipper-project/zipper/common/db"
 	"github.com/zipper-project/zipper/common/utils"
 	ltyes "github.com/zipper-project/zipper/ledger/balance"
 )
@@ -164,23 +167,45 @@ func byteToJSvalue(buf *bytes.Buffer, ottoVM *otto.Otto) (otto.Value, error) {
 	return otto.NullValue(), nil
 }
 
-//func kvsToJSValue(kvs []*db.KeyValue, ottoVM *otto.Otto) (otto.Value, error) {
-//	mp := make(map[string]interface{})
-//	for _, v := range kvs {
-//		buf := bytes.NewBuffer(v.Value)
-//		value, err := byteToJSvalue(buf, ottoVM)
-//		if err != nil {
-//			return otto.NullValue(), err
-//		}
-//		mp[string(v.Key)] = value
-//	}
-//	return ottoVM.ToValue(mp)
-//}
-//
+// kvsToJSValue returns the states as {records: [{key: ..., value: ...}, ...], next: ...}, a null next if it's empty
+func kvsToJSValue(kvs []*db.KeyValue, next string, ottoVM *otto.Otto) (otto.Value, error) {
+	records, err := ottoVM.Object("[]")
+	if err != nil {
+		return otto.NullValue(), err
+	}
+	for _, kv := range kvs {
+		value, err := byteToJSvalue(bytes.NewBuffer(kv.Value), ottoVM)
+		if err != nil {
+			return otto.NullValue(), err
+		}
+		record, err := ottoVM.Object("({})")
+		if err != nil {
+			return otto.NullValue(), err
+		}
+		record.Set("key", string(kv.Key))
+		record.Set("value", value)
+		if _, err := records.Call("push", record); err != nil {
+			return otto.NullValue(), err
+		}
+	}
+
+	result, err := ottoVM.Object("({})")
+	if err != nil {
+		return otto.NullValue(), err
+	}
+	result.Set("records", records)
+	if next == "" {
+		result.Set("next", otto.NullValue())
+	} else {
+		result.Set("next", next)
+	}
+	return result.Value(), nil
+}
+
 func objToLValue(balance *ltyes.Balance, ottoVM *otto.Otto) (otto.Value, error) {
 	amountsMp := make(map[string]interface{})
 	for k, v := range balance.Amounts {
-		value, err := ottoVM.ToValue(v)
+		value, err := amountToValue(v, ottoVM)
 		if err != nil {
 			return otto.NullValue(), err
 		}
@@ -194,3 +219,63 @@ func objToLValue(balance *ltyes.Balance, ottoVM *otto.Otto) (otto.Value, error)
 
 	return amounts, nil
 }
+
+// maxExactNumber is the largest integer a javascript number holds exactly
+var maxExactNumber = big.NewInt(1 << 53)
+
+// amountToValue returns an amount as a javascript number, or as a decimal string if a number can't hold it exactly
+func amountToValue(amount *big.Int, ottoVM *otto.Otto) (otto.Value, error) {
+	if new(big.Int).Abs(amount).Cmp(maxExactNumber) <= 0 {
+		return ottoVM.ToValue(amount.Int64())
+	}
+	return ottoVM.ToValue(amount.String())
+}
+
+// valueToAmount returns the amount given to a contract function as a javascript number or a decimal string
+func valueToAmount(value otto.Value) (*big.Int, error) {
+	if value.IsString() {
+		if amount, ok := new(big.Int).SetString(value.String(), 10); ok {
+			return amount, nil
+		}
+		return nil, fmt.Errorf("invalid amount %s", value.String())
+	}
+	f, err := value.ToFloat()
+	if err != nil {
+		return nil, err
+	}
+	amount, _ := new(big.Float).SetFloat64(f).Int(nil)
+	return amount, nil
+}
+
+// valueToArgs returns the strings of the elements of the array value, nil if value is undefined
+func valueToArgs(value otto.Value) ([]string, error) {
+	if !value.IsDefined() {
+		return nil, nil
+	}
+	if !value.IsObject() || value.Class() != "Array" {
+		return nil, errors.New("args isn't an array")
+	}
+
+	obj := value.Object()
+	length, err := obj.Get("length")
+	if err != nil {
+		return nil, err
+	}
+	n, err := length.ToInteger()
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for i := int64(0); i < n; i++ {
+		arg, err := obj.Get(strconv.FormatInt(i, 10))
+		if err != nil {
+			return nil, err
+		}
+		s, err := arg.ToString()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, s)
+	}
+	return args, nil
+}
@@ -19,9 +19,11 @@ package luavm
 
 import (
 	"bytes"
+	"math/big"
 	"time"
 
 	"github.com/yuin/gopher-lua"
+	"github.com/zipper-project/zipper/common/db"
 	"github.com/zipper-project/zipper/common/log"
 	"github.com/zipper-project/zipper/vm"
 	luajson "github.com/zipper-project/zipper/vm/luavm/json"
@@ -36,8 +38,8 @@ func exporter(workerProc *vm.WorkerProc) map[string]lua.LGFunction {
 		"PutState":       putStateFunc(workerProc),
 		"DelState":       delStateFunc(workerProc),
 
-		//"GetByPrefix":  getByPrefixFunc(workerProc),
-		//"GetByRange":   getByRangeFunc(workerProc),
+		"GetByPrefix":  getByPrefixFunc(workerProc),
+		"GetByRange":   getByRangeFunc(workerProc),
 		"ComplexQuery": complexQueryFunc(workerProc),
 
 		"GetBalance":  getBalanceFunc(workerProc),
@@ -45,6 +47,8 @@ func exporter(workerProc *vm.WorkerProc) map[string]lua.LGFunction {
 		"Account":     accountFunc(workerProc),
 		"TxInfo":      txInfo(workerProc),
 		"Transfer":    transferFunc(workerProc),
+		"Emit":        emitFunc(workerProc),
+		"Call":        callFunc(workerProc),
 
 		"CurrentBlockHeight": currentBlockHeightFunc(workerProc),
 		"sleep":              sleepFunc(workerProc),
@@ -196,64 +200,65 @@ func delStateFunc(workerProc *vm.WorkerProc) lua.LGFunction {
 	}
 }
 
-//
-//func getByPrefixFunc(workerProc *vm.WorkerProc) lua.LGFunction {
-//	return func(l *lua.LState) int {
-//		if l.GetTop() != 1 {
-//			l.RaiseError("param illegality when invoke getByPrefix")
-//			return 1
-//		}
-//
-//		key := l.CheckString(1)
-//
-//		values, err := workerProc.CCallGetByPrefix(key)
-//
-//		if err != nil {
-//			l.RaiseError("getByPrefix error key:%s  err:%s", key, err)
-//		}
-//		if values == nil {
-//			l.Push(lua.LNil)
-//			return 1
-//		}
-//
-//		if lv, err := kvsToLValue(values); err != nil {
-//			l.RaiseError("byteToLValue error")
-//		} else {
-//			l.Push(lv)
-//		}
-//
-//		return 1
-//	}
-//}
-//
-//func getByRangeFunc(workerProc *vm.WorkerProc) lua.LGFunction {
-//	return func(l *lua.LState) int {
-//		if l.GetTop() != 2 {
-//			l.RaiseError("param illegality when invoke getByRange")
-//			return 1
-//		}
-//
-//		startKey := l.CheckString(1)
-//		limitKey := l.CheckString(2)
-//		values, err := workerProc.CCallGetByRange(startKey, limitKey)
-//		if err != nil {
-//			l.RaiseError("getByRange error startKey:%s ,limitKsy:%s ,err:%s", startKey, limitKey, err)
-//		}
-//		if values == nil {
-//			l.Push(lua.LNil)
-//			return 1
-//		}
-//
-//		lv, err := kvsToLValue(values)
-//		if err != nil {
-//			l.RaiseError("byteToLValue error")
-//		} else {
-//			l.Push(lv)
-//		}
-//
-//		return 1
-//	}
-//}
+// getByPrefixFunc is GetByPrefix(prefix [, count [, startKey]]), it returns the states with prefix as an array of
+// {key = ..., value = ...} tables in key order and the key the next page starts at, nil if there is none
+func getByPrefixFunc(workerProc *vm.WorkerProc) lua.LGFunction {
+	return func(l *lua.LState) int {
+		if l.GetTop() < 1 || l.GetTop() > 3 {
+			l.RaiseError("param illegality when invoke GetByPrefix")
+			return 1
+		}
+
+		prefix := l.CheckString(1)
+		count := l.OptInt(2, 0)
+		startKey := l.OptString(3, "")
+		values, next, err := workerProc.CCallGetByPrefix(prefix, startKey, count)
+		if err != nil {
+			l.RaiseError("getByPrefix error prefix:%s  err:%s", prefix, err)
+			return 1
+		}
+
+		return pushStates(l, values, next)
+	}
+}
+
+// getByRangeFunc is GetByRange(startKey, limitKey [, count]), it returns the states from startKey up to but excluding
+// limitKey as an array of {key = ..., value = ...} tables in key order and the key the next page starts at, nil if there is none
+func getByRangeFunc(workerProc *vm.WorkerProc) lua.LGFunction {
+	return func(l *lua.LState) int {
+		if l.GetTop() < 2 || l.GetTop() > 3 {
+			l.RaiseError("param illegality when invoke GetByRange")
+			return 1
+		}
+
+		startKey := l.CheckString(1)
+		limitKey := l.CheckString(2)
+		count := l.OptInt(3, 0)
+		values, next, err := workerProc.CCallGetByRange(startKey, limitKey, count)
+		if err != nil {
+			l.RaiseError("getByRange error startKey:%s ,limitKey:%s ,err:%s", startKey, limitKey, err)
+			return 1
+		}
+
+		return pushStates(l, values, next)
+	}
+}
+
+func pushStates(l *lua.LState, values []*db.KeyValue, next string) int {
+	lv, err := kvsToLValue(values)
+	if err != nil {
+		l.RaiseError("byteToLValue error")
+		return 1
+	}
+	l.Push(lv)
+	if next == "" {
+		l.Push(lua.LNil)
+	} else {
+		l.Push(lua.LString(next))
+	}
+
+	return 2
+}
 
 func complexQueryFunc(workerProc *vm.WorkerProc) lua.LGFunction {
 	return func(l *lua.LState) int {
@@ -294,8 +299,7 @@ func getBalanceFunc(workerProc *vm.WorkerProc) lua.LGFunction {
 			return 1
 		}
 
-		val := lua.LNumber(res)
-		l.Push(val)
+		l.Push(amountToLValue(res))
 		return 1
 	}
 }
@@ -322,11 +326,11 @@ func getBalancesFunc(workerProc *vm.WorkerProc) lua.LGFunction {
 func txInfo(workerProc *vm.WorkerProc) lua.LGFunction {
 	return func(l *lua.LState) int {
 		var sender, recipient string
-		var amount, fee int64
+		var amount, fee *big.Int
 
 		sender = workerProc.ContractData.Transaction.Sender().String()
-		amount = workerProc.ContractData.Transaction.GetHeader().GetAmount()
-		fee = workerProc.ContractData.Transaction.GetHeader().GetFee()
+		amount = workerProc.ContractData.Transaction.Amount()
+		fee = workerProc.ContractData.Transaction.Fee()
 		recipient = workerProc.ContractData.Transaction.Recipient().String()
 		assetID := workerProc.ContractData.Transaction.GetHeader().GetAssetID()
 		hash := workerProc.ContractData.Transaction.Hash().String()
@@ -335,8 +339,8 @@ func txInfo(workerProc *vm.WorkerProc) lua.LGFunction {
 		tb.RawSetString("Sender", lua.LString(sender))
 		tb.RawSetString("Recipient", lua.LString(recipient))
 		tb.RawSetString("AssetID", lua.LNumber(assetID))
-		tb.RawSetString("Amount", lua.LNumber(amount))
-		tb.RawSetString("Fee", lua.LNumber(fee))
+		tb.RawSetString("Amount", amountToLValue(amount))
+		tb.RawSetString("Fee", amountToLValue(fee))
 		tb.RawSetString("Hash", lua.LString(hash))
 		l.Push(tb)
 		return 1
@@ -346,7 +350,7 @@ func txInfo(workerProc *vm.WorkerProc) lua.LGFunction {
 func accountFunc(workerProc *vm.WorkerProc) lua.LGFunction {
 	return func(l *lua.LState) int {
 		var addr, sender, recipient string
-		var amount int64
+		var amount *big.Int
 		if l.GetTop() == 1 {
 			addr = l.CheckString(1)
 		} else {
@@ -359,13 +363,13 @@ func accountFunc(workerProc *vm.WorkerProc) lua.LGFunction {
 			return 1
 		}
 		sender = workerProc.ContractData.Transaction.Sender().String()
-		amount = workerProc.ContractData.Transaction.GetHeader().GetAmount()
+		amount = workerProc.ContractData.Transaction.Amount()
 		recipient = workerProc.ContractData.Transaction.Recipient().String()
 		tb := l.NewTable()
 		tb.RawSetString("Sender", lua.LString(sender))
 		tb.RawSetString("Address", lua.LString(addr))
 		tb.RawSetString("Recipient", lua.LString(recipient))
-		tb.RawSetString("Amount", lua.LNumber(amount))
+		tb.RawSetString("Amount", amountToLValue(amount))
 		tb.RawSetString("Balances", objToLValue(balances))
 		l.Push(tb)
 		return 1
@@ -381,11 +385,19 @@ func transferFunc(workerProc *vm.WorkerProc) lua.LGFunction {
 
 		recipientAddr := l.CheckString(1)
 		id := int64(float64(l.CheckNumber(2)))
-		amout := int64(float64(l.CheckNumber(3)))
-		fee := int64(float64(l.CheckNumber(4)))
-		err := workerProc.CCallTransfer(recipientAddr, id, amout, fee)
+		amout, err := lvalueToAmount(l.Get(3))
 		if err != nil {
-			l.RaiseError("contract do transfer error recipientAddr:%s,id:%d, amout:%d, fee:%d  err:%s", recipientAddr, id, amout, fee, err)
+			l.RaiseError("contract do transfer error recipientAddr:%s,id:%d, err:%s", recipientAddr, id, err)
+			return 1
+		}
+		fee, err := lvalueToAmount(l.Get(4))
+		if err != nil {
+			l.RaiseError("contract do transfer error recipientAddr:%s,id:%d, err:%s", recipientAddr, id, err)
+			return 1
+		}
+		err = workerProc.CCallTransfer(recipientAddr, id, amout, fee)
+		if err != nil {
+			l.RaiseError("contract do transfer error recipientAddr:%s,id:%d, amout:%s, fee:%s  err:%s", recipientAddr, id, amout, fee, err)
 			return 1
 		}
 
@@ -419,3 +431,67 @@ func currentBlockHeightFunc(workerProc *vm.WorkerProc) lua.LGFunction {
 		return 1
 	}
 }
+
+// emitFunc is Emit(name [, data]), it emits an event of the contract with data encoded as JSON
+func emitFunc(workerProc *vm.WorkerProc) lua.LGFunction {
+	return func(l *lua.LState) int {
+		if l.GetTop() < 1 || l.GetTop() > 2 {
+			l.RaiseError("param illegality when invoke Emit")
+			return 1
+		}
+
+		name := l.CheckString(1)
+		data, err := luajson.Encode(l.Get(2))
+		if err != nil {
+			l.RaiseError("emit error name:%s  err:%s", name, err)
+			return 1
+		}
+		if err := workerProc.CCallEmit(name, data); err != nil {
+			l.RaiseError("emit error name:%s  err:%s", name, err)
+		} else {
+			l.Push(lua.LBool(true))
+		}
+
+		return 1
+	}
+}
+
+// callFunc is Call(contractAddr, funcName [, args]), it invokes funcName of another contract with the array of string
+// args and returns the result of its Invoke
+func callFunc(workerProc *vm.WorkerProc) lua.LGFunction {
+	return func(l *lua.LState) int {
+		if l.GetTop() < 2 || l.GetTop() > 3 {
+			l.RaiseError("param illegality when invoke Call")
+			return 1
+		}
+
+		contractAddr := l.CheckString(1)
+		funcName := l.CheckString(2)
+		var args []string
+		if l.GetTop() == 3 {
+			tb := l.CheckTable(3)
+			for i := 1; i <= tb.Len(); i++ {
+				switch arg := tb.RawGetInt(i).(type) {
+				case lua.LString, lua.LNumber:
+					args = append(args, arg.String())
+				default:
+					l.RaiseError("call error contractAddr:%s  err:arg %d isn't a string or number", contractAddr, i)
+					return 1
+				}
+			}
+		}
+
+		result, err := workerProc.CCallCall(contractAddr, funcName, args)
+		if err != nil {
+			l.RaiseError("call error contractAddr:%s  err:%s", contractAddr, err)
+			return 1
+		}
+
+		if ok, isBool := result.(bool); isBool {
+			l.Push(lua.LBool(ok))
+		} else {
+			l.Push(lua.LString(result.(string)))
+		}
+		return 1
+	}
+}
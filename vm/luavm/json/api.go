@@ -38,6 +38,11 @@ func ApiDecode() lua.LGFunction {
 	}
 }
 
+// Encode returns the JSON encoding of value
+func Encode(value lua.LValue) ([]byte, error) {
+	return toJSON(value, make(map[*lua.LTable]bool))
+}
+
 func ApiEncode() lua.LGFunction {
 	return func(L *lua.LState) int {
 		value := L.CheckAny(1)
@@ -53,7 +53,7 @@ func toJSON(value lua.LValue, visited map[*lua.LTable]bool) (data []byte, err er
 	case *lua.LFunction:
 		err = errFunction
 	case *lua.LNilType:
-		data, err = json.Marshal(converted)
+		data = []byte("null")
 	case *lua.LState:
 		err = errState
 	case lua.LString:
@@ -89,6 +89,8 @@ func (worker *LuaWorker) requestHandle(wp *vm.WorkerProc) (interface{}, error) {
 		return worker.InvokeExecute(wp)
 	} else if txType == proto.TransactionType_ContractQuery {
 		return worker.QueryContract(wp)
+	} else if txType == proto.TransactionType_ContractUpgrade {
+		return worker.UpgradeContract(wp)
 	}
 
 	return nil, errors.New(fmt.Sprintf("luavm no method match transaction type: %d", txType))
@@ -111,8 +113,10 @@ func (worker *LuaWorker) InitContract(wp *vm.WorkerProc) (interface{}, error) {
 		return false, err
 	}
 
-	if _, ok := ok.(bool); !ok {
+	if initialized, isBool := ok.(bool); !isBool {
 		return false, errors.New("InitContract execContract result type is not bool")
+	} else if !initialized {
+		return false, vm.ErrResultFalse
 	}
 
 	err = worker.workerProc.CCallCommit()
@@ -145,8 +149,73 @@ func (worker *LuaWorker) InvokeExecute(wp *vm.WorkerProc) (interface{}, error) {
 		return false, err
 	}
 
-	if _, ok := ok.(bool); !ok {
+	if invoked, isBool := ok.(bool); !isBool {
 		return false, errors.New("RealExecute execContract result type is not bool")
+	} else if !invoked {
+		return false, vm.ErrResultFalse
+	}
+
+	err = worker.workerProc.CCallCommit()
+
+	if err != nil {
+		log.Errorf("commit all change error contractAddr:%s, errmsg:%s\n", worker.workerProc.ContractData.ContractAddr, err.Error())
+		return false, err
+	}
+
+	return ok, err
+}
+
+// CallContract runs the Invoke function of a contract called by another contract and commits its changes,
+// the result is the true or string Invoke returns, false fails the call
+func (worker *LuaWorker) CallContract(wp *vm.WorkerProc) (interface{}, error) {
+	worker.resetProc(wp)
+	if len(wp.ContractData.ContractCode) == 0 {
+		code, err := worker.GetContractCode()
+		if err != nil {
+			return nil, errors.New("can't get contract code")
+		}
+		wp.ContractData.ContractCode = string(code)
+	}
+
+	result, err := worker.execContract(wp.ContractData, "Invoke")
+	if err != nil {
+		return nil, err
+	}
+	switch r := result.(type) {
+	case bool:
+		if !r {
+			return nil, vm.ErrResultFalse
+		}
+	case string:
+	default:
+		return nil, errors.New("CallContract execContract result type is not bool or string")
+	}
+
+	if err := worker.workerProc.CCallCommit(); err != nil {
+		log.Errorf("commit all change error contractAddr:%s, errmsg:%s\n", worker.workerProc.ContractData.ContractAddr, err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpgradeContract replaces the code of a contract with the code of the upgrade transaction
+// and runs the Migrate function of the new code if it has one, the upgrade fails unless Migrate returns true
+func (worker *LuaWorker) UpgradeContract(wp *vm.WorkerProc) (interface{}, error) {
+	worker.resetProc(wp)
+	err := worker.txTransfer()
+	if err != nil {
+		return nil, err
+	}
+
+	wp.ContractData.ContractCode = string(wp.ContractData.Transaction.GetContractSpec().GetCode())
+	ok, err := worker.execContract(wp.ContractData, "Migrate")
+	if err != nil {
+		return false, err
+	}
+
+	if migrated, isBool := ok.(bool); !isBool || !migrated {
+		return false, errors.New("UpgradeContract Migrate result is not true")
 	}
 
 	err = worker.workerProc.CCallCommit()
@@ -161,10 +230,21 @@ func (worker *LuaWorker) InvokeExecute(wp *vm.WorkerProc) (interface{}, error) {
 
 func (worker *LuaWorker) QueryContract(wp *vm.WorkerProc) ([]byte, error) {
 	worker.resetProc(wp)
+	if len(wp.ContractData.ContractCode) == 0 {
+		code, err := worker.GetContractCode()
+		if err != nil {
+			return nil, errors.New("can't get contract code")
+		}
+		wp.ContractData.ContractCode = string(code)
+	}
+
 	value, err := worker.execContract(wp.ContractData, "Query")
 	if err != nil {
 		return nil, err
 	}
+	if err := worker.workerProc.CheckReadOnly(); err != nil {
+		return nil, err
+	}
 
 	result, ok := value.(string)
 	if !ok {
@@ -187,6 +267,7 @@ func (worker *LuaWorker) resetProc(wp *vm.WorkerProc) {
 	worker.workerProc = wp
 	worker.workerProc.StateChangeQueue = vm.NewStateQueue()
 	worker.workerProc.TransferQueue = vm.NewTransferQueue()
+	worker.workerProc.EventQueue = vm.NewEventQueue()
 	worker.L = worker.newState()
 	loader := func(L *lua.LState) int {
 		mod := L.SetFuncs(L.NewTable(), exporter(worker.workerProc)) // register functions to the table
@@ -251,7 +332,8 @@ func (worker *LuaWorker) execContract(cd *vm.ContractData, funcName string) (int
 	//}
 	//worker.L.PreloadModule("ZIP", loader)
 
-	_, ok := worker.luaProto[cd.ContractAddr]
+	// compiled code is cached by the code itself, an upgrade replaces the code at an address
+	_, ok := worker.luaProto[cd.ContractCode]
 	if !ok {
 		chunk, err := parse.Parse(strings.NewReader(cd.ContractCode), "<string>")
 		if err != nil {
@@ -261,14 +343,14 @@ func (worker *LuaWorker) execContract(cd *vm.ContractData, funcName string) (int
 		if err != nil {
 			return nil, err
 		}
-		worker.luaProto[cd.ContractAddr] = proto
+		worker.luaProto[cd.ContractCode] = proto
 	}
 
 	fn := &lua.LFunction{
 		IsG: false,
 		Env: worker.L.Env,
 
-		Proto:     worker.luaProto[cd.ContractAddr],
+		Proto:     worker.luaProto[cd.ContractCode],
 		GFunction: nil,
 		Upvalues:  make([]*lua.Upvalue, 0)}
 	worker.L.Push(fn)
@@ -362,10 +444,14 @@ func (worker *LuaWorker) openLib(L *lua.LState, libName string, libFunc lua.LGFu
 	L.Call(1, 0)
 }
 
-// call lua function(Init, Invoke)
+// call lua function(Init, Invoke, Migrate), code without a Migrate function needs no migration
 func (worker *LuaWorker) callLuaFunc(L *lua.LState, funcName string, params ...string) (interface{}, error) {
+	fn := L.GetGlobal(funcName)
+	if fn == lua.LNil && "Migrate" == funcName {
+		return true, nil
+	}
 	p := lua.P{
-		Fn:      L.GetGlobal(funcName),
+		Fn:      fn,
 		NRet:    1,
 		Protect: true,
 	}
@@ -20,8 +20,11 @@ package luavm
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"math/big"
 
 	lua "github.com/yuin/gopher-lua"
+	"github.com/zipper-project/zipper/common/db"
 	"github.com/zipper-project/zipper/common/utils"
 	ltyes "github.com/zipper-project/zipper/ledger/balance"
 )
@@ -135,11 +138,27 @@ func byteToLValue(buf *bytes.Buffer) (lua.LValue, error) {
 	return nil, errors.New("not support data type")
 }
 
+// kvsToLValue returns the states as an array of {key = ..., value = ...} tables
+func kvsToLValue(kvs []*db.KeyValue) (lua.LValue, error) {
+	tb := new(lua.LTable)
+	for _, kv := range kvs {
+		value, err := byteToLValue(bytes.NewBuffer(kv.Value))
+		if err != nil {
+			return nil, err
+		}
+		record := new(lua.LTable)
+		record.RawSetString("key", lua.LString(string(kv.Key)))
+		record.RawSetString("value", value)
+		tb.Append(record)
+	}
+	return tb, nil
+}
+
 func objToLValue(balance *ltyes.Balance) lua.LValue {
 	//tb := new(lua.LTable)
 	amountTb := new(lua.LTable)
 	for k, v := range balance.Amounts {
-		amountTb.RawSetInt(int(k), lua.LNumber(v))
+		amountTb.RawSetInt(int(k), amountToLValue(v))
 	}
 	//tb.RawSet(lua.LString("Amounts"), amountTb)
 	//tb.RawSet(lua.LString("Nonce"), lua.LNumber(balance.Nonce))
@@ -147,3 +166,28 @@ func objToLValue(balance *ltyes.Balance) lua.LValue {
 
 	return amountTb
 }
+
+// maxExactNumber is the largest integer a lua number holds exactly
+var maxExactNumber = big.NewInt(1 << 53)
+
+// amountToLValue returns an amount as a lua number, or as a decimal string if a number can't hold it exactly
+func amountToLValue(amount *big.Int) lua.LValue {
+	if new(big.Int).Abs(amount).Cmp(maxExactNumber) <= 0 {
+		return lua.LNumber(amount.Int64())
+	}
+	return lua.LString(amount.String())
+}
+
+// lvalueToAmount returns the amount given to a contract function as a lua number or a decimal string
+func lvalueToAmount(value lua.LValue) (*big.Int, error) {
+	switch v := value.(type) {
+	case lua.LNumber:
+		amount, _ := new(big.Float).SetFloat64(float64(v)).Int(nil)
+		return amount, nil
+	case lua.LString:
+		if amount, ok := new(big.Int).SetString(string(v), 10); ok {
+			return amount, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid amount %s", value.String())
+}
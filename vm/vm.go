@@ -18,6 +18,9 @@
 package vm
 
 import (
+	"math/big"
+
+	"github.com/zipper-project/zipper/common/db"
 	ltyes "github.com/zipper-project/zipper/ledger/balance"
 	"github.com/zipper-project/zipper/ledger/state"
 	"github.com/zipper-project/zipper/proto"
@@ -39,19 +42,26 @@ type ISmartConstract interface {
 
 	ComplexQuery(key string) ([]byte, error)
 
-	GetBalance(addr string, assetID uint32) (int64, error)
+	GetBalance(addr string, assetID uint32) (*big.Int, error)
 
 	GetCurrentBlockHeight() uint32
 
-	AddTransfer(fromAddr, toAddr string, assetID uint32, amount, fee int64) error
+	AddTransfer(fromAddr, toAddr string, assetID uint32, amount, fee *big.Int) error
 
 	Transfer(tx *proto.Transaction) error
 
 	GetBalances(addr string) (*ltyes.Balance, error)
 
-	//GetByPrefix(prefix string) ([]*db.KeyValue, error)
+	GetByPrefix(prefix, startKey string, count int) ([]*db.KeyValue, error)
+
+	GetByRange(startKey, limitKey string, count int) ([]*db.KeyValue, error)
+
+	Emit(name string, data []byte) error
+
+	EnterContract(tx *proto.Transaction) error
+
+	ExitContract() error
 
-	//GetByRange(startKey, limitKey string) ([]*db.KeyValue, error)
 	CallBack(response *state.CallBackResponse) error
 }
 